@@ -0,0 +1,344 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ChangePassword handler
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+
+	var changeRequest struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&changeRequest); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var user models.User
+	if err := utils.DB.First(&user, userID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Akun yang dibuat lewat identity provider belum punya password, jadi boleh menetapkan password pertama tanpa password lama
+	if (!user.PasswordUnset && changeRequest.CurrentPassword == "") || changeRequest.NewPassword == "" {
+		http.Error(w, "Current password and new password are required", http.StatusBadRequest)
+		return
+	}
+
+	if !user.PasswordUnset {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(changeRequest.CurrentPassword)); err != nil {
+			http.Error(w, "Current password is incorrect", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if !checkPasswordPolicy(w, changeRequest.NewPassword, user) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(changeRequest.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+
+	if err := utils.DB.Model(&user).Updates(map[string]interface{}{"password": string(hashedPassword), "password_unset": false}).Error; err != nil {
+		http.Error(w, "Failed to update password", http.StatusInternalServerError)
+		return
+	}
+
+	// Cabut semua token dan sesi lain, lalu beri token baru untuk sesi yang sedang dipakai
+	sessionID, _ := r.Context().Value(utils.SessionIDKey).(string)
+	if err := utils.InvalidateUserTokens(user.ID, sessionID); err != nil {
+		http.Error(w, "Failed to revoke existing tokens", http.StatusInternalServerError)
+		return
+	}
+	if err := utils.DB.First(&user, userID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	session, err := utils.ActiveSession(sessionID)
+	if err != nil {
+		http.Error(w, "Session has been revoked", http.StatusUnauthorized)
+		return
+	}
+
+	token, refreshToken, err := sessionTokens(user, *session)
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Password changed successfully",
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
+	})
+}
+
+// ChangeEmail handler
+func ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+
+	var changeRequest struct {
+		CurrentPassword string `json:"current_password"`
+		NewEmail        string `json:"new_email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&changeRequest); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	changeRequest.NewEmail = strings.TrimSpace(changeRequest.NewEmail)
+
+	var user models.User
+	if err := utils.DB.First(&user, userID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Akun yang hanya memakai identity provider tidak punya password untuk dikonfirmasi
+	if (!user.PasswordUnset && changeRequest.CurrentPassword == "") || changeRequest.NewEmail == "" {
+		http.Error(w, "Current password and new email are required", http.StatusBadRequest)
+		return
+	}
+
+	if !user.PasswordUnset {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(changeRequest.CurrentPassword)); err != nil {
+			http.Error(w, "Current password is incorrect", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if changeRequest.NewEmail == user.Email {
+		http.Error(w, "New email is the same as the current email", http.StatusBadRequest)
+		return
+	}
+
+	var existingUser models.User
+	if err := utils.DB.Where("email = ?", changeRequest.NewEmail).First(&existingUser).Error; err == nil {
+		http.Error(w, "Email already registered", http.StatusConflict)
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Email baru baru berlaku setelah link verifikasi di alamat baru dibuka
+	if err := utils.SendVerificationEmail(user, changeRequest.NewEmail); err != nil {
+		log.Println("Error sending verification email:", err)
+		http.Error(w, "Failed to send verification email", http.StatusInternalServerError)
+		return
+	}
+
+	// Beri tahu alamat lama agar pemilik akun sadar jika ini bukan ulahnya
+	if err := utils.GetMailer().Send(utils.Mail{
+		To:      user.Email,
+		Subject: "Your Nyanime email address is being changed",
+		Body: fmt.Sprintf("Hi %s,\n\nA request was made to change your Nyanime email address to %s.\n"+
+			"If this was not you, reset your password immediately.\n", user.Username, changeRequest.NewEmail),
+	}); err != nil {
+		log.Println("Error sending email change notice:", err)
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Verification email sent to the new address; the change takes effect once it is confirmed",
+	})
+}
+
+// ExportUserData handler (GET /user/export?format=json|zip)
+func ExportUserData(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "zip" {
+		http.Error(w, "Format must be json or zip", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+	export, err := utils.BuildUserExport(userID)
+	if err != nil {
+		log.Println("Error building user export:", err)
+		http.Error(w, "Failed to export data", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("nyanime-export-%d-%s.%s", userID, export.ExportedAt.Format("20060102"), format)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if format == "zip" {
+		w.Header().Set("Content-Type", "application/zip")
+		w.WriteHeader(http.StatusOK)
+		if err := utils.WriteUserExportZip(w, export); err != nil {
+			log.Println("Error writing export archive:", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(export)
+}
+
+// DeleteAccount handler (DELETE /user)
+func DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+
+	var deleteRequest struct {
+		Password string `json:"password"`
+		Mode     string `json:"mode"` // "anonymize" (default) atau "delete"
+	}
+	if err := json.NewDecoder(r.Body).Decode(&deleteRequest); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if deleteRequest.Mode == "" {
+		deleteRequest.Mode = utils.DeletionModeAnonymize
+	}
+	if !utils.ValidDeletionMode(deleteRequest.Mode) {
+		http.Error(w, utils.ErrInvalidDeletionMode.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var user models.User
+	if err := utils.DB.First(&user, userID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Konfirmasi dengan password, kecuali akun yang hanya memakai identity provider
+	if !user.PasswordUnset {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(deleteRequest.Password)); err != nil {
+			http.Error(w, "Password is incorrect", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	scheduledAt, err := utils.ScheduleAccountDeletion(user, deleteRequest.Mode)
+	if err != nil {
+		if errors.Is(err, utils.ErrDeletionAlreadyQueued) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Println("Error scheduling account deletion:", err)
+		http.Error(w, "Failed to delete account", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+
+	// Masa tenggang 0: akun sudah dihapus
+	if scheduledAt.IsZero() {
+		w.WriteHeader(http.StatusNoContent) // 204 No Content
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":               "Account scheduled for deletion",
+		"deletion_scheduled_at": scheduledAt,
+		"mode":                  deleteRequest.Mode,
+	})
+}
+
+// CancelAccountDeletion handler (POST /user/deletion/cancel)
+func CancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+	if err := utils.CancelAccountDeletion(userID); err != nil {
+		if errors.Is(err, utils.ErrDeletionNotScheduled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to cancel account deletion", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Account deletion cancelled"})
+}
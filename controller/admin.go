@@ -0,0 +1,476 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// ClearLoginLockout handler
+func ClearLoginLockout(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email := r.URL.Query().Get("email")
+	ip := r.URL.Query().Get("ip")
+	if email == "" && ip == "" {
+		http.Error(w, "Email or IP is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ClearLoginLockout(email, ip); err != nil {
+		log.Println("Error clearing login lockout:", err)
+		http.Error(w, "Failed to clear lockout", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RecordAudit(r, utils.AuditLockoutClear, utils.AuditTargetLoginAttempt, email, map[string]interface{}{"email": email, "ip": ip})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Lockout cleared"})
+}
+
+// adminUserView adalah data user yang ditampilkan di panel admin (tanpa hash password dan secret)
+type adminUserView struct {
+	ID                    int        `json:"id"`
+	Username              string     `json:"username"`
+	Email                 string     `json:"email"`
+	Role                  string     `json:"role"`
+	VerifiedAt            *time.Time `json:"verified_at"`
+	TOTPEnabled           bool       `json:"totp_enabled"`
+	BannedAt              *time.Time `json:"banned_at"`
+	BanReason             string     `json:"ban_reason,omitempty"`
+	SuspendedUntil        *time.Time `json:"suspended_until"`
+	SuspendReason         string     `json:"suspend_reason,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+}
+
+func newAdminUserView(user models.User) adminUserView {
+	return adminUserView{
+		ID:                    user.ID,
+		Username:              user.Username,
+		Email:                 user.Email,
+		Role:                  user.Role,
+		VerifiedAt:            user.VerifiedAt,
+		TOTPEnabled:           user.TOTPEnabled,
+		BannedAt:              user.BannedAt,
+		BanReason:             user.BanReason,
+		SuspendedUntil:        user.SuspendedUntil,
+		SuspendReason:         user.SuspendReason,
+		PasswordResetRequired: user.PasswordResetRequired,
+	}
+}
+
+// GetUsers handler (GET /admin/users?q=&role=&status=&page=&limit=)
+func GetUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	page, limit := utils.ParsePagination(r, 20, 100)
+	query := utils.DB.Model(&models.User{})
+
+	// Pencarian berdasarkan username atau email
+	if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+		like := "%" + utils.EscapeLike(q) + "%"
+		query = query.Where("username LIKE ? OR email LIKE ?", like, like)
+	}
+	if role := r.URL.Query().Get("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	switch r.URL.Query().Get("status") {
+	case "":
+	case "banned":
+		query = query.Where("banned_at IS NOT NULL")
+	case "suspended":
+		query = query.Where("suspended_until > ?", time.Now())
+	case "active":
+		query = query.Where("banned_at IS NULL AND (suspended_until IS NULL OR suspended_until <= ?)", time.Now())
+	default:
+		http.Error(w, "Invalid status filter", http.StatusBadRequest)
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+		return
+	}
+
+	var users []models.User
+	if err := query.Order("id").Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
+		http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+		return
+	}
+
+	data := make([]adminUserView, 0, len(users))
+	for _, user := range users {
+		data = append(data, newAdminUserView(user))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": data,
+		"meta": map[string]interface{}{"page": page, "limit": limit, "total": total},
+	})
+}
+
+// GetUser handler (GET /admin/users/{id})
+func GetUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	user, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newAdminUserView(user))
+}
+
+// loadTargetUser mengambil user dari parameter {id} dan menulis error jika tidak ada
+func loadTargetUser(w http.ResponseWriter, r *http.Request) (models.User, bool) {
+	var user models.User
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return user, false
+	}
+	if err := utils.DB.First(&user, targetID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return user, false
+	}
+	return user, true
+}
+
+// canRestrict mengecek apakah aktor boleh mem-ban/suspend target.
+// Tidak boleh membatasi diri sendiri, dan staf yang bisa mem-ban hanya bisa dibatasi oleh pengelola user.
+func canRestrict(w http.ResponseWriter, r *http.Request, target models.User) bool {
+	if target.ID == r.Context().Value(utils.UserIDKey).(int) {
+		http.Error(w, "You cannot restrict your own account", http.StatusForbidden)
+		return false
+	}
+
+	perms, err := utils.ResolvePermissions(target.Role)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return false
+	}
+	for _, p := range perms {
+		if (p == utils.PermUserBan || p == utils.PermUserManage) && !utils.HasPermission(r, utils.PermUserManage) {
+			http.Error(w, "Forbidden: Cannot restrict another staff member", http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+// BanUser handler (POST /admin/users/{id}/ban)
+func BanUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := loadTargetUser(w, r)
+	if !ok || !canRestrict(w, r, user) {
+		return
+	}
+
+	var banRequest struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&banRequest); err != nil || strings.TrimSpace(banRequest.Reason) == "" {
+		http.Error(w, "Reason is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.BanUser(user.ID, banRequest.Reason); err != nil {
+		log.Println("Error banning user:", err)
+		http.Error(w, "Failed to ban user", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RecordAudit(r, utils.AuditUserBan, utils.AuditTargetUser, user.ID, map[string]interface{}{"reason": banRequest.Reason})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "User banned"})
+}
+
+// UnbanUser handler (DELETE /admin/users/{id}/ban)
+func UnbanUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+	if user.BannedAt == nil {
+		http.Error(w, "User is not banned", http.StatusConflict)
+		return
+	}
+
+	if err := utils.UnbanUser(user.ID); err != nil {
+		http.Error(w, "Failed to unban user", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RecordAudit(r, utils.AuditUserUnban, utils.AuditTargetUser, user.ID, map[string]interface{}{"previous_reason": user.BanReason})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "User unbanned"})
+}
+
+// SuspendUser handler (POST /admin/users/{id}/suspend)
+// Body: {"until": "2026-01-02T15:04:05Z"} atau {"duration": "72h"}, ditambah "reason".
+func SuspendUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := loadTargetUser(w, r)
+	if !ok || !canRestrict(w, r, user) {
+		return
+	}
+
+	var suspendRequest struct {
+		Until    *time.Time `json:"until"`
+		Duration string     `json:"duration"`
+		Reason   string     `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&suspendRequest); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(suspendRequest.Reason) == "" {
+		http.Error(w, "Reason is required", http.StatusBadRequest)
+		return
+	}
+
+	var until time.Time
+	switch {
+	case suspendRequest.Until != nil:
+		until = *suspendRequest.Until
+	case suspendRequest.Duration != "":
+		d, err := time.ParseDuration(suspendRequest.Duration)
+		if err != nil || d <= 0 {
+			http.Error(w, "Invalid duration", http.StatusBadRequest)
+			return
+		}
+		until = time.Now().Add(d)
+	default:
+		http.Error(w, "Either until or duration is required", http.StatusBadRequest)
+		return
+	}
+	if !until.After(time.Now()) {
+		http.Error(w, "Suspension must end in the future", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.SuspendUser(user.ID, until, suspendRequest.Reason); err != nil {
+		log.Println("Error suspending user:", err)
+		http.Error(w, "Failed to suspend user", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RecordAudit(r, utils.AuditUserSuspend, utils.AuditTargetUser, user.ID, map[string]interface{}{"reason": suspendRequest.Reason, "until": until})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "User suspended", "suspended_until": until})
+}
+
+// UnsuspendUser handler (DELETE /admin/users/{id}/suspend)
+func UnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+	if user.SuspendedUntil == nil || !time.Now().Before(*user.SuspendedUntil) {
+		http.Error(w, "User is not suspended", http.StatusConflict)
+		return
+	}
+
+	if err := utils.LiftSuspension(user.ID); err != nil {
+		http.Error(w, "Failed to lift suspension", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RecordAudit(r, utils.AuditUserUnsuspend, utils.AuditTargetUser, user.ID, map[string]interface{}{"previous_until": user.SuspendedUntil})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Suspension lifted"})
+}
+
+// ForcePasswordReset handler (POST /admin/users/{id}/password-reset)
+func ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := loadTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	// Reset paksa mencabut semua sesi dan API key, jadi tidak boleh dipakai pada diri sendiri
+	// atau pada akun dengan permission yang tidak dimiliki aktor (sama seperti AssignUserRole)
+	if user.ID == r.Context().Value(utils.UserIDKey).(int) {
+		http.Error(w, "You cannot force a password reset on your own account", http.StatusForbidden)
+		return
+	}
+	if !utils.HasPermission(r, utils.PermRoleManage) {
+		missing, err := utils.MissingRolePermission(r, user.Role)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if missing != "" {
+			http.Error(w, "Forbidden: User has permission "+missing+" that you do not have", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := utils.ForcePasswordReset(user); err != nil {
+		log.Println("Error forcing password reset:", err)
+		http.Error(w, "Failed to force password reset", http.StatusInternalServerError)
+		return
+	}
+
+	utils.RecordAudit(r, utils.AuditUserPasswordReset, utils.AuditTargetUser, user.ID, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password reset required; a reset link has been sent to the user"})
+}
+
+// GetAuditLog handler (GET /admin/audit-log?actor_id=&target_type=&target_id=&action=&page=&limit=)
+func GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	page, limit := utils.ParsePagination(r, 50, 200)
+	query := utils.DB.Model(&models.AuditLog{})
+
+	params := r.URL.Query()
+	if actorID := params.Get("actor_id"); actorID != "" {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if action := params.Get("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if targetType := params.Get("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID := params.Get("target_id"); targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		http.Error(w, "Failed to fetch audit log", http.StatusInternalServerError)
+		return
+	}
+
+	entries := []models.AuditLog{}
+	if err := query.Order("created_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&entries).Error; err != nil {
+		http.Error(w, "Failed to fetch audit log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": entries,
+		"meta": map[string]interface{}{"page": page, "limit": limit, "total": total},
+	})
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// GetAPIKeys handler
+func GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+	keys, err := utils.ListAPIKeys(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch API keys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(keys)
+}
+
+// CreateAPIKey handler
+func CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var keyRequest struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"` // 0 berarti tidak kedaluwarsa
+	}
+	if err := json.NewDecoder(r.Body).Decode(&keyRequest); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(keyRequest.Scopes) == 0 {
+		keyRequest.Scopes = []string{utils.ScopeRead}
+	}
+	if keyRequest.ExpiresInDays < 0 {
+		http.Error(w, "expires_in_days must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+	role, _ := r.Context().Value(utils.UserRoleKey).(string)
+	mfa, _ := r.Context().Value(utils.UserMFAKey).(bool)
+
+	// Scope permission hanya boleh diminta jika role pengguna memilikinya
+	perms, err := utils.ResolvePermissions(role)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := utils.ValidateScopes(keyRequest.Scopes, perms); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var expiresAt *time.Time
+	if keyRequest.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, keyRequest.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	raw, key, err := utils.CreateAPIKey(userID, keyRequest.Name, keyRequest.Scopes, expiresAt, mfa)
+	if err != nil {
+		if errors.Is(err, utils.ErrAPIKeyLimitReached) {
+			http.Error(w, "API key limit reached; revoke an unused key first", http.StatusConflict)
+			return
+		}
+		log.Println("Error creating API key:", err)
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Store this key now; it will not be shown again",
+		"api_key": raw,
+		"key":     key,
+	})
+}
+
+// RevokeAPIKey handler
+func RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	keyID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+	if err := utils.RevokeAPIKey(userID, uint(keyID)); err != nil {
+		if errors.Is(err, utils.ErrAPIKeyInvalid) {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
@@ -0,0 +1,617 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// writeCreditError memetakan error studio/staf/tokoh ke status HTTP
+func writeCreditError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrAnimeNotFound), errors.Is(err, utils.ErrStudioNotFound),
+		errors.Is(err, utils.ErrPersonNotFound), errors.Is(err, utils.ErrCharacterNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, utils.ErrStudioExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, utils.ErrUnknownCreditRef):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Println("Error managing credits:", err)
+		http.Error(w, "Failed to update credits", http.StatusInternalServerError)
+	}
+}
+
+// writeCreditPage mengirim satu halaman daftar studio/staf/tokoh
+func writeCreditPage(w http.ResponseWriter, data interface{}, page, limit int, total int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": data,
+		"meta": map[string]interface{}{"page": page, "limit": limit, "total": total},
+	})
+}
+
+// writeJSON mengirim respons JSON dengan status tertentu
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// GetStudios handler
+// Query: q, page, limit
+func GetStudios(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	page, limit := utils.ParsePagination(r, 20, 100)
+	studios, total, err := utils.ListStudios(r.URL.Query().Get("q"), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch studios", http.StatusInternalServerError)
+		return
+	}
+	writeCreditPage(w, studios, page, limit, total)
+}
+
+// GetStudio handler
+func GetStudio(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid studio ID", http.StatusBadRequest)
+		return
+	}
+	studio, err := utils.GetStudio(id)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, studio)
+}
+
+// CreateStudio handler
+func CreateStudio(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input utils.StudioInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	studio, err := utils.CreateStudio(input)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, studio)
+}
+
+// UpdateStudio handler
+func UpdateStudio(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid studio ID", http.StatusBadRequest)
+		return
+	}
+
+	var input utils.StudioInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	studio, err := utils.UpdateStudio(id, input)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, studio)
+}
+
+// DeleteStudio handler
+func DeleteStudio(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid studio ID", http.StatusBadRequest)
+		return
+	}
+	if err := utils.DeleteStudio(id); err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
+
+// GetStudioAnime handler (GET /studios/{id}/anime)
+func GetStudioAnime(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid studio ID", http.StatusBadRequest)
+		return
+	}
+	animes, err := utils.GetStudioAnime(id)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, animes)
+}
+
+// GetPeople handler
+// Query: q, page, limit
+func GetPeople(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	page, limit := utils.ParsePagination(r, 20, 100)
+	people, total, err := utils.ListPeople(r.URL.Query().Get("q"), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch people", http.StatusInternalServerError)
+		return
+	}
+	writeCreditPage(w, people, page, limit, total)
+}
+
+// GetPerson handler
+func GetPerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid person ID", http.StatusBadRequest)
+		return
+	}
+	person, err := utils.GetPerson(id)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, person)
+}
+
+// CreatePerson handler
+func CreatePerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input utils.PersonInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	person, err := utils.CreatePerson(input)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, person)
+}
+
+// UpdatePerson handler
+func UpdatePerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid person ID", http.StatusBadRequest)
+		return
+	}
+
+	var input utils.PersonInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	person, err := utils.UpdatePerson(id, input)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, person)
+}
+
+// DeletePerson handler
+func DeletePerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid person ID", http.StatusBadRequest)
+		return
+	}
+	if err := utils.DeletePerson(id); err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
+
+// GetPersonCredits handler (GET /people/{id}/credits)
+func GetPersonCredits(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid person ID", http.StatusBadRequest)
+		return
+	}
+	credits, err := utils.GetPersonCredits(id)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, credits)
+}
+
+// GetCharacters handler
+// Query: q, page, limit
+func GetCharacters(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	page, limit := utils.ParsePagination(r, 20, 100)
+	characters, total, err := utils.ListCharacters(r.URL.Query().Get("q"), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch characters", http.StatusInternalServerError)
+		return
+	}
+	writeCreditPage(w, characters, page, limit, total)
+}
+
+// GetCharacter handler
+func GetCharacter(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+	character, err := utils.GetCharacter(id)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, character)
+}
+
+// CreateCharacter handler
+func CreateCharacter(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input utils.CharacterInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	character, err := utils.CreateCharacter(input)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, character)
+}
+
+// UpdateCharacter handler
+func UpdateCharacter(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+
+	var input utils.CharacterInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	character, err := utils.UpdateCharacter(id, input)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, character)
+}
+
+// DeleteCharacter handler
+func DeleteCharacter(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+	if err := utils.DeleteCharacter(id); err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
+
+// GetAnimeCredits handler (GET /anime/{id}/credits)
+func GetAnimeCredits(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+	credits, err := utils.GetAnimeCredits(utils.DB, animeID)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, credits)
+}
+
+// SetAnimeCredits handler (PUT /anime/{id}/studios, /staff atau /characters).
+// Body berisi daftar lengkap pengganti; daftar kosong menghapus semua kredit jenis tersebut.
+func SetAnimeCredits(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "PUT, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch mux.Vars(r)["kind"] {
+	case "studios":
+		var links []utils.StudioLink
+		if err := json.NewDecoder(r.Body).Decode(&links); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if links, err = utils.NormalizeStudioLinks(links); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = utils.SetAnimeStudios(animeID, links)
+	case "staff":
+		var links []utils.StaffLink
+		if err := json.NewDecoder(r.Body).Decode(&links); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if links, err = utils.NormalizeStaffLinks(links); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = utils.SetAnimeStaff(animeID, links)
+	case "characters":
+		var links []utils.CharacterLink
+		if err := json.NewDecoder(r.Body).Decode(&links); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if links, err = utils.NormalizeCharacterLinks(links); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = utils.SetAnimeCharacters(animeID, links)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+
+	credits, err := utils.GetAnimeCredits(utils.DB, animeID)
+	if err != nil {
+		writeCreditError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, credits)
+}
@@ -0,0 +1,356 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// writeEpisodeError memetakan error season/episode ke status HTTP
+func writeEpisodeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrAnimeNotFound), errors.Is(err, utils.ErrSeasonNotFound), errors.Is(err, utils.ErrEpisodeNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, utils.ErrSeasonExists), errors.Is(err, utils.ErrEpisodeExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Println("Error managing episodes:", err)
+		http.Error(w, "Failed to update episodes", http.StatusInternalServerError)
+	}
+}
+
+// pathID membaca ID numerik dari variabel URL
+func pathID(r *http.Request, name string) (uint, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+	return uint(id), err == nil
+}
+
+// GetSeasons handler
+func GetSeasons(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+
+	seasons, err := utils.ListSeasons(animeID)
+	if err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(seasons)
+}
+
+// CreateSeason handler
+func CreateSeason(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+
+	var input utils.SeasonInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	season, err := utils.CreateSeason(animeID, input)
+	if err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(season)
+}
+
+// UpdateSeason handler
+func UpdateSeason(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	seasonID, ok2 := pathID(r, "season_id")
+	if !ok || !ok2 {
+		http.Error(w, "Invalid anime or season ID", http.StatusBadRequest)
+		return
+	}
+
+	var input utils.SeasonInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	season, err := utils.UpdateSeason(animeID, seasonID, input)
+	if err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(season)
+}
+
+// DeleteSeason handler
+func DeleteSeason(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	seasonID, ok2 := pathID(r, "season_id")
+	if !ok || !ok2 {
+		http.Error(w, "Invalid anime or season ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.DeleteSeason(animeID, seasonID); err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
+
+// GetEpisodes handler
+// Query: season_id (opsional)
+func GetEpisodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+
+	var seasonID *uint
+	if v := r.URL.Query().Get("season_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid season ID", http.StatusBadRequest)
+			return
+		}
+		sid := uint(id)
+		seasonID = &sid
+	}
+
+	episodes, err := utils.ListEpisodes(animeID, seasonID)
+	if err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(episodes)
+}
+
+// GetEpisode handler
+func GetEpisode(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	episodeID, ok2 := pathID(r, "episode_id")
+	if !ok || !ok2 {
+		http.Error(w, "Invalid anime or episode ID", http.StatusBadRequest)
+		return
+	}
+
+	episode, err := utils.GetEpisode(animeID, episodeID)
+	if err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(episode)
+}
+
+// CreateEpisode handler
+func CreateEpisode(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+
+	var input utils.EpisodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	episode, err := utils.CreateEpisode(animeID, input)
+	if err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(episode)
+}
+
+// UpdateEpisode handler
+func UpdateEpisode(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	episodeID, ok2 := pathID(r, "episode_id")
+	if !ok || !ok2 {
+		http.Error(w, "Invalid anime or episode ID", http.StatusBadRequest)
+		return
+	}
+
+	var input utils.EpisodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := input.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	episode, err := utils.UpdateEpisode(animeID, episodeID, input)
+	if err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(episode)
+}
+
+// DeleteEpisode handler
+func DeleteEpisode(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	episodeID, ok2 := pathID(r, "episode_id")
+	if !ok || !ok2 {
+		http.Error(w, "Invalid anime or episode ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.DeleteEpisode(animeID, episodeID); err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// genreRequest adalah body untuk membuat atau mengubah genre
+type genreRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// writeGenreError memetakan error genre ke status HTTP
+func writeGenreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrGenreNotFound):
+		http.Error(w, "Genre not found", http.StatusNotFound)
+	case errors.Is(err, utils.ErrGenreExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, utils.ErrInvalidGenre):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Println("Error managing genre:", err)
+		http.Error(w, "Failed to update genres", http.StatusInternalServerError)
+	}
+}
+
+// GetGenres handler
+func GetGenres(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	genres, err := utils.ListGenres(utils.DB)
+	if err != nil {
+		http.Error(w, "Failed to fetch genres", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(genres)
+}
+
+// CreateGenre handler
+func CreateGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var genreReq genreRequest
+	if err := json.NewDecoder(r.Body).Decode(&genreReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	genre, err := utils.CreateGenre(genreReq.Name, genreReq.Slug)
+	if err != nil {
+		writeGenreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(genre)
+}
+
+// UpdateGenre handler
+func UpdateGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	genreID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid genre ID", http.StatusBadRequest)
+		return
+	}
+
+	var genreReq genreRequest
+	if err := json.NewDecoder(r.Body).Decode(&genreReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	genre, err := utils.UpdateGenre(uint(genreID), genreReq.Name, genreReq.Slug)
+	if err != nil {
+		writeGenreError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(genre)
+}
+
+// DeleteGenre handler
+func DeleteGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	genreID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid genre ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.DeleteGenre(uint(genreID)); err != nil {
+		writeGenreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// writeImageError memetakan error upload gambar ke status HTTP
+func writeImageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrAnimeNotFound), errors.Is(err, utils.ErrImageNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, utils.ErrImageTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, utils.ErrUnsupportedImage):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, utils.ErrInvalidImage), errors.Is(err, utils.ErrInvalidImageKind):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Println("Error managing anime images:", err)
+		http.Error(w, "Failed to update anime image", http.StatusInternalServerError)
+	}
+}
+
+// readImageUpload membaca field "image" dari body multipart/form-data tanpa menyimpan
+// lebih dari MaxImageBytes di memori
+func readImageUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	// Sisa 1 MiB untuk header multipart dan field lain
+	r.Body = http.MaxBytesReader(w, r.Body, utils.MaxImageBytes+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("request must be multipart/form-data")
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, errors.New("image field is required")
+		}
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, utils.ErrImageTooLarge
+		}
+		if err != nil {
+			return nil, errors.New("invalid multipart body")
+		}
+		if part.FormName() != "image" {
+			part.Close()
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, utils.MaxImageBytes+1))
+		part.Close()
+		if errors.As(err, &maxErr) || int64(len(data)) > utils.MaxImageBytes {
+			return nil, utils.ErrImageTooLarge
+		}
+		if err != nil {
+			return nil, errors.New("invalid multipart body")
+		}
+		return data, nil
+	}
+}
+
+// UploadAnimeImage handler (POST /anime/{id}/images/{kind}), kind: cover atau banner.
+// Body multipart/form-data dengan file di field "image"; gambar lama diganti.
+func UploadAnimeImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+	kind, err := utils.ParseImageKind(mux.Vars(r)["kind"])
+	if err != nil {
+		writeImageError(w, err)
+		return
+	}
+
+	data, err := readImageUpload(w, r)
+	if err != nil {
+		if errors.Is(err, utils.ErrImageTooLarge) {
+			writeImageError(w, err)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	processed, err := utils.ProcessImage(data, kind)
+	if err != nil {
+		writeImageError(w, err)
+		return
+	}
+	img, err := utils.SaveAnimeImage(animeID, kind, processed)
+	if err != nil {
+		writeImageError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, struct {
+		models.AnimeImage
+		URLs *models.ImageURLs `json:"urls"`
+	}{img, utils.ImageURLs(img)})
+}
+
+// DeleteAnimeImage handler (DELETE /anime/{id}/images/{kind})
+func DeleteAnimeImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+	kind, err := utils.ParseImageKind(mux.Vars(r)["kind"])
+	if err != nil {
+		writeImageError(w, err)
+		return
+	}
+
+	if err := utils.DeleteAnimeImage(animeID, kind); err != nil {
+		writeImageError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,351 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LoginTwoFactor handler
+func LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var mfaRequest struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&mfaRequest); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if mfaRequest.MFAToken == "" || (mfaRequest.Code == "" && mfaRequest.RecoveryCode == "") {
+		http.Error(w, "MFA token and code are required", http.StatusBadRequest)
+		return
+	}
+
+	token, claims, err := utils.VerifyToken(mfaRequest.MFAToken)
+	if err != nil || !token.Valid || !claims.MFAPending || utils.IsBlacklisted(mfaRequest.MFAToken) {
+		http.Error(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return
+	}
+
+	var user models.User
+	if err := utils.DB.First(&user, claims.UserID).Error; err != nil || user.TokenVersion != claims.TokenVersion || !user.TOTPEnabled {
+		http.Error(w, "Invalid or expired MFA token", http.StatusUnauthorized)
+		return
+	}
+
+	clientIP := utils.ClientIP(r)
+	block, err := utils.CheckLogin(user.Email, clientIP)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if block.Blocked() {
+		writeLoginBlocked(w, block)
+		return
+	}
+
+	ok, err := utils.VerifySecondFactor(user, mfaRequest.Code, mfaRequest.RecoveryCode)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		loginFailed(w, user.Email, clientIP, "Invalid two-factor code")
+		return
+	}
+
+	// Token sementara hanya boleh ditukar sekali
+	utils.AddToBlacklist(mfaRequest.MFAToken)
+	if err := utils.RecordLoginSuccess(user.Email); err != nil {
+		log.Println("Error clearing login attempts:", err)
+	}
+
+	writeLoginSuccess(w, r, user, true)
+}
+
+// SetupTwoFactor handler
+func SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+
+	var user models.User
+	if err := utils.DB.First(&user, userID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if user.TOTPEnabled {
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	// Secret disimpan dulu tetapi 2FA belum aktif sampai kode pertama dikonfirmasi
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		http.Error(w, "Failed to generate secret", http.StatusInternalServerError)
+		return
+	}
+	if err := utils.DB.Model(&user).Updates(map[string]interface{}{"totp_secret": secret, "totp_last_counter": 0}).Error; err != nil {
+		http.Error(w, "Failed to save secret", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"secret":           secret,
+		"provisioning_uri": utils.TOTPProvisioningURI(user.Email, secret),
+		"period":           utils.TOTPPeriod,
+		"digits":           utils.TOTPDigits,
+	})
+}
+
+// EnableTwoFactor handler
+func EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+
+	var enableRequest struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&enableRequest); err != nil || enableRequest.Code == "" {
+		http.Error(w, "Code is required", http.StatusBadRequest)
+		return
+	}
+
+	var user models.User
+	if err := utils.DB.First(&user, userID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if user.TOTPEnabled {
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if user.TOTPSecret == "" {
+		http.Error(w, "Two-factor setup has not been started", http.StatusBadRequest)
+		return
+	}
+
+	// Sesi dicek lebih dulu agar 2FA tidak aktif tanpa token baru untuk pengguna
+	sessionID, _ := r.Context().Value(utils.SessionIDKey).(string)
+	session, err := utils.ActiveSession(sessionID)
+	if err != nil {
+		http.Error(w, "Session has been revoked", http.StatusUnauthorized)
+		return
+	}
+
+	counter, ok := utils.ValidateTOTP(user.TOTPSecret, enableRequest.Code, time.Now(), user.TOTPLastCounter)
+	if !ok {
+		http.Error(w, "Invalid two-factor code", http.StatusUnauthorized)
+		return
+	}
+
+	// Kode TOTP baru saja dibuktikan, jadi sesi ini langsung dianggap sesi ber-2FA
+	codes, err := utils.EnableTwoFactor(user.ID, counter, session.ID)
+	if errors.Is(err, utils.ErrTwoFactorAlreadyEnabled) {
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to enable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	session.MFA = true
+
+	token, err := utils.GenerateToken(user, *session)
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+		"token":          token,
+		"expires_in":     int(utils.AccessTokenTTL.Seconds()),
+	})
+}
+
+// DisableTwoFactor handler
+func DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+
+	var disableRequest struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&disableRequest); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var user models.User
+	if err := utils.DB.First(&user, userID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if !user.TOTPEnabled {
+		http.Error(w, "Two-factor authentication is not enabled", http.StatusBadRequest)
+		return
+	}
+	required, err := utils.RoleRequiresTwoFactor(user.Role)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if required {
+		http.Error(w, "Two-factor authentication is mandatory for admin accounts", http.StatusForbidden)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(disableRequest.Password)); err != nil {
+		http.Error(w, "Password is incorrect", http.StatusUnauthorized)
+		return
+	}
+	ok, err := utils.VerifySecondFactor(user, disableRequest.Code, disableRequest.RecoveryCode)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Invalid two-factor code", http.StatusUnauthorized)
+		return
+	}
+
+	if err := utils.DB.Model(&user).Updates(map[string]interface{}{
+		"totp_enabled":      false,
+		"totp_secret":       "",
+		"totp_last_counter": 0,
+	}).Error; err != nil {
+		http.Error(w, "Failed to disable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	if err := utils.DB.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		log.Println("Error deleting recovery codes:", err)
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes handler
+func RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+
+	var regenerateRequest struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&regenerateRequest); err != nil || regenerateRequest.Code == "" {
+		http.Error(w, "Code is required", http.StatusBadRequest)
+		return
+	}
+
+	var user models.User
+	if err := utils.DB.First(&user, userID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if !user.TOTPEnabled {
+		http.Error(w, "Two-factor authentication is not enabled", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := utils.VerifySecondFactor(user, regenerateRequest.Code, "")
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Invalid two-factor code", http.StatusUnauthorized)
+		return
+	}
+
+	codes, err := utils.ReplaceRecoveryCodes(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to generate recovery codes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"recovery_codes": codes})
+}
@@ -3,10 +3,14 @@ package controller
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"NYANIMEBACKEND/models"
 	"NYANIMEBACKEND/utils"
@@ -36,14 +40,24 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var user models.User
-	err := json.NewDecoder(r.Body).Decode(&user)
+	// Hanya field ini yang boleh diisi klien; role, status akun, 2FA dan relasi diatur server
+	var registerRequest struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&registerRequest)
 	if err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	user := models.User{
+		Username: registerRequest.Username,
+		Email:    registerRequest.Email,
+		Password: registerRequest.Password,
+	}
 
-	log.Printf("User input: %+v\n", user)
+	log.Printf("User input: username=%q email=%q\n", user.Username, user.Email)
 
 	if user.Username == "" || user.Email == "" || user.Password == "" {
 		http.Error(w, "Username, email, and password are required", http.StatusBadRequest)
@@ -61,25 +75,34 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkPasswordPolicy(w, user.Password, user) {
+		return
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
 		return
 	}
 	user.Password = string(hashedPassword)
-	user.Role = "user"
+	user.Role = utils.RoleUser
 
 	if err := utils.DB.Create(&user).Error; err != nil {
 		http.Error(w, "Failed to register user", http.StatusInternalServerError)
 		return
 	}
 
+	// Kegagalan kirim email tidak membatalkan registrasi; pengguna bisa minta kirim ulang
+	if err := utils.SendVerificationEmail(user, user.Email); err != nil {
+		log.Println("Error sending verification email:", err)
+	}
+
 	user.Password = ""
 	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "User registered successfully",
+		"message": "User registered successfully, please check your email to verify your address",
 		"user":    user,
 	})
 }
@@ -111,20 +134,73 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Tolak lebih awal jika akun atau IP sedang diblokir karena terlalu banyak percobaan gagal
+	clientIP := utils.ClientIP(r)
+	block, err := utils.CheckLogin(loginRequest.Email, clientIP)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if block.Blocked() {
+		writeLoginBlocked(w, block)
+		return
+	}
+
 	var user models.User
 	if err := utils.DB.Where("email = ?", loginRequest.Email).First(&user).Error; err != nil {
-		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		loginFailed(w, loginRequest.Email, clientIP, "Invalid email or password")
 		return
 	}
 
 	// Check password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginRequest.Password)); err != nil {
-		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		loginFailed(w, loginRequest.Email, clientIP, "Invalid email or password")
+		return
+	}
+
+	// Password benar, tapi akun yang di-ban, di-suspend atau wajib reset password tidak boleh login.
+	// Dicek setelah password agar status akun tidak bocor ke pihak yang tidak tahu password.
+	if err := utils.AccountRestriction(user, time.Now()); err != nil {
+		writeAccountRestricted(w, err)
+		return
+	}
+	if user.PasswordResetRequired {
+		writeAccountRestricted(w, utils.ErrPasswordResetRequired)
 		return
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID, user.Role) // Pastikan user.ID dan user.Role ada
+	// Akun dengan 2FA harus menyelesaikan langkah kedua sebelum mendapat token penuh.
+	// Riwayat gagal baru dibersihkan setelah kode 2FA benar.
+	if user.TOTPEnabled {
+		mfaToken, err := utils.GenerateMFAPendingToken(user)
+		if err != nil {
+			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":      "Two-factor authentication required",
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"expires_in":   int(utils.MFAPendingTTL.Seconds()),
+		})
+		return
+	}
+
+	if err := utils.RecordLoginSuccess(loginRequest.Email); err != nil {
+		log.Println("Error clearing login attempts:", err)
+	}
+
+	writeLoginSuccess(w, r, user, false)
+}
+
+// writeLoginSuccess membuat token untuk pengguna yang sudah lolos autentikasi dan mengirim respons login
+func writeLoginSuccess(w http.ResponseWriter, r *http.Request, user models.User, mfa bool) {
+	// Buat sesi baru beserta JWT token dan refresh token-nya
+	token, refreshToken, err := startSession(r, user, mfa)
 	if err != nil {
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 		return
@@ -135,12 +211,54 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Login successful",
-		"user":    map[string]interface{}{"id": user.ID, "email": user.Email, "role": user.Role}, // Hanya mengembalikan informasi yang diperlukan
-		"token":   token,
+		"message":       "Login successful",
+		"user":          map[string]interface{}{"id": user.ID, "email": user.Email, "role": user.Role}, // Hanya mengembalikan informasi yang diperlukan
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
 	})
 }
 
+// writeAccountRestricted mengirim 403 untuk akun yang dibatasi admin
+func writeAccountRestricted(w http.ResponseWriter, err error) {
+	response := map[string]interface{}{"error": err.Error()}
+	var suspended *utils.AccountSuspendedError
+	switch {
+	case errors.As(err, &suspended):
+		response["suspended_until"] = suspended.Until
+	case errors.Is(err, utils.ErrPasswordResetRequired):
+		response["password_reset_required"] = true
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(response)
+}
+
+// loginFailed mencatat percobaan gagal dan mengirim respons yang sesuai
+func loginFailed(w http.ResponseWriter, email, clientIP, message string) {
+	block, err := utils.RecordLoginFailure(email, clientIP)
+	if err != nil {
+		log.Println("Error recording login failure:", err)
+	}
+	if block.Blocked() {
+		writeLoginBlocked(w, block)
+		return
+	}
+	http.Error(w, message, http.StatusUnauthorized)
+}
+
+// writeLoginBlocked mengirim 423 untuk akun terkunci atau 429 untuk backoff, beserta Retry-After
+func writeLoginBlocked(w http.ResponseWriter, block utils.LoginBlock) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(block.RetryAfter.Seconds()))))
+	if block.Locked {
+		http.Error(w, "Account temporarily locked due to too many failed login attempts", http.StatusLocked)
+		return
+	}
+	http.Error(w, "Too many failed login attempts, please try again later", http.StatusTooManyRequests)
+}
+
 // Logout handeler
 func Logout(w http.ResponseWriter, r *http.Request) {
 	// Handle OPTIONS request for CORS
@@ -170,6 +288,14 @@ func Logout(w http.ResponseWriter, r *http.Request) {
 	// Add the token to the blacklist
 	utils.AddToBlacklist(token)
 
+	// Tutup sesi saat ini; refresh token milik sesi ini ikut dicabut
+	if userID, ok := r.Context().Value(utils.UserIDKey).(int); ok {
+		sessionID, _ := r.Context().Value(utils.SessionIDKey).(string)
+		if err := utils.RevokeSession(userID, sessionID); err != nil && !errors.Is(err, utils.ErrSessionInactive) {
+			log.Println("Error revoking session:", err)
+		}
+	}
+
 	// Send success response
 	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
 	w.Header().Set("Content-Type", "text/plain") // Set content type
@@ -178,6 +304,11 @@ func Logout(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetAllAnime handler
+// Query: page, limit, cursor, genre, year_from, year_to, min_rating, sort, order
+//
+// Perubahan respons: dengan page, limit atau cursor hasilnya berupa {data, meta} dan dibatasi
+// 20 item per halaman (default). Tanpa ketiganya respons tetap array semua anime seperti dulu,
+// agar frontend lama tidak rusak; klien baru sebaiknya selalu mengirim limit.
 func GetAllAnime(w http.ResponseWriter, r *http.Request) {
 	// Cek metode OPTIONS untuk CORS
 	if r.Method == http.MethodOptions {
@@ -188,21 +319,210 @@ func GetAllAnime(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var animes []models.Anime
-	// Query untuk mengambil anime dengan rating akumulatif dari tabel animes
-	if err := utils.DB.Table("animes"). // Ganti "anime" dengan "animes"
-						Select("animes.*, COALESCE(AVG(reviews_new.rating), 0) as average_rating"). // Ganti "reviews" dengan "reviews_new"
-						Joins("LEFT JOIN reviews_new ON animes.id = reviews_new.anime_id").         // Ganti "reviews" dengan "reviews_new"
-						Group("animes.id").                                                         // Ganti "anime" dengan "animes"
-						Scan(&animes).Error; err != nil {
+	params, err := utils.ParseAnimeListParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := utils.ListAnime(utils.DB, params)
+	if err != nil {
 		log.Println("Error retrieving anime:", err) // Log error untuk debugging
 		http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
 		return
 	}
 
+	// Judul tampilan mengikuti ?lang=, preferensi user atau Accept-Language
+	ids := make([]uint, len(result.Items))
+	for i, item := range result.Items {
+		ids[i] = item.ID
+	}
+	displayTitles, err := utils.RequestDisplayTitles(r, ids)
+	if err != nil {
+		log.Println("Error retrieving anime titles:", err)
+		http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+		return
+	}
+	animes := make([]*models.Anime, len(result.Items))
+	for i := range result.Items {
+		result.Items[i].DisplayTitle = displayTitle(displayTitles, result.Items[i].ID, result.Items[i].Title)
+		animes[i] = &result.Items[i].Anime
+	}
+	if err := utils.AttachAnimeImages(utils.DB, animes); err != nil {
+		log.Println("Error retrieving anime images:", err)
+		http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(result.Total, 10))
+	w.Header().Set("Access-Control-Expose-Headers", "Link, X-Total-Count")
+	w.Header().Set("Vary", "Accept-Language")
+
+	// Kompatibilitas: tanpa page, limit atau cursor respons tetap berupa array seperti dulu.
+	// Bentuk {data, meta} hanya dipakai klien yang meminta pagination.
+	if params.Legacy {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result.Items)
+		return
+	}
+
+	// Jumlah anime per genre di antara hasil filter, untuk menampilkan pilihan filter genre
+	genreCounts, err := utils.GenreFacets(utils.DB, params)
+	if err != nil {
+		log.Println("Error counting genres:", err)
+		http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+		return
+	}
+
+	meta := map[string]interface{}{
+		"total":        result.Total,
+		"limit":        params.Limit,
+		"next_cursor":  result.NextCursor,
+		"genre_counts": genreCounts,
+	}
+	if !params.UseCursor {
+		meta["page"] = params.Page
+	}
+
+	if links := animeListLinks(r, params, result); links != "" {
+		w.Header().Set("Link", links)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(animes)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": result.Items,
+		"meta": meta,
+	})
+}
+
+// displayTitle mengambil judul tampilan anime, atau judul utama jika tidak ada yang cocok
+func displayTitle(titles map[uint]string, animeID uint, fallback string) string {
+	if title, ok := titles[animeID]; ok {
+		return title
+	}
+	return fallback
+}
+
+// animeListLinks membuat header Link (RFC 8288) untuk halaman pertama, sebelumnya dan berikutnya
+func animeListLinks(r *http.Request, params utils.AnimeListParams, result *utils.AnimeListResult) string {
+	link := func(rel string, change func(q url.Values)) string {
+		q := r.URL.Query()
+		change(q)
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, q.Encode(), rel)
+	}
+
+	links := []string{link("first", func(q url.Values) {
+		q.Del("cursor")
+		q.Set("page", "1")
+	})}
+	if !params.UseCursor && params.Page > 1 {
+		links = append(links, link("prev", func(q url.Values) { q.Set("page", strconv.Itoa(params.Page-1)) }))
+	}
+	if result.NextCursor != "" {
+		links = append(links, link("next", func(q url.Values) {
+			if params.UseCursor {
+				q.Set("cursor", result.NextCursor)
+			} else {
+				q.Set("page", strconv.Itoa(params.Page+1))
+			}
+		}))
+	}
+	return strings.Join(links, ", ")
+}
+
+// GetAnime handler
+// Query: reviews (jumlah review terbaru, default 5, maksimal 20)
+func GetAnime(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+
+	recent := 5
+	if v := r.URL.Query().Get("reviews"); v != "" {
+		recent, err = strconv.Atoi(v)
+		if err != nil || recent < 0 {
+			http.Error(w, "reviews must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		recent = min(recent, 20)
+	}
+
+	// Pemanggil anonim tidak punya user ID di context
+	viewerID, _ := r.Context().Value(utils.UserIDKey).(int)
+
+	detail, err := utils.GetAnimeDetail(utils.DB, uint(animeID), viewerID, recent)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Anime not found", http.StatusNotFound)
+			return
+		}
+		log.Println("Error retrieving anime detail:", err)
+		http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+		return
+	}
+
+	displayTitles, err := utils.RequestDisplayTitles(r, []uint{detail.ID})
+	if err != nil {
+		log.Println("Error retrieving anime titles:", err)
+		http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+		return
+	}
+	detail.DisplayTitle = displayTitle(displayTitles, detail.ID, detail.Title)
+	if err := utils.AttachAnimeImages(utils.DB, []*models.Anime{&detail.Anime}); err != nil {
+		log.Println("Error retrieving anime images:", err)
+		http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Vary", "Accept-Language")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(detail)
+}
+
+// animeRequest adalah body untuk membuat atau mengubah anime. Genre dikirim sebagai daftar
+// nama/slug di "genres", atau sebagai teks dipisah koma di "genre" untuk frontend lama.
+// Nama tak dikenal di "genres" ditolak, sedangkan di "genre" dibuat otomatis seperti dulu.
+type animeRequest struct {
+	models.Anime
+	GenreNames []string `json:"genres"`
+}
+
+// genreNames mengembalikan genre yang diminta, nil jika request tidak menyebut genre
+func (req animeRequest) genreNames() []string {
+	if req.GenreNames != nil {
+		return req.GenreNames
+	}
+	if strings.TrimSpace(req.Genre) != "" {
+		return utils.SplitGenreString(req.Genre)
+	}
+	return nil
+}
+
+// setGenres menyimpan genre anime; genre dari teks lama dibuat dulu jika belum ada
+func (req animeRequest) setGenres(tx *gorm.DB, anime *models.Anime, names []string) error {
+	if req.GenreNames == nil {
+		if err := utils.EnsureGenres(tx, names); err != nil {
+			return err
+		}
+	}
+	return utils.SetAnimeGenres(tx, anime, names)
 }
 
 // CreateAnime handler
@@ -220,13 +540,17 @@ func CreateAnime(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var anime models.Anime
-	err := json.NewDecoder(r.Body).Decode(&anime)
+	var req animeRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		log.Printf("Error decoding request body: %v", err) // Log kesalahan decoding
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	anime := req.Anime
+	genreNames := req.genreNames()
+	anime.Genre, anime.Genres = "", nil
+	anime.FranchiseID = nil // Franchise diatur lewat relasi anime
 
 	// Log data yang diterima
 	log.Printf("Received anime data: %+v", anime)
@@ -236,15 +560,30 @@ func CreateAnime(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Title is required", http.StatusBadRequest)
 		return
 	}
+	if err := utils.ApplyReleaseInfo(&anime, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Log sebelum menyimpan ke database
 	log.Printf("Creating anime: %+v", anime)
 
-	if err := utils.DB.Create(&anime).Error; err != nil {
+	err = utils.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Genres").Create(&anime).Error; err != nil {
+			return err
+		}
+		return req.setGenres(tx, &anime, genreNames)
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrUnknownGenre) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		log.Printf("Error creating anime: %v", err)
 		http.Error(w, "Failed to create anime", http.StatusInternalServerError)
 		return
 	}
+	utils.SyncAnimeIndex(anime.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -268,25 +607,78 @@ func EditAnime(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "ID is required", http.StatusBadRequest)
 		return
 	}
+	animeID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
 
-	var anime models.Anime
-	err := json.NewDecoder(r.Body).Decode(&anime)
+	var req animeRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	anime := req.Anime
+	genreNames := req.genreNames()
+	anime.Genre, anime.Genres = "", nil
+	anime.FranchiseID = nil // Franchise diatur lewat relasi anime
 
 	// Validasi data anime
 	if anime.Title == "" {
 		http.Error(w, "Title is required", http.StatusBadRequest)
 		return
 	}
+	if anime.AiringStatus != "" && !utils.ValidAiringStatus(anime.AiringStatus) {
+		http.Error(w, "Invalid airing status", http.StatusBadRequest)
+		return
+	}
 
 	// Update anime di database
-	if err := utils.DB.Model(&anime).Where("id = ?", id).Updates(anime).Error; err != nil {
+	err = utils.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&anime).Where("id = ?", animeID).Omit("Genres").Updates(anime).Error; err != nil {
+			return err
+		}
+
+		// Tanggal, status dan musim tayang dihitung ulang dari data yang sudah digabung
+		var stored models.Anime
+		if err := tx.First(&stored, animeID).Error; err != nil {
+			return err
+		}
+		if anime.ReleaseDate != "" && !anime.StartDate.Known() {
+			stored.StartDate = models.PartialDate{} // Frontend lama hanya mengirim releaseDate
+		}
+		if anime.AiringStatus == "" && stored.AiringStatus != models.AiringStatusCancelled {
+			stored.AiringStatus = ""
+		}
+		if err := utils.ApplyReleaseInfo(&stored, time.Now()); err != nil {
+			return err
+		}
+		if err := utils.SaveReleaseInfo(tx, &stored); err != nil {
+			return err
+		}
+		anime.ReleaseDate, anime.StartDate, anime.EndDate = stored.ReleaseDate, stored.StartDate, stored.EndDate
+		anime.AiringStatus, anime.BroadcastSeason, anime.SeasonYear = stored.AiringStatus, stored.BroadcastSeason, stored.SeasonYear
+		// Genre hanya diganti jika dikirim
+		if genreNames == nil {
+			return nil
+		}
+		anime.ID = uint(animeID)
+		return req.setGenres(tx, &anime, genreNames)
+	})
+	if err != nil {
+		if errors.Is(err, utils.ErrUnknownGenre) || errors.Is(err, utils.ErrInvalidReleaseInfo) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Anime not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Failed to update anime", http.StatusInternalServerError)
 		return
 	}
+	utils.SyncAnimeIndex(uint(animeID))
 
 	log.Printf("Editing anime with ID: %s", id)
 	log.Printf("Request body: %+v", anime)
@@ -325,10 +717,32 @@ func DeleteAnime(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := utils.DB.Delete(&anime).Error; err != nil {
+	var images []models.AnimeImage
+	err := utils.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if images, err = utils.DeleteAnimeImages(tx, anime.ID); err != nil {
+			return err
+		}
+		if err := utils.DeleteAnimeEpisodes(tx, anime.ID); err != nil {
+			return err
+		}
+		if err := utils.DeleteAnimeCredits(tx, anime.ID); err != nil {
+			return err
+		}
+		if err := utils.DeleteAnimeRelations(tx, anime.ID); err != nil {
+			return err
+		}
+		if err := utils.DeleteAnimeTitles(tx, anime.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&anime).Error
+	})
+	if err != nil {
 		http.Error(w, "Failed to delete anime", http.StatusInternalServerError)
 		return
 	}
+	utils.RemoveImageFiles(images)
+	utils.SyncAnimeIndex(anime.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -388,17 +802,9 @@ func AddReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var averageRating float64
-	if err := utils.DB.Table("reviews_new").
-		Select("COALESCE(AVG(rating), 0)").
-		Where("anime_id = ?", animeID).
-		Scan(&averageRating).Error; err != nil {
-		log.Println("Error calculating average rating:", err)
-	} else {
-		// Update average rating di tabel animes
-		if err := utils.DB.Model(&anime).Update("average_rating", averageRating).Error; err != nil {
-			log.Println("Error updating average rating:", err)
-		}
+	// Update average rating di tabel animes
+	if err := models.RecalculateAverageRating(utils.DB, anime.ID); err != nil {
+		log.Println("Error updating average rating:", err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -472,6 +878,12 @@ func EditReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Hanya pemilik review atau moderator yang boleh mengubah review
+	if !canModifyReview(r, review) {
+		http.Error(w, `{"error": "You can only edit your own review"}`, http.StatusForbidden)
+		return
+	}
+
 	// Decode body request ke dalam updatedReview
 	var updatedReview models.Review
 	err = json.NewDecoder(r.Body).Decode(&updatedReview)
@@ -500,6 +912,10 @@ func EditReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := models.RecalculateAverageRating(utils.DB, review.AnimeID); err != nil {
+		log.Println("Error updating average rating:", err)
+	}
+
 	// Logging
 	log.Printf("Editing review with ID: %d", reviewID)
 	log.Printf("Updated review data: %+v", review)
@@ -567,15 +983,41 @@ func DeleteReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var review models.Review
+	if err := utils.DB.First(&review, reviewID).Error; err != nil {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+
+	// Hanya pemilik review atau moderator yang boleh menghapus review
+	if !canModifyReview(r, review) {
+		http.Error(w, "You can only delete your own review", http.StatusForbidden)
+		return
+	}
+
 	// Hapus review
 	if err := utils.DB.Delete(&models.Review{}, reviewID).Error; err != nil {
 		http.Error(w, "Failed to delete review", http.StatusInternalServerError)
 		return
 	}
 
+	if review.UserID != r.Context().Value(utils.UserIDKey).(int) {
+		log.Printf("Review %d removed by moderator %v", reviewID, r.Context().Value(utils.UserIDKey))
+	}
+
+	if err := models.RecalculateAverageRating(utils.DB, review.AnimeID); err != nil {
+		log.Println("Error updating average rating:", err)
+	}
+
 	w.WriteHeader(http.StatusNoContent) // 204 No Content
 }
 
+// canModifyReview mengecek apakah user adalah pemilik review atau punya izin moderasi
+func canModifyReview(r *http.Request, review models.Review) bool {
+	userID, _ := r.Context().Value(utils.UserIDKey).(int)
+	return review.UserID == userID || utils.HasPermission(r, utils.PermReviewModerate)
+}
+
 // AddFavorite handler
 func AddFavorite(w http.ResponseWriter, r *http.Request) {
 	// Menangani preflight request untuk CORS
@@ -679,6 +1121,19 @@ func GetFavorites(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ids := make([]uint, len(favorites))
+	for i, favorite := range favorites {
+		ids[i] = uint(favorite.AnimeID)
+	}
+	displayTitles, err := utils.RequestDisplayTitles(r, ids)
+	if err != nil {
+		http.Error(w, "Failed to fetch favorites", http.StatusInternalServerError)
+		return
+	}
+	for i := range favorites {
+		favorites[i].DisplayTitle = displayTitle(displayTitles, uint(favorites[i].AnimeID), favorites[i].AnimeTitle)
+	}
+
 	// Log data yang diambil
 	log.Printf("Favorites: %+v", favorites)
 
@@ -769,6 +1224,19 @@ func GetUserReviews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ids := make([]uint, len(reviews))
+	for i, review := range reviews {
+		ids[i] = uint(review.AnimeID)
+	}
+	displayTitles, err := utils.RequestDisplayTitles(r, ids)
+	if err != nil {
+		http.Error(w, "Failed to fetch reviews", http.StatusInternalServerError)
+		return
+	}
+	for i := range reviews {
+		reviews[i].DisplayTitle = displayTitle(displayTitles, uint(reviews[i].AnimeID), reviews[i].AnimeTitle)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(reviews)
@@ -782,7 +1250,12 @@ func EditUserProfile(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.Context().Value(utils.UserIDKey).(int) // Get userID from context
 
-	var updatedUser models.User
+	// PreferredLanguage berupa pointer agar field yang tidak dikirim tidak menghapus preferensi
+	var updatedUser struct {
+		Username          string  `json:"username"`
+		Bio               string  `json:"bio"`
+		PreferredLanguage *string `json:"preferred_language"`
+	}
 	if err := json.NewDecoder(r.Body).Decode(&updatedUser); err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
 		return
@@ -799,6 +1272,19 @@ func EditUserProfile(w http.ResponseWriter, r *http.Request) {
 	user.Username = updatedUser.Username
 	user.Bio = updatedUser.Bio
 
+	// Bahasa judul pilihan user; hanya diubah jika dikirim, string kosong menghapus preferensi
+	if updatedUser.PreferredLanguage != nil {
+		user.PreferredLanguage = ""
+		if *updatedUser.PreferredLanguage != "" {
+			lang, ok := utils.NormalizeLanguageTag(*updatedUser.PreferredLanguage)
+			if !ok {
+				http.Error(w, "Invalid preferred language", http.StatusBadRequest)
+				return
+			}
+			user.PreferredLanguage = lang
+		}
+	}
+
 	// Save the updated user
 	if err := utils.DB.Save(&user).Error; err != nil {
 		http.Error(w, "Failed to update user profile", http.StatusInternalServerError)
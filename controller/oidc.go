@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// GetIdentityProviders handler
+func GetIdentityProviders(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"providers": utils.IdentityProviderNames()})
+}
+
+// OIDCLogin handler: mengarahkan browser ke halaman login provider
+func OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := utils.GetIdentityProvider(mux.Vars(r)["provider"])
+	if !ok {
+		http.Error(w, "Unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	authURL, browserSecret, err := utils.BeginOAuth(r.Context(), provider, nil)
+	if err != nil {
+		log.Println("Error starting OIDC login:", err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	utils.SetOAuthBrowserCookie(w, browserSecret)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback handler: menyelesaikan login atau penautan provider lalu kembali ke frontend.
+// Token dikirim di fragment URL sehingga tidak ikut terkirim ke server mana pun.
+func OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["provider"]
+	provider, ok := utils.GetIdentityProvider(name)
+	if !ok {
+		http.Error(w, "Unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	// Cookie hanya berlaku untuk satu alur, apa pun hasilnya
+	var browserSecret string
+	if cookie, err := r.Cookie(utils.OAuthBrowserCookie); err == nil {
+		browserSecret = cookie.Value
+	}
+	utils.ClearOAuthBrowserCookie(w)
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		redirectOAuthResult(w, r, url.Values{"error": {providerError}})
+		return
+	}
+
+	state, err := utils.ConsumeOAuthState(name, query.Get("state"), browserSecret)
+	if err != nil {
+		redirectOAuthResult(w, r, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	identity, err := provider.Exchange(r.Context(), query.Get("code"), state.CodeVerifier, state.Nonce)
+	if err != nil {
+		log.Printf("OIDC code exchange with %s failed: %v", name, err)
+		redirectOAuthResult(w, r, url.Values{"error": {"exchange_failed"}})
+		return
+	}
+
+	// Alur penautan dari halaman akun
+	if state.LinkUserID != nil {
+		if err := utils.LinkIdentity(*state.LinkUserID, identity); err != nil {
+			redirectOAuthResult(w, r, url.Values{"error": {oauthErrorCode(err)}})
+			return
+		}
+		redirectOAuthResult(w, r, url.Values{"linked": {name}})
+		return
+	}
+
+	user, created, err := utils.ResolveIdentityUser(identity)
+	if err != nil {
+		redirectOAuthResult(w, r, url.Values{"error": {oauthErrorCode(err)}})
+		return
+	}
+
+	if err := utils.AccountRestriction(*user, time.Now()); err != nil {
+		redirectOAuthResult(w, r, url.Values{"error": {oauthErrorCode(err)}})
+		return
+	}
+
+	// 2FA tetap berlaku untuk login lewat provider
+	if user.TOTPEnabled {
+		mfaToken, err := utils.GenerateMFAPendingToken(*user)
+		if err != nil {
+			redirectOAuthResult(w, r, url.Values{"error": {"server_error"}})
+			return
+		}
+		redirectOAuthResult(w, r, url.Values{
+			"mfa_required": {"true"},
+			"mfa_token":    {mfaToken},
+			"expires_in":   {strconv.Itoa(int(utils.MFAPendingTTL.Seconds()))},
+		})
+		return
+	}
+
+	token, refreshToken, err := startSession(r, *user, false)
+	if err != nil {
+		redirectOAuthResult(w, r, url.Values{"error": {"server_error"}})
+		return
+	}
+
+	redirectOAuthResult(w, r, url.Values{
+		"token":         {token},
+		"refresh_token": {refreshToken},
+		"expires_in":    {strconv.Itoa(int(utils.AccessTokenTTL.Seconds()))},
+		"created":       {strconv.FormatBool(created)},
+	})
+}
+
+// redirectOAuthResult mengarahkan kembali ke halaman callback frontend dengan hasil di fragment
+func redirectOAuthResult(w http.ResponseWriter, r *http.Request, result url.Values) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	http.Redirect(w, r, utils.FrontendURL()+"/oauth-callback.html#"+result.Encode(), http.StatusFound)
+}
+
+// oauthErrorCode memetakan error alur OIDC ke kode yang bisa dibaca frontend
+func oauthErrorCode(err error) string {
+	switch {
+	case errors.Is(err, utils.ErrIdentityLinkedElsewhere):
+		return "identity_linked_elsewhere"
+	case errors.Is(err, utils.ErrProviderAlreadyLinked):
+		return "provider_already_linked"
+	case errors.Is(err, utils.ErrIdentityEmailMissing):
+		return "email_missing"
+	case errors.Is(err, utils.ErrIdentityEmailTaken):
+		return "account_exists"
+	case errors.Is(err, utils.ErrAccountBanned):
+		return "account_banned"
+	case errors.Is(err, utils.ErrAccountSuspended):
+		return "account_suspended"
+	}
+	log.Println("OIDC login error:", err)
+	return "server_error"
+}
+
+// GetIdentities handler
+func GetIdentities(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+	identities, err := utils.ListIdentities(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch linked identities", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(identities)
+}
+
+// LinkIdentity handler: mengembalikan URL authorization untuk menautkan provider ke akun ini.
+// Respons ini juga memasang cookie alur OAuth, jadi frontend harus memanggilnya dengan
+// credentials: "include" lalu membuka authorization_url di tab yang sama. URL yang bocor ke
+// browser lain tidak bisa dipakai karena browser tersebut tidak punya cookie-nya.
+func LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	provider, ok := utils.GetIdentityProvider(mux.Vars(r)["provider"])
+	if !ok {
+		http.Error(w, "Unknown identity provider", http.StatusNotFound)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+	authURL, browserSecret, err := utils.BeginOAuth(r.Context(), provider, &userID)
+	if err != nil {
+		log.Println("Error starting OIDC link:", err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	utils.SetOAuthBrowserCookie(w, browserSecret)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"authorization_url": authURL})
+}
+
+// UnlinkIdentity handler
+func UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+	if err := utils.UnlinkIdentity(userID, mux.Vars(r)["provider"]); err != nil {
+		switch {
+		case errors.Is(err, utils.ErrIdentityNotLinked):
+			http.Error(w, "Identity not linked", http.StatusNotFound)
+		case errors.Is(err, utils.ErrLastLoginMethod):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to unlink identity", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
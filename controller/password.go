@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ForgotPassword handler
+func ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var forgotRequest struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&forgotRequest); err != nil || forgotRequest.Email == "" {
+		http.Error(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	var user models.User
+	if err := utils.DB.Where("email = ?", forgotRequest.Email).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		// Token dan email dibuat di background; kegagalan kirim hanya dicatat di log
+		utils.QueuePasswordResetEmail(user)
+	}
+
+	// Respons selalu sama agar tidak bisa dipakai untuk menebak email yang terdaftar
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If the email is registered, a password reset link has been sent",
+	})
+}
+
+// ResetPassword handler
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var resetRequest struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&resetRequest); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if resetRequest.Token == "" || resetRequest.Password == "" {
+		http.Error(w, "Token and password are required", http.StatusBadRequest)
+		return
+	}
+
+	resetToken, err := utils.LookupPasswordResetToken(resetRequest.Token)
+	if err != nil {
+		if errors.Is(err, utils.ErrResetTokenInvalid) {
+			http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	var user models.User
+	if err := utils.DB.Select("id", "username", "email").First(&user, resetToken.UserID).Error; err != nil {
+		http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+		return
+	}
+	if !checkPasswordPolicy(w, resetRequest.Password, user) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(resetRequest.Password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+
+	if err := utils.ConsumePasswordResetToken(resetToken, string(hashedPassword)); err != nil {
+		if errors.Is(err, utils.ErrResetTokenInvalid) {
+			http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	// Sesi lama tidak boleh bertahan setelah password diganti
+	if err := utils.InvalidateUserTokens(resetToken.UserID, ""); err != nil {
+		log.Println("Error invalidating user tokens:", err)
+	}
+
+	// Reset password yang berhasil membuktikan kepemilikan akun, jadi lockout-nya dibuka
+	if err := utils.ClearLoginLockout(user.Email, ""); err != nil {
+		log.Println("Error clearing login lockout:", err)
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password reset successfully"})
+}
+
+// checkPasswordPolicy memvalidasi password baru dan mengirim 422 berisi daftar pelanggaran jika ditolak
+func checkPasswordPolicy(w http.ResponseWriter, password string, user models.User) bool {
+	err := utils.ValidatePassword(password, user.Username, user.Email)
+	if err == nil {
+		return true
+	}
+
+	var policyErr *utils.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		log.Println("Error checking password policy:", err)
+		http.Error(w, "Failed to validate password", http.StatusInternalServerError)
+		return false
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":      "Password does not meet the password policy",
+		"violations": policyErr.Violations,
+	})
+	return false
+}
@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"NYANIMEBACKEND/utils"
+)
+
+// writeRelationError memetakan error relasi dan franchise ke status HTTP
+func writeRelationError(w http.ResponseWriter, err error) {
+	var cycleErr *utils.RelationCycleError
+	switch {
+	case errors.As(err, &cycleErr):
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error": cycleErr.Error(),
+			"cycle": cycleErr.Cycle,
+		})
+	case errors.Is(err, utils.ErrAnimeNotFound), errors.Is(err, utils.ErrRelationNotFound), errors.Is(err, utils.ErrFranchiseNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, utils.ErrRelationExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, utils.ErrInvalidRelation), errors.Is(err, utils.ErrSelfRelation), errors.Is(err, utils.ErrInvalidFranchise):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Println("Error managing anime relations:", err)
+		http.Error(w, "Failed to update anime relations", http.StatusInternalServerError)
+	}
+}
+
+// GetRelatedAnime handler (GET /anime/{id}/related)
+func GetRelatedAnime(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+	related, err := utils.ListRelatedAnime(utils.DB, animeID)
+	if err != nil {
+		writeRelationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, related)
+}
+
+// AddRelatedAnime handler (POST /anime/{id}/related)
+func AddRelatedAnime(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+
+	var relationReq struct {
+		RelatedID uint   `json:"related_id"`
+		Type      string `json:"type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&relationReq); err != nil || relationReq.RelatedID == 0 {
+		http.Error(w, "related_id and type are required", http.StatusBadRequest)
+		return
+	}
+
+	relation, err := utils.AddAnimeRelation(animeID, relationReq.RelatedID, relationReq.Type)
+	if err != nil {
+		writeRelationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, relation)
+}
+
+// RemoveRelatedAnime handler (DELETE /anime/{id}/related/{related_id})
+func RemoveRelatedAnime(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	relatedID, ok2 := pathID(r, "related_id")
+	if !ok || !ok2 {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+	if err := utils.RemoveAnimeRelation(animeID, relatedID); err != nil {
+		writeRelationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
+
+// GetFranchises handler
+// Query: page, limit
+func GetFranchises(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	page, limit := utils.ParsePagination(r, 20, 100)
+	franchises, total, err := utils.ListFranchises(utils.DB, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch franchises", http.StatusInternalServerError)
+		return
+	}
+	writeCreditPage(w, franchises, page, limit, total)
+}
+
+// GetFranchise handler
+func GetFranchise(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid franchise ID", http.StatusBadRequest)
+		return
+	}
+	franchise, animes, err := utils.GetFranchise(utils.DB, id)
+	if err != nil {
+		writeRelationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"franchise": franchise,
+		"anime":     animes,
+	})
+}
+
+// UpdateFranchise handler
+func UpdateFranchise(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid franchise ID", http.StatusBadRequest)
+		return
+	}
+
+	var franchiseReq struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&franchiseReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	franchise, err := utils.UpdateFranchise(id, franchiseReq.Name, franchiseReq.Description)
+	if err != nil {
+		writeRelationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, franchise)
+}
+
+// GetFranchiseWatchOrder handler (GET /franchises/{id}/watch-order)
+func GetFranchiseWatchOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid franchise ID", http.StatusBadRequest)
+		return
+	}
+	order, err := utils.FranchiseWatchOrder(utils.DB, id)
+	if err != nil {
+		writeRelationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// GetSeasonAnime handler (GET /anime/seasons/{year}/{season})
+// Query: page, limit, lang
+func GetSeasonAnime(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	vars := mux.Vars(r)
+	year, err := strconv.Atoi(vars["year"])
+	if err != nil || year < 1900 || year > 9999 {
+		http.Error(w, "Year must be a four-digit year", http.StatusBadRequest)
+		return
+	}
+	season, ok := utils.ParseBroadcastSeason(vars["season"])
+	if !ok {
+		http.Error(w, "Season must be one of "+strings.Join(utils.BroadcastSeasons, ", "), http.StatusBadRequest)
+		return
+	}
+	page, limit := utils.ParsePagination(r, 20, 100)
+
+	animes, total, err := utils.ListSeasonAnime(utils.DB, year, season, page, limit)
+	if err != nil {
+		log.Println("Error retrieving season anime:", err)
+		http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+		return
+	}
+
+	ids := make([]uint, len(animes))
+	for i, anime := range animes {
+		ids[i] = anime.ID
+	}
+	displayTitles, err := utils.RequestDisplayTitles(r, ids)
+	if err != nil {
+		log.Println("Error retrieving anime titles:", err)
+		http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+		return
+	}
+	withImages := make([]*models.Anime, len(animes))
+	for i := range animes {
+		animes[i].DisplayTitle = displayTitle(displayTitles, animes[i].ID, animes[i].Title)
+		withImages[i] = &animes[i]
+	}
+	if err := utils.AttachAnimeImages(utils.DB, withImages); err != nil {
+		log.Println("Error retrieving anime images:", err)
+		http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Vary", "Accept-Language")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": animes,
+		"meta": map[string]interface{}{
+			"year":   year,
+			"season": season,
+			"page":   page,
+			"limit":  limit,
+			"total":  total,
+		},
+	})
+}
@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// roleRequest adalah body untuk membuat atau mengubah role
+type roleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// writeRoleError memetakan error role ke status HTTP
+func writeRoleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrRoleNotFound):
+		http.Error(w, "Role not found", http.StatusNotFound)
+	case errors.Is(err, utils.ErrRoleExists), errors.Is(err, utils.ErrRoleInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, utils.ErrRoleProtected):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, utils.ErrUnknownPermission):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Println("Error managing role:", err)
+		http.Error(w, "Failed to update roles", http.StatusInternalServerError)
+	}
+}
+
+// GetRoles handler
+func GetRoles(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var roles []models.Role
+	if err := utils.DB.Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		http.Error(w, "Failed to fetch roles", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(roles)
+}
+
+// GetPermissions handler
+func GetPermissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var permissions []models.Permission
+	if err := utils.DB.Order("name").Find(&permissions).Error; err != nil {
+		http.Error(w, "Failed to fetch permissions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(permissions)
+}
+
+// CreateRole handler
+func CreateRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var roleReq roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&roleReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	roleReq.Name = strings.ToLower(strings.TrimSpace(roleReq.Name))
+	if roleReq.Name == "" {
+		http.Error(w, "Role name is required", http.StatusBadRequest)
+		return
+	}
+
+	role, err := utils.CreateRole(roleReq.Name, roleReq.Description, roleReq.Permissions)
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+
+	utils.RecordAudit(r, utils.AuditRoleCreate, utils.AuditTargetRole, role.Name, map[string]interface{}{"permissions": roleReq.Permissions})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(role)
+}
+
+// UpdateRole handler
+func UpdateRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var roleReq roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&roleReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	role, err := utils.UpdateRole(mux.Vars(r)["name"], roleReq.Description, roleReq.Permissions)
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+
+	utils.RecordAudit(r, utils.AuditRoleUpdate, utils.AuditTargetRole, role.Name, map[string]interface{}{"permissions": roleReq.Permissions})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(role)
+}
+
+// DeleteRole handler
+func DeleteRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if err := utils.DeleteRole(name); err != nil {
+		writeRoleError(w, err)
+		return
+	}
+
+	utils.RecordAudit(r, utils.AuditRoleDelete, utils.AuditTargetRole, name, nil)
+
+	w.WriteHeader(http.StatusNoContent) // 204 No Content
+}
+
+// AssignUserRole handler
+func AssignUserRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "PUT, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	// Admin tidak boleh mengubah role-nya sendiri agar tidak terkunci dari panel admin
+	adminID := r.Context().Value(utils.UserIDKey).(int)
+	if targetID == adminID {
+		http.Error(w, "You cannot change your own role", http.StatusForbidden)
+		return
+	}
+
+	var assignRequest struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&assignRequest); err != nil || assignRequest.Role == "" {
+		http.Error(w, "Role is required", http.StatusBadRequest)
+		return
+	}
+
+	var target models.User
+	if err := utils.DB.Select("id", "role").First(&target, targetID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Tanpa role:manage, pemegang user:manage hanya boleh memberi atau mencabut role yang
+	// semua permission-nya ia miliki, agar tidak bisa membuat akun lain lebih berkuasa darinya
+	if !utils.HasPermission(r, utils.PermRoleManage) {
+		for _, roleName := range []string{target.Role, assignRequest.Role} {
+			missing, err := utils.MissingRolePermission(r, roleName)
+			if err != nil {
+				writeRoleError(w, err)
+				return
+			}
+			if missing != "" {
+				http.Error(w, "Forbidden: Role "+roleName+" has permission "+missing+" that you do not have", http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	if err := utils.AssignRole(targetID, assignRequest.Role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		writeRoleError(w, err)
+		return
+	}
+
+	utils.RecordAudit(r, utils.AuditUserRoleChange, utils.AuditTargetUser, targetID, map[string]interface{}{"from": target.Role, "to": assignRequest.Role})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Role updated",
+		"user_id": targetID,
+		"role":    assignRequest.Role,
+	})
+}
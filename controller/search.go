@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+// animeSearchResult adalah anime beserta skor relevansinya
+type animeSearchResult struct {
+	models.Anime
+	Score float64 `json:"score"`
+}
+
+// SearchAnime handler
+// Query: q, limit
+func SearchAnime(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Query parameter q is required", http.StatusBadRequest)
+		return
+	}
+	_, limit := utils.ParsePagination(r, 10, 50)
+
+	hits, err := utils.AnimeSearch.Search(query, limit)
+	if err != nil {
+		log.Println("Error searching anime:", err)
+		http.Error(w, "Failed to search anime", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]animeSearchResult, 0, len(hits))
+	if len(hits) > 0 {
+		ids := make([]uint, len(hits))
+		for i, hit := range hits {
+			ids[i] = hit.ID
+		}
+		var animes []models.Anime
+		if err := utils.DB.Preload("Genres").Where("id IN ?", ids).Find(&animes).Error; err != nil {
+			http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+			return
+		}
+		displayTitles, err := utils.RequestDisplayTitles(r, ids)
+		if err != nil {
+			http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+			return
+		}
+		withImages := make([]*models.Anime, len(animes))
+		for i := range animes {
+			withImages[i] = &animes[i]
+		}
+		if err := utils.AttachAnimeImages(utils.DB, withImages); err != nil {
+			http.Error(w, "Failed to retrieve anime", http.StatusInternalServerError)
+			return
+		}
+		byID := make(map[uint]models.Anime, len(animes))
+		for _, anime := range animes {
+			anime.DisplayTitle = displayTitle(displayTitles, anime.ID, anime.Title)
+			byID[anime.ID] = anime
+		}
+
+		// Urutan mengikuti skor relevansi dari index
+		for _, hit := range hits {
+			if anime, ok := byID[hit.ID]; ok {
+				results = append(results, animeSearchResult{Anime: anime, Score: hit.Score})
+			}
+		}
+	}
+
+	w.Header().Set("Vary", "Accept-Language")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": results,
+		"meta": map[string]interface{}{
+			"query": query,
+			"total": len(results),
+			"limit": limit,
+		},
+	})
+}
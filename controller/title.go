@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"NYANIMEBACKEND/utils"
+)
+
+// GetAnimeTitles handler (GET /anime/{id}/titles)
+func GetAnimeTitles(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+	titles, err := utils.ListAnimeTitles(animeID)
+	if err != nil {
+		writeTitleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, titles)
+}
+
+// SetAnimeTitles handler (PUT /anime/{id}/titles), mengganti semua judul alternatif
+func SetAnimeTitles(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	animeID, ok := pathID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid anime ID", http.StatusBadRequest)
+		return
+	}
+
+	var inputs []utils.TitleInput
+	if err := json.NewDecoder(r.Body).Decode(&inputs); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	inputs, err := utils.NormalizeTitleInputs(inputs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	titles, err := utils.SetAnimeTitles(animeID, inputs)
+	if err != nil {
+		writeTitleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, titles)
+}
+
+// writeTitleError memetakan error judul alternatif ke status HTTP
+func writeTitleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, utils.ErrAnimeNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	log.Println("Error managing anime titles:", err)
+	http.Error(w, "Failed to update anime titles", http.StatusInternalServerError)
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+// RefreshToken handler
+func RefreshToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var refreshRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&refreshRequest); err != nil || refreshRequest.RefreshToken == "" {
+		http.Error(w, "Refresh token is required", http.StatusBadRequest)
+		return
+	}
+
+	newRefreshToken, previous, err := utils.RotateRefreshToken(refreshRequest.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrRefreshTokenReused):
+			http.Error(w, "Refresh token has already been used; please log in again", http.StatusUnauthorized)
+		case errors.Is(err, utils.ErrRefreshTokenExpired), errors.Is(err, utils.ErrRefreshTokenInvalid):
+			http.Error(w, "Invalid or expired refresh token", http.StatusUnauthorized)
+		default:
+			log.Println("Error rotating refresh token:", err)
+			http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Family refresh token adalah sesi login; sesi yang sudah dicabut tidak boleh diperpanjang
+	session, err := utils.ActiveSession(previous.FamilyID)
+	if err != nil {
+		if err := utils.RevokeRefreshTokenFamily(previous.FamilyID); err != nil {
+			log.Println("Error revoking refresh token family:", err)
+		}
+		http.Error(w, "Session has been revoked", http.StatusUnauthorized)
+		return
+	}
+	if err := utils.ExtendSession(session.ID); err != nil {
+		log.Println("Error extending session:", err)
+	}
+
+	// Role diambil ulang dari database agar perubahan role langsung berlaku
+	var user models.User
+	if err := utils.DB.First(&user, previous.UserID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusUnauthorized)
+		return
+	}
+	if err := utils.AccountRestriction(user, time.Now()); err != nil {
+		writeAccountRestricted(w, err)
+		return
+	}
+
+	token, err := utils.GenerateToken(user, *session)
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":         token,
+		"refresh_token": newRefreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
+	})
+}
+
+// GetJWKS handler
+func GetJWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(utils.CurrentKeys().JWKS())
+}
+
+// startSession mencatat sesi login baru untuk perangkat yang mengirim request lalu membuat token-nya
+func startSession(r *http.Request, user models.User, mfa bool) (string, string, error) {
+	session, err := utils.CreateSession(user.ID, r.UserAgent(), utils.ClientIP(r), mfa)
+	if err != nil {
+		return "", "", err
+	}
+	return sessionTokens(user, *session)
+}
+
+// sessionTokens membuat access token dan refresh token untuk sesi yang sudah ada
+func sessionTokens(user models.User, session models.Session) (string, string, error) {
+	token, err := utils.GenerateToken(user, session)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err := utils.IssueRefreshToken(user.ID, session.ID)
+	if err != nil {
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+// VerifyEmail handler
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Token is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := utils.VerifyEmailToken(token)
+	if err != nil {
+		if errors.Is(err, utils.ErrVerificationTokenInvalid) {
+			http.Error(w, "Invalid or expired verification token", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, utils.ErrEmailTaken) {
+			http.Error(w, "Email already registered", http.StatusConflict)
+			return
+		}
+		log.Println("Error verifying email:", err)
+		http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Email verified successfully",
+		"email":       user.Email,
+		"verified_at": user.VerifiedAt,
+	})
+}
+
+// ResendVerification handler
+func ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Context().Value(utils.UserIDKey).(int)
+
+	var user models.User
+	if err := utils.DB.First(&user, userID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if user.VerifiedAt != nil {
+		http.Error(w, "Email already verified", http.StatusConflict)
+		return
+	}
+
+	remaining, err := utils.VerificationCooldownRemaining(user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if remaining > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(remaining.Seconds())+1))
+		http.Error(w, "Please wait before requesting another verification email", http.StatusTooManyRequests)
+		return
+	}
+
+	if err := utils.SendVerificationEmail(user, user.Email); err != nil {
+		log.Println("Error sending verification email:", err)
+		http.Error(w, "Failed to send verification email", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:5500")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Verification email sent"})
+}
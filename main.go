@@ -17,9 +17,18 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	// Muat kunci penandatanganan JWT
+	utils.InitKeys()
+
 	// Inisialisasi Database
 	utils.InitDB()
 
+	// Daftarkan identity provider OIDC dari konfigurasi
+	utils.InitIdentityProviders()
+
+	// Storage untuk gambar cover dan banner anime
+	utils.InitImageStorage()
+
 	// Setup Routes
 	router := routes.SetupRoutes()
 
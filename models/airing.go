@@ -0,0 +1,18 @@
+package models
+
+// Status tayang anime
+const (
+	AiringStatusUpcoming  = "upcoming"
+	AiringStatusAiring    = "airing"
+	AiringStatusFinished  = "finished"
+	AiringStatusCancelled = "cancelled"
+)
+
+// Musim tayang (broadcast season), dihitung dari bulan mulai tayang:
+// winter Januari-Maret, spring April-Juni, summer Juli-September, fall Oktober-Desember
+const (
+	SeasonWinter = "winter"
+	SeasonSpring = "spring"
+	SeasonSummer = "summer"
+	SeasonFall   = "fall"
+)
@@ -0,0 +1,34 @@
+package models
+
+// AnimeListItem adalah satu baris di daftar anime beserta statistik review dan episodenya
+type AnimeListItem struct {
+	Anime
+	ReviewCount  int64 `json:"review_count"`
+	EpisodeCount int64 `json:"episode_count"`
+}
+
+// AnimeDetail adalah data satu anime beserta statistik review dan favorit
+type AnimeDetail struct {
+	Anime
+	ReviewCount     int64            `json:"review_count"`
+	SeasonCount     int64            `json:"season_count"`
+	EpisodeCount    int64            `json:"episode_count"`
+	RatingHistogram map[int]int64    `json:"rating_histogram"` // Jumlah review per rating 1-5
+	FavoriteCount   int64            `json:"favorite_count"`
+	Studios         []StudioCredit   `json:"studios"`
+	RecentReviews   []ReviewWithUser `json:"recent_reviews"`
+	Viewer          *AnimeViewer     `json:"viewer,omitempty"` // Hanya ada jika pemanggil sudah login
+}
+
+// ReviewWithUser adalah review beserta nama penulisnya
+type ReviewWithUser struct {
+	Review
+	Username string `json:"username"`
+}
+
+// AnimeViewer adalah keadaan anime untuk user yang sedang login
+type AnimeViewer struct {
+	Review     *Review `json:"review"`
+	IsFavorite bool    `json:"is_favorite"`
+	FavoriteID *uint64 `json:"favorite_id"`
+}
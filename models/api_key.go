@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// APIKey adalah kunci jangka panjang untuk script dan bot. Hanya hash yang disimpan;
+// Prefix ditampilkan agar pengguna bisa mengenali kuncinya.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     int        `json:"-" gorm:"index;not null"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16);not null"`
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;type:text"`
+	MFA        bool       `json:"-" gorm:"not null;default:false"` // Dibuat dari sesi yang login dengan 2FA
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"type:varchar(64)"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Active mengecek apakah kunci belum dicabut dan belum kedaluwarsa
+func (k APIKey) Active() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog mencatat tindakan administratif: siapa melakukan apa terhadap siapa
+type AuditLog struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	ActorID    int             `json:"actor_id" gorm:"index"`
+	Action     string          `json:"action" gorm:"type:varchar(64);index;not null"`
+	TargetType string          `json:"target_type" gorm:"type:varchar(32);index:idx_audit_target"`
+	TargetID   string          `json:"target_id" gorm:"type:varchar(64);index:idx_audit_target"`
+	Details    json.RawMessage `json:"details,omitempty" gorm:"type:text"`
+	IP         string          `json:"ip" gorm:"type:varchar(64)"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,119 @@
+package models
+
+import "time"
+
+// Studio adalah studio animasi atau perusahaan produksi
+type Studio struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"type:varchar(128);uniqueIndex;not null"`
+	Website     string    `json:"website"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Person adalah staf produksi atau pengisi suara
+type Person struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Name       string    `json:"name" gorm:"type:varchar(128);not null;index"`
+	NativeName string    `json:"native_name" gorm:"type:varchar(128)"`
+	Bio        string    `json:"bio" gorm:"type:text"`
+	BirthDate  *Date     `json:"birth_date"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Character adalah tokoh yang bisa muncul di beberapa anime (misalnya sekuel)
+type Character struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"type:varchar(128);not null;index"`
+	NativeName  string    `json:"native_name" gorm:"type:varchar(128)"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AnimeStudio menghubungkan anime dengan studio beserta perannya (animation, production, ...)
+type AnimeStudio struct {
+	ID       uint   `json:"-" gorm:"primaryKey"`
+	AnimeID  uint   `json:"anime_id" gorm:"not null;uniqueIndex:idx_anime_studio_role"`
+	StudioID uint   `json:"studio_id" gorm:"not null;uniqueIndex:idx_anime_studio_role;index"`
+	Role     string `json:"role" gorm:"type:varchar(64);not null;uniqueIndex:idx_anime_studio_role"`
+}
+
+// AnimeStaff menghubungkan anime dengan staf beserta perannya (director, composer, ...)
+type AnimeStaff struct {
+	ID       uint   `json:"-" gorm:"primaryKey"`
+	AnimeID  uint   `json:"anime_id" gorm:"not null;uniqueIndex:idx_anime_staff_role"`
+	PersonID uint   `json:"person_id" gorm:"not null;uniqueIndex:idx_anime_staff_role;index"`
+	Role     string `json:"role" gorm:"type:varchar(64);not null;uniqueIndex:idx_anime_staff_role"`
+}
+
+func (AnimeStaff) TableName() string {
+	return "anime_staff"
+}
+
+// AnimeCharacter menghubungkan anime dengan tokoh dan pengisi suaranya. Satu tokoh bisa
+// punya pengisi suara berbeda per bahasa (misalnya ja dan en).
+type AnimeCharacter struct {
+	ID           uint   `json:"-" gorm:"primaryKey"`
+	AnimeID      uint   `json:"anime_id" gorm:"not null;uniqueIndex:idx_anime_character_language"`
+	CharacterID  uint   `json:"character_id" gorm:"not null;uniqueIndex:idx_anime_character_language;index"`
+	Role         string `json:"role" gorm:"type:varchar(32);not null"` // main, supporting atau background
+	VoiceActorID *uint  `json:"voice_actor_id" gorm:"index"`
+	Language     string `json:"language" gorm:"type:varchar(16);not null;uniqueIndex:idx_anime_character_language"`
+}
+
+// StudioCredit adalah studio yang mengerjakan sebuah anime
+type StudioCredit struct {
+	Studio
+	Role string `json:"role"`
+}
+
+// StaffCredit adalah staf yang mengerjakan sebuah anime
+type StaffCredit struct {
+	Person
+	Role string `json:"role"`
+}
+
+// CharacterCredit adalah tokoh di sebuah anime beserta pengisi suaranya
+type CharacterCredit struct {
+	Character  Character `json:"character"`
+	Role       string    `json:"role"`
+	Language   string    `json:"language"`
+	VoiceActor *Person   `json:"voice_actor"`
+}
+
+// AnimeCredits adalah seluruh kredit produksi dan pemeran sebuah anime
+type AnimeCredits struct {
+	Studios    []StudioCredit    `json:"studios"`
+	Staff      []StaffCredit     `json:"staff"`
+	Characters []CharacterCredit `json:"characters"`
+}
+
+// StudioAnime adalah anime yang dikerjakan sebuah studio
+type StudioAnime struct {
+	Anime
+	Role string `json:"role"`
+}
+
+// PersonStaffCredit adalah satu peran staf seseorang di sebuah anime
+type PersonStaffCredit struct {
+	AnimeID    uint   `json:"anime_id"`
+	AnimeTitle string `json:"anime_title"`
+	Role       string `json:"role"`
+}
+
+// PersonVoiceCredit adalah satu peran pengisi suara seseorang
+type PersonVoiceCredit struct {
+	AnimeID       uint   `json:"anime_id"`
+	AnimeTitle    string `json:"anime_title"`
+	CharacterID   uint   `json:"character_id"`
+	CharacterName string `json:"character_name"`
+	Role          string `json:"role"`
+	Language      string `json:"language"`
+}
+
+// PersonCredits adalah semua kredit seseorang
+type PersonCredits struct {
+	Person Person              `json:"person"`
+	Staff  []PersonStaffCredit `json:"staff"`
+	Voices []PersonVoiceCredit `json:"voices"`
+}
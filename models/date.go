@@ -0,0 +1,176 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout adalah format tanggal tanpa jam yang dipakai di JSON dan database
+const DateLayout = "2006-01-02"
+
+// Date adalah tanggal kalender tanpa jam dan zona waktu, disimpan sebagai kolom DATE
+type Date struct {
+	time.Time
+}
+
+// NewDate membuat Date dari tahun, bulan dan hari
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate membaca tanggal berformat YYYY-MM-DD
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+	return Date{t}, nil
+}
+
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value menyimpan tanggal ke database
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan membaca kolom DATE (time.Time jika parseTime aktif, atau teks)
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		*d = NewDate(v.Year(), v.Month(), v.Day())
+		return nil
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	}
+	return fmt.Errorf("cannot scan %T into Date", value)
+}
+
+func (d *Date) scanString(s string) error {
+	if len(s) > len(DateLayout) {
+		s = s[:len(DateLayout)]
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// GormDataType menentukan tipe kolom untuk auto-migrate
+func (Date) GormDataType() string {
+	return "date"
+}
+
+// DatePrecision menandai bagian tanggal yang diketahui pada PartialDate
+type DatePrecision string
+
+const (
+	PrecisionYear  DatePrecision = "year"
+	PrecisionMonth DatePrecision = "month"
+	PrecisionDay   DatePrecision = "day"
+)
+
+// PartialDate adalah tanggal yang mungkin hanya diketahui tahun atau bulannya, misalnya
+// anime yang diumumkan "2027" tanpa tanggal tayang. Date menyimpan hari pertama dari periode
+// tersebut sehingga kolomnya tetap bisa diurutkan; nil berarti tanggal tidak diketahui.
+// Di JSON ditulis sebagai "2027", "2027-04" atau "2027-04-05".
+type PartialDate struct {
+	Date      *Date         `gorm:"column:date"`
+	Precision DatePrecision `gorm:"column:precision;type:varchar(5)"`
+}
+
+// ParsePartialDate membaca tanggal berformat YYYY, YYYY-MM atau YYYY-MM-DD
+func ParsePartialDate(s string) (PartialDate, error) {
+	layouts := []struct {
+		layout    string
+		precision DatePrecision
+	}{
+		{"2006", PrecisionYear},
+		{"2006-01", PrecisionMonth},
+		{DateLayout, PrecisionDay},
+	}
+	for _, l := range layouts {
+		if len(s) != len(l.layout) {
+			continue
+		}
+		t, err := time.Parse(l.layout, s)
+		if err != nil {
+			break
+		}
+		date := Date{t}
+		return PartialDate{Date: &date, Precision: l.precision}, nil
+	}
+	return PartialDate{}, fmt.Errorf("invalid date %q, expected YYYY, YYYY-MM or YYYY-MM-DD", s)
+}
+
+// Known mengembalikan true jika tanggal diisi
+func (p PartialDate) Known() bool {
+	return p.Date != nil
+}
+
+func (p PartialDate) String() string {
+	if p.Date == nil {
+		return ""
+	}
+	switch p.Precision {
+	case PrecisionYear:
+		return p.Date.Format("2006")
+	case PrecisionMonth:
+		return p.Date.Format("2006-01")
+	}
+	return p.Date.String()
+}
+
+// End mengembalikan hari terakhir dari periode tanggal (31 Desember untuk presisi tahun)
+func (p PartialDate) End() time.Time {
+	switch p.Precision {
+	case PrecisionYear:
+		return p.Date.AddDate(1, 0, -1)
+	case PrecisionMonth:
+		return p.Date.AddDate(0, 1, -1)
+	}
+	return p.Date.Time
+}
+
+func (p PartialDate) MarshalJSON() ([]byte, error) {
+	if p.Date == nil {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + p.String() + `"`), nil
+}
+
+func (p *PartialDate) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" || s == "" {
+		*p = PartialDate{}
+		return nil
+	}
+	parsed, err := ParsePartialDate(s)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Season adalah satu musim/cour dari sebuah anime. Number unik per anime.
+type Season struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	AnimeID   uint      `json:"anime_id" gorm:"not null;uniqueIndex:idx_season_anime_number"`
+	Number    int       `json:"number" gorm:"not null;uniqueIndex:idx_season_anime_number"`
+	Title     string    `json:"title"`
+	Synopsis  string    `json:"synopsis" gorm:"type:text"`
+	Episodes  []Episode `json:"episodes,omitempty" gorm:"foreignKey:SeasonID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Episode adalah satu episode anime. SeasonID kosong untuk anime yang tidak dibagi per musim.
+type Episode struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	AnimeID         uint      `json:"anime_id" gorm:"not null;index"`
+	SeasonID        *uint     `json:"season_id" gorm:"index"`
+	Number          int       `json:"number" gorm:"not null"` // Nomor episode di dalam season
+	Title           string    `json:"title"`
+	AirDate         *Date     `json:"air_date"`
+	DurationMinutes int       `json:"duration_minutes"`
+	Synopsis        string    `json:"synopsis" gorm:"type:text"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// Genre adalah satu genre anime. Slug dipakai sebagai kunci unik yang tidak peka huruf besar/kecil,
+// sehingga "Action" dan "action" adalah genre yang sama.
+type Genre struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"type:varchar(64);not null"`
+	Slug string `json:"slug" gorm:"type:varchar(64);uniqueIndex;not null"`
+}
+
+// GenreCount adalah genre beserta jumlah anime-nya
+type GenreCount struct {
+	Genre
+	AnimeCount int64 `json:"anime_count"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// LinkedIdentity menghubungkan akun eksternal (provider + subject) ke User
+type LinkedIdentity struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      int        `json:"-" gorm:"not null;uniqueIndex:idx_identity_user_provider"`
+	Provider    string     `json:"provider" gorm:"type:varchar(32);not null;uniqueIndex:idx_identity_provider_subject;uniqueIndex:idx_identity_user_provider"`
+	Subject     string     `json:"-" gorm:"type:varchar(255);not null;uniqueIndex:idx_identity_provider_subject"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// OAuthState menyimpan state, nonce dan PKCE verifier selama alur login OIDC berlangsung
+type OAuthState struct {
+	StateHash    string    `gorm:"type:varchar(64);primaryKey"`
+	Provider     string    `gorm:"type:varchar(32);not null"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null"`
+	Nonce        string    `gorm:"type:varchar(128);not null"`
+	BrowserHash  string    `gorm:"type:varchar(64);not null"` // Hash rahasia di cookie browser yang memulai alur ini
+	LinkUserID   *int      // Diisi jika alur ini untuk menautkan provider ke akun yang sedang login
+	ExpiresAt    time.Time `gorm:"index"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Jenis gambar anime
+const (
+	ImageKindCover  = "cover"
+	ImageKindBanner = "banner"
+)
+
+// AnimeImage adalah gambar cover atau banner yang diunggah untuk anime. File asli dan
+// thumbnail disimpan di storage dengan awalan Key; satu anime punya paling banyak satu
+// gambar per jenis.
+type AnimeImage struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	AnimeID     uint      `json:"anime_id" gorm:"not null;uniqueIndex:idx_anime_images_kind"`
+	Kind        string    `json:"kind" gorm:"type:varchar(16);not null;uniqueIndex:idx_anime_images_kind"`
+	Key         string    `json:"-" gorm:"type:varchar(255);not null"`
+	ContentType string    `json:"content_type" gorm:"type:varchar(32);not null"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ImageURLs adalah URL publik sebuah gambar beserta thumbnail-nya (per nama ukuran)
+type ImageURLs struct {
+	Original   string            `json:"original"`
+	Width      int               `json:"width"`
+	Height     int               `json:"height"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
+
+// AnimeImages adalah gambar yang ditampilkan di respons anime
+type AnimeImages struct {
+	Cover  *ImageURLs `json:"cover"`
+	Banner *ImageURLs `json:"banner"`
+}
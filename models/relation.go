@@ -0,0 +1,30 @@
+package models
+
+// AnimeRelation adalah sisi berarah antara dua anime: RelatedID adalah <Type> dari AnimeID.
+// Misalnya {AnimeID: 1, RelatedID: 2, Type: "sequel"} berarti anime 2 adalah sekuel anime 1.
+// Setiap relasi selalu disimpan bersama sisi kebalikannya.
+type AnimeRelation struct {
+	ID        uint   `json:"-" gorm:"primaryKey"`
+	AnimeID   uint   `json:"anime_id" gorm:"not null;uniqueIndex:idx_anime_relation_pair"`
+	RelatedID uint   `json:"related_id" gorm:"not null;uniqueIndex:idx_anime_relation_pair;index"`
+	Type      string `json:"type" gorm:"type:varchar(32);not null"`
+}
+
+// Franchise mengelompokkan anime yang saling berhubungan
+type Franchise struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"type:varchar(255);not null"`
+	Description string `json:"description" gorm:"type:text"`
+}
+
+// FranchiseSummary adalah franchise beserta jumlah anime-nya
+type FranchiseSummary struct {
+	Franchise
+	AnimeCount int64 `json:"anime_count"`
+}
+
+// RelatedAnime adalah anime yang berhubungan dengan anime lain beserta jenis relasinya
+type RelatedAnime struct {
+	Type  string `json:"type"`
+	Anime Anime  `json:"anime"`
+}
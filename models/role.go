@@ -0,0 +1,16 @@
+package models
+
+// Permission adalah satu hak akses, misalnya "anime:write" atau "review:moderate"
+type Permission struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Description string `json:"description"`
+}
+
+// Role adalah kumpulan permission. User.Role berisi Name dari role ini.
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// LoginAttempt menyimpan jumlah login gagal untuk satu kunci (akun atau alamat IP)
+type LoginAttempt struct {
+	Key           string    `json:"key" gorm:"type:varchar(255);primaryKey"`
+	Failures      int       `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	BlockedUntil  time.Time `json:"blocked_until" gorm:"index"`
+	Locked        bool      `json:"locked"` // true = lockout penuh, false = sekadar backoff
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// RecoveryCode adalah kode pemulihan 2FA sekali pakai; hanya hash-nya yang disimpan
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index;not null"`
+	CodeHash  string     `json:"-" gorm:"type:char(64);not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Session mewakili satu login (satu perangkat/browser). Setiap access token membawa ID sesi (sid)
+// dan refresh token-nya memakai ID sesi sebagai FamilyID.
+type Session struct {
+	ID         string     `json:"id" gorm:"type:varchar(64);primaryKey"`
+	UserID     int        `json:"-" gorm:"index;not null"`
+	UserAgent  string     `json:"user_agent" gorm:"type:varchar(512)"`
+	IP         string     `json:"ip" gorm:"type:varchar(64)"`
+	MFA        bool       `json:"mfa" gorm:"not null;default:false"` // Login dilakukan dengan 2FA
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"index"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"current" gorm:"-"` // Sesi yang dipakai untuk request ini
+}
+
+// Active mengecek apakah sesi belum dicabut dan belum kedaluwarsa
+func (s Session) Active() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
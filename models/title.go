@@ -0,0 +1,18 @@
+package models
+
+// Jenis judul alternatif
+const (
+	TitleTypeOfficial = "official"
+	TitleTypeSynonym  = "synonym"
+	TitleTypeShort    = "short"
+)
+
+// AnimeTitle adalah judul alternatif atau terjemahan sebuah anime. Language berisi tag bahasa
+// huruf kecil, misalnya "ja" (Jepang), "ja-latn" (romaji) atau "en".
+type AnimeTitle struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	AnimeID  uint   `json:"anime_id" gorm:"not null;index"`
+	Title    string `json:"title" gorm:"type:varchar(255);not null"`
+	Language string `json:"language" gorm:"type:varchar(35);not null"`
+	Type     string `json:"type" gorm:"type:varchar(16);not null;default:official"` // official, synonym atau short
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// RefreshToken menyimpan refresh token sekali pakai. Token asli hanya dikirim ke klien,
+// database hanya menyimpan hash-nya. Semua token hasil rotasi berbagi FamilyID yang sama (ID sesi).
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index;not null"`
+	FamilyID  string     `json:"-" gorm:"type:varchar(64);index;not null"`
+	TokenHash string     `json:"-" gorm:"type:char(64);uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RevokedToken mencatat JWT yang dicabut (misalnya saat logout) berdasarkan jti.
+// Entri dihapus otomatis setelah ExpiresAt karena token tersebut sudah tidak valid.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"column:jti;type:varchar(128);primaryKey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PasswordResetToken adalah token reset password sekali pakai dengan masa berlaku singkat
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index;not null"`
+	TokenHash string     `json:"-" gorm:"type:char(64);uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// EmailVerificationToken membuktikan bahwa pengguna memiliki alamat Email tersebut
+type EmailVerificationToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index;not null"`
+	Email     string     `json:"email" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"type:char(64);uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
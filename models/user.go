@@ -7,14 +7,28 @@ import (
 )
 
 type User struct {
-	ID        int        `json:"id" gorm:"primaryKey"`
-	Username  string     `json:"username" gorm:"not null"`
-	Email     string     `json:"email" gorm:"unique;not null"`
-	Password  string     `json:"password" gorm:"not null"`
-	Role      string     `json:"role" gorm:"not null"`
-	Bio       string     `json:"bio"`
-	Reviews   []Review   `json:"reviews" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Favorites []Favorite `json:"favorites" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ID                    int        `json:"id" gorm:"primaryKey"`
+	Username              string     `json:"username" gorm:"not null"`
+	Email                 string     `json:"email" gorm:"unique;not null"`
+	Password              string     `json:"password" gorm:"not null"`
+	Role                  string     `json:"role" gorm:"not null"`
+	Bio                   string     `json:"bio"`
+	VerifiedAt            *time.Time `json:"verified_at"`
+	TokenVersion          int        `json:"-" gorm:"not null;default:0"` // Dinaikkan saat kredensial berubah agar semua JWT lama ditolak
+	TOTPSecret            string     `json:"-" gorm:"column:totp_secret"`
+	TOTPEnabled           bool       `json:"totp_enabled" gorm:"column:totp_enabled;not null;default:false"`
+	TOTPLastCounter       int64      `json:"-" gorm:"column:totp_last_counter;not null;default:0"` // Counter TOTP terakhir yang dipakai, mencegah replay
+	BannedAt              *time.Time `json:"banned_at"`
+	BanReason             string     `json:"ban_reason,omitempty"`
+	SuspendedUntil        *time.Time `json:"suspended_until"`
+	SuspendReason         string     `json:"suspend_reason,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required" gorm:"not null;default:false"` // Dipaksa admin; login ditolak sampai password direset
+	PasswordUnset         bool       `json:"-" gorm:"not null;default:false"`                       // Akun dibuat lewat identity provider dan belum punya password lokal
+	DeletionScheduledAt   *time.Time `json:"deletion_scheduled_at" gorm:"index"`                    // Akun dihapus setelah waktu ini kecuali dibatalkan
+	DeletionMode          string     `json:"deletion_mode,omitempty" gorm:"type:varchar(16)"`
+	PreferredLanguage     string     `json:"preferred_language" gorm:"type:varchar(35)"` // Bahasa judul anime yang diutamakan, misalnya "en" atau "ja-latn"
+	Reviews               []Review   `json:"reviews" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Favorites             []Favorite `json:"favorites" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 type Review struct {
@@ -31,13 +45,14 @@ func (Review) TableName() string {
 }
 
 type ReviewWithAnime struct {
-	ID          int     `json:"id"`
-	AnimeID     int     `json:"anime_id"`
-	Content     string  `json:"content"`
-	Rating      float64 `json:"rating"`
-	AnimeTitle  string  `json:"anime_title"`
-	Genre       string  `json:"genre"`
-	ReleaseDate string  `json:"release_date"`
+	ID           int     `json:"id"`
+	AnimeID      int     `json:"anime_id"`
+	Content      string  `json:"content"`
+	Rating       float64 `json:"rating"`
+	AnimeTitle   string  `json:"anime_title"`
+	DisplayTitle string  `json:"display_title" gorm:"-"`
+	Genre        string  `json:"genre"`
+	ReleaseDate  string  `json:"release_date"`
 }
 
 type Favorite struct {
@@ -49,14 +64,15 @@ type Favorite struct {
 }
 
 type FavoriteWithAnime struct {
-	ID          uint64    `json:"id"`       // ID favorit
-	AnimeID     uint64    `json:"anime_id"` // ID anime yang difavoritkan
-	AnimeTitle  string    `json:"anime_title"`
-	Genre       string    `json:"genre"`        // Judul anime
-	Description string    `json:"description"`  // Deskripsi anime
-	Rating      float64   `json:"rating"`       // Rating anime
-	ReleaseDate string    `json:"release_date"` // Tanggal rilis anime
-	CreatedAt   time.Time `json:"created_at"`   // Waktu pembuatan
+	ID           uint64    `json:"id"`       // ID favorit
+	AnimeID      uint64    `json:"anime_id"` // ID anime yang difavoritkan
+	AnimeTitle   string    `json:"anime_title"`
+	DisplayTitle string    `json:"display_title" gorm:"-"` // Judul sesuai bahasa pilihan user
+	Genre        string    `json:"genre"`                  // Judul anime
+	Description  string    `json:"description"`            // Deskripsi anime
+	Rating       float64   `json:"rating"`                 // Rating anime
+	ReleaseDate  string    `json:"release_date"`           // Tanggal rilis anime
+	CreatedAt    time.Time `json:"created_at"`             // Waktu pembuatan
 }
 
 func (Favorite) TableName() string {
@@ -64,13 +80,22 @@ func (Favorite) TableName() string {
 }
 
 type Anime struct {
-	ID            uint    `json:"id" gorm:"primaryKey"`
-	Title         string  `json:"title" gorm:"not null"`
-	Description   string  `json:"description"`
-	Genre         string  `json:"genre"`
-	ReleaseDate   string  `json:"releaseDate"`
-	CreatedBy     uint    `json:"createdBy"`
-	AverageRating float64 `json:"average_rating"`
+	ID              uint         `json:"id" gorm:"primaryKey"`
+	Title           string       `json:"title" gorm:"not null"`
+	Description     string       `json:"description"`
+	Genre           string       `json:"genre"`
+	ReleaseDate     string       `json:"releaseDate"` // Format lama, disinkronkan dari StartDate
+	StartDate       PartialDate  `json:"start_date" gorm:"embedded;embeddedPrefix:start_"`
+	EndDate         PartialDate  `json:"end_date" gorm:"embedded;embeddedPrefix:end_"`
+	AiringStatus    string       `json:"airing_status" gorm:"type:varchar(16);not null;default:upcoming;index"`
+	BroadcastSeason string       `json:"season" gorm:"column:season;type:varchar(8);index:idx_animes_season"` // Dihitung dari StartDate
+	SeasonYear      *int         `json:"season_year" gorm:"index:idx_animes_season"`
+	CreatedBy       uint         `json:"createdBy"`
+	AverageRating   float64      `json:"average_rating"`
+	FranchiseID     *uint        `json:"franchise_id" gorm:"index"`        // Diisi otomatis saat anime dihubungkan dengan anime lain
+	DisplayTitle    string       `json:"display_title,omitempty" gorm:"-"` // Judul sesuai bahasa pilihan pemanggil
+	Images          *AnimeImages `json:"images,omitempty" gorm:"-"`        // URL cover dan banner
+	Genres          []Genre      `json:"genres" gorm:"many2many:anime_genres;constraint:OnDelete:CASCADE"`
 }
 
 func GetUserByID(DB *gorm.DB, userID int) (User, error) {
@@ -78,3 +103,15 @@ func GetUserByID(DB *gorm.DB, userID int) (User, error) {
 	err := DB.Preload("Reviews").Preload("Favorites").First(&user, userID).Error
 	return user, err
 }
+
+// RecalculateAverageRating menghitung ulang rata-rata rating anime dari tabel review
+func RecalculateAverageRating(DB *gorm.DB, animeID uint) error {
+	var averageRating float64
+	if err := DB.Table("reviews_new").
+		Select("COALESCE(AVG(rating), 0)").
+		Where("anime_id = ?", animeID).
+		Scan(&averageRating).Error; err != nil {
+		return err
+	}
+	return DB.Model(&Anime{}).Where("id = ?", animeID).Update("average_rating", averageRating).Error
+}
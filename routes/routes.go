@@ -19,40 +19,162 @@ func SetupRoutes() *mux.Router {
 	router.HandleFunc("/user/register", controller.Register).Methods("POST", "OPTIONS")
 	router.HandleFunc("/user/login", controller.Login).Methods("POST", "OPTIONS")
 
+	// Kunci publik JWT untuk layanan lain yang memverifikasi token Nyanime
+	router.HandleFunc("/.well-known/jwks.json", controller.GetJWKS).Methods("GET", "OPTIONS")
+
+	// Login lewat identity provider (OIDC)
+	authRouter := router.PathPrefix("/auth").Subrouter()
+	authRouter.HandleFunc("/providers", controller.GetIdentityProviders).Methods("GET", "OPTIONS")
+	authRouter.HandleFunc("/{provider}/login", controller.OIDCLogin).Methods("GET")
+	authRouter.HandleFunc("/{provider}/callback", controller.OIDCCallback).Methods("GET")
+
 	// Redirect root to the frontend
 	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "http://127.0.0.1:5500", http.StatusFound)
 	}).Methods("GET", "OPTIONS")
 
+	// Hapus akun (dengan masa tenggang)
+	router.Handle("/user", utils.SessionAuthMiddleware(http.HandlerFunc(controller.DeleteAccount))).Methods("OPTIONS", "DELETE")
+
 	// User Routes
 	userRouter := router.PathPrefix("/user").Subrouter()
 	userRouter.HandleFunc("/register", controller.Register).Methods("OPTIONS", "POST")
 	userRouter.HandleFunc("/login", controller.Login).Methods("OPTIONS", "POST")
+	userRouter.HandleFunc("/login/2fa", controller.LoginTwoFactor).Methods("OPTIONS", "POST")
+	userRouter.HandleFunc("/token/refresh", controller.RefreshToken).Methods("OPTIONS", "POST")
+	userRouter.HandleFunc("/password/forgot", controller.ForgotPassword).Methods("OPTIONS", "POST")
+	userRouter.HandleFunc("/password/reset", controller.ResetPassword).Methods("OPTIONS", "POST")
+	userRouter.HandleFunc("/verify", controller.VerifyEmail).Methods("GET", "OPTIONS")
+	userRouter.Handle("/verify/resend", utils.SessionAuthMiddleware(http.HandlerFunc(controller.ResendVerification))).Methods("OPTIONS", "POST")
 	userRouter.Handle("/profile", utils.AuthMiddleware(http.HandlerFunc(controller.GetUserProfile))).Methods("GET", "OPTIONS")
-	userRouter.Handle("/logout", utils.AuthMiddleware(http.HandlerFunc(controller.Logout))).Methods("OPTIONS", "POST")
+	userRouter.Handle("/logout", utils.SessionAuthMiddleware(http.HandlerFunc(controller.Logout))).Methods("OPTIONS", "POST")
 	userRouter.Handle("/edit", utils.AuthMiddleware(http.HandlerFunc(controller.EditUserProfile))).Methods("OPTIONS", "PUT")
+	userRouter.Handle("/password/change", utils.SessionAuthMiddleware(http.HandlerFunc(controller.ChangePassword))).Methods("OPTIONS", "POST")
+	userRouter.Handle("/email/change", utils.SessionAuthMiddleware(http.HandlerFunc(controller.ChangeEmail))).Methods("OPTIONS", "POST")
+	userRouter.Handle("/2fa/setup", utils.SessionAuthMiddleware(http.HandlerFunc(controller.SetupTwoFactor))).Methods("OPTIONS", "POST")
+	userRouter.Handle("/2fa/enable", utils.SessionAuthMiddleware(http.HandlerFunc(controller.EnableTwoFactor))).Methods("OPTIONS", "POST")
+	userRouter.Handle("/2fa/disable", utils.SessionAuthMiddleware(http.HandlerFunc(controller.DisableTwoFactor))).Methods("OPTIONS", "POST")
+	userRouter.Handle("/2fa/recovery-codes", utils.SessionAuthMiddleware(http.HandlerFunc(controller.RegenerateRecoveryCodes))).Methods("OPTIONS", "POST")
+	userRouter.Handle("/sessions", utils.SessionAuthMiddleware(http.HandlerFunc(controller.GetSessions))).Methods("GET", "OPTIONS")
+	userRouter.Handle("/sessions", utils.SessionAuthMiddleware(http.HandlerFunc(controller.RevokeAllSessions))).Methods("OPTIONS", "DELETE")
+	userRouter.Handle("/sessions/{id}", utils.SessionAuthMiddleware(http.HandlerFunc(controller.RevokeSession))).Methods("OPTIONS", "DELETE")
+	userRouter.Handle("/export", utils.SessionAuthMiddleware(http.HandlerFunc(controller.ExportUserData))).Methods("GET", "OPTIONS")
+	userRouter.Handle("/deletion/cancel", utils.SessionAuthMiddleware(http.HandlerFunc(controller.CancelAccountDeletion))).Methods("OPTIONS", "POST")
+	userRouter.Handle("/identities", utils.AuthMiddleware(http.HandlerFunc(controller.GetIdentities))).Methods("GET", "OPTIONS")
+	userRouter.Handle("/identities/{provider}", utils.SessionAuthMiddleware(http.HandlerFunc(controller.LinkIdentity))).Methods("OPTIONS", "POST")
+	userRouter.Handle("/identities/{provider}", utils.SessionAuthMiddleware(http.HandlerFunc(controller.UnlinkIdentity))).Methods("OPTIONS", "DELETE")
+	userRouter.Handle("/api-keys", utils.AuthMiddleware(http.HandlerFunc(controller.GetAPIKeys))).Methods("GET", "OPTIONS")
+	userRouter.Handle("/api-keys", utils.SessionAuthMiddleware(http.HandlerFunc(controller.CreateAPIKey))).Methods("OPTIONS", "POST")
+	userRouter.Handle("/api-keys/{id}", utils.SessionAuthMiddleware(http.HandlerFunc(controller.RevokeAPIKey))).Methods("OPTIONS", "DELETE")
+
+	// Gambar anime di storage lokal
+	router.PathPrefix("/media/").Handler(utils.MediaHandler("/media/")).Methods("GET")
 
-	// Anime Routes (Admin Privileges)
+	// Anime Routes (butuh permission anime:write / anime:delete)
 	animeRouter := router.PathPrefix("/anime").Subrouter()
-	animeRouter.HandleFunc("/", controller.GetAllAnime).Methods("GET", "OPTIONS")
-	animeRouter.Handle("/", utils.AuthMiddleware(utils.AdminMiddleware(http.HandlerFunc(controller.CreateAnime)))).Methods("OPTIONS", "POST")
-	animeRouter.Handle("/{id}", utils.AuthMiddleware(utils.AdminMiddleware(http.HandlerFunc(controller.EditAnime)))).Methods("OPTIONS", "PUT")
-	animeRouter.Handle("/{id}", utils.AuthMiddleware(utils.AdminMiddleware(http.HandlerFunc(controller.DeleteAnime)))).Methods("OPTIONS", "DELETE")
+	animeRouter.Handle("/", utils.OptionalAuthMiddleware(http.HandlerFunc(controller.GetAllAnime))).Methods("GET")
+	animeRouter.HandleFunc("/", controller.GetAllAnime).Methods("OPTIONS")
+	animeRouter.Handle("/search", utils.OptionalAuthMiddleware(http.HandlerFunc(controller.SearchAnime))).Methods("GET")
+	animeRouter.HandleFunc("/search", controller.SearchAnime).Methods("OPTIONS")
+	animeRouter.Handle("/seasons/{year:[0-9]{4}}/{season}", utils.OptionalAuthMiddleware(http.HandlerFunc(controller.GetSeasonAnime))).Methods("GET")
+	animeRouter.HandleFunc("/seasons/{year:[0-9]{4}}/{season}", controller.GetSeasonAnime).Methods("OPTIONS")
+	animeRouter.Handle("/{id:[0-9]+}", utils.OptionalAuthMiddleware(http.HandlerFunc(controller.GetAnime))).Methods("GET")
+	animeRouter.Handle("/", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.CreateAnime)))).Methods("OPTIONS", "POST")
+	animeRouter.Handle("/{id}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.EditAnime)))).Methods("OPTIONS", "PUT")
+	animeRouter.Handle("/{id}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeDelete)(http.HandlerFunc(controller.DeleteAnime)))).Methods("OPTIONS", "DELETE")
+	animeRouter.HandleFunc("/{id:[0-9]+}/seasons", controller.GetSeasons).Methods("GET", "OPTIONS")
+	animeRouter.Handle("/{id:[0-9]+}/seasons", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.CreateSeason)))).Methods("OPTIONS", "POST")
+	animeRouter.Handle("/{id:[0-9]+}/seasons/{season_id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.UpdateSeason)))).Methods("OPTIONS", "PUT")
+	animeRouter.Handle("/{id:[0-9]+}/seasons/{season_id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeDelete)(http.HandlerFunc(controller.DeleteSeason)))).Methods("OPTIONS", "DELETE")
+	animeRouter.HandleFunc("/{id:[0-9]+}/episodes", controller.GetEpisodes).Methods("GET", "OPTIONS")
+	animeRouter.Handle("/{id:[0-9]+}/episodes", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.CreateEpisode)))).Methods("OPTIONS", "POST")
+	animeRouter.HandleFunc("/{id:[0-9]+}/episodes/{episode_id:[0-9]+}", controller.GetEpisode).Methods("GET", "OPTIONS")
+	animeRouter.Handle("/{id:[0-9]+}/episodes/{episode_id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.UpdateEpisode)))).Methods("OPTIONS", "PUT")
+	animeRouter.Handle("/{id:[0-9]+}/episodes/{episode_id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeDelete)(http.HandlerFunc(controller.DeleteEpisode)))).Methods("OPTIONS", "DELETE")
+
+	animeRouter.HandleFunc("/{id:[0-9]+}/credits", controller.GetAnimeCredits).Methods("GET", "OPTIONS")
+	animeRouter.Handle("/{id:[0-9]+}/{kind:studios|staff|characters}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.SetAnimeCredits)))).Methods("OPTIONS", "PUT")
+
+	animeRouter.Handle("/{id:[0-9]+}/images/{kind:cover|banner}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.UploadAnimeImage)))).Methods("OPTIONS", "POST")
+	animeRouter.Handle("/{id:[0-9]+}/images/{kind:cover|banner}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeDelete)(http.HandlerFunc(controller.DeleteAnimeImage)))).Methods("OPTIONS", "DELETE")
+
+	animeRouter.HandleFunc("/{id:[0-9]+}/titles", controller.GetAnimeTitles).Methods("GET", "OPTIONS")
+	animeRouter.Handle("/{id:[0-9]+}/titles", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.SetAnimeTitles)))).Methods("OPTIONS", "PUT")
+
+	animeRouter.HandleFunc("/{id:[0-9]+}/related", controller.GetRelatedAnime).Methods("GET", "OPTIONS")
+	animeRouter.Handle("/{id:[0-9]+}/related", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.AddRelatedAnime)))).Methods("OPTIONS", "POST")
+	animeRouter.Handle("/{id:[0-9]+}/related/{related_id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.RemoveRelatedAnime)))).Methods("OPTIONS", "DELETE")
+
+	// Franchise dibuat otomatis dari relasi anime
+	franchiseRouter := router.PathPrefix("/franchises").Subrouter()
+	franchiseRouter.HandleFunc("/", controller.GetFranchises).Methods("GET", "OPTIONS")
+	franchiseRouter.HandleFunc("/{id:[0-9]+}", controller.GetFranchise).Methods("GET", "OPTIONS")
+	franchiseRouter.Handle("/{id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.UpdateFranchise)))).Methods("OPTIONS", "PUT")
+	franchiseRouter.HandleFunc("/{id:[0-9]+}/watch-order", controller.GetFranchiseWatchOrder).Methods("GET", "OPTIONS")
+
+	// Studio, staf dan tokoh (butuh permission anime:write / anime:delete untuk mengubah)
+	studioRouter := router.PathPrefix("/studios").Subrouter()
+	studioRouter.HandleFunc("/", controller.GetStudios).Methods("GET", "OPTIONS")
+	studioRouter.Handle("/", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.CreateStudio)))).Methods("OPTIONS", "POST")
+	studioRouter.HandleFunc("/{id:[0-9]+}", controller.GetStudio).Methods("GET", "OPTIONS")
+	studioRouter.Handle("/{id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.UpdateStudio)))).Methods("OPTIONS", "PUT")
+	studioRouter.Handle("/{id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeDelete)(http.HandlerFunc(controller.DeleteStudio)))).Methods("OPTIONS", "DELETE")
+	studioRouter.HandleFunc("/{id:[0-9]+}/anime", controller.GetStudioAnime).Methods("GET", "OPTIONS")
+
+	peopleRouter := router.PathPrefix("/people").Subrouter()
+	peopleRouter.HandleFunc("/", controller.GetPeople).Methods("GET", "OPTIONS")
+	peopleRouter.Handle("/", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.CreatePerson)))).Methods("OPTIONS", "POST")
+	peopleRouter.HandleFunc("/{id:[0-9]+}", controller.GetPerson).Methods("GET", "OPTIONS")
+	peopleRouter.Handle("/{id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.UpdatePerson)))).Methods("OPTIONS", "PUT")
+	peopleRouter.Handle("/{id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeDelete)(http.HandlerFunc(controller.DeletePerson)))).Methods("OPTIONS", "DELETE")
+	peopleRouter.HandleFunc("/{id:[0-9]+}/credits", controller.GetPersonCredits).Methods("GET", "OPTIONS")
+
+	characterRouter := router.PathPrefix("/characters").Subrouter()
+	characterRouter.HandleFunc("/", controller.GetCharacters).Methods("GET", "OPTIONS")
+	characterRouter.Handle("/", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.CreateCharacter)))).Methods("OPTIONS", "POST")
+	characterRouter.HandleFunc("/{id:[0-9]+}", controller.GetCharacter).Methods("GET", "OPTIONS")
+	characterRouter.Handle("/{id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.UpdateCharacter)))).Methods("OPTIONS", "PUT")
+	characterRouter.Handle("/{id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeDelete)(http.HandlerFunc(controller.DeleteCharacter)))).Methods("OPTIONS", "DELETE")
+
+	// Genre Routes (butuh permission anime:write / anime:delete untuk mengubah)
+	genreRouter := router.PathPrefix("/genres").Subrouter()
+	genreRouter.HandleFunc("/", controller.GetGenres).Methods("GET", "OPTIONS")
+	genreRouter.Handle("/", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.CreateGenre)))).Methods("OPTIONS", "POST")
+	genreRouter.Handle("/{id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(controller.UpdateGenre)))).Methods("OPTIONS", "PUT")
+	genreRouter.Handle("/{id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermAnimeDelete)(http.HandlerFunc(controller.DeleteGenre)))).Methods("OPTIONS", "DELETE")
 
 	// Review Routes
 	reviewRouter := router.PathPrefix("/review").Subrouter()
 	reviewRouter.Handle("/reviews", utils.AuthMiddleware(http.HandlerFunc(controller.GetUserReviews))).Methods("GET", "OPTIONS")
 	reviewRouter.Handle("/anime/{anime_id}", utils.AuthMiddleware(http.HandlerFunc(controller.LoadReviews))).Methods("GET")
 	reviewRouter.Handle("/anime/{anime_id}/{user_id}", utils.AuthMiddleware(http.HandlerFunc(controller.CheckUserRating))).Methods("OPTIONS", "GET")
-	reviewRouter.Handle("/anime/{anime_id}", utils.AuthMiddleware(http.HandlerFunc(controller.AddReview))).Methods("OPTIONS", "POST")
-	reviewRouter.Handle("/{review_id}", utils.AuthMiddleware(http.HandlerFunc(controller.EditReview))).Methods("OPTIONS", "PUT")
+	reviewRouter.Handle("/anime/{anime_id}", utils.AuthMiddleware(utils.VerifiedEmailMiddleware(http.HandlerFunc(controller.AddReview)))).Methods("OPTIONS", "POST")
+	reviewRouter.Handle("/{review_id}", utils.AuthMiddleware(utils.VerifiedEmailMiddleware(http.HandlerFunc(controller.EditReview)))).Methods("OPTIONS", "PUT")
 	reviewRouter.Handle("/{review_id}", utils.AuthMiddleware(http.HandlerFunc(controller.DeleteReview))).Methods("OPTIONS", "DELETE")
 
 	// Favorite Routes
 	favoriteRouter := router.PathPrefix("/favorites").Subrouter()
-	favoriteRouter.Handle("/{anime_id}", utils.AuthMiddleware(http.HandlerFunc(controller.AddFavorite))).Methods("POST", "OPTIONS")
+	favoriteRouter.Handle("/{anime_id}", utils.AuthMiddleware(utils.VerifiedEmailMiddleware(http.HandlerFunc(controller.AddFavorite)))).Methods("POST", "OPTIONS")
 	favoriteRouter.Handle("/", utils.AuthMiddleware(http.HandlerFunc(controller.GetFavorites))).Methods("GET", "OPTIONS")
 	favoriteRouter.Handle("/{id}", utils.AuthMiddleware(http.HandlerFunc(controller.DeleteFavorite))).Methods("DELETE", "OPTIONS")
 
+	// Admin Routes
+	adminRouter := router.PathPrefix("/admin").Subrouter()
+	adminRouter.Handle("/lockouts", utils.AuthMiddleware(utils.RequirePermission(utils.PermUserManage)(http.HandlerFunc(controller.ClearLoginLockout)))).Methods("OPTIONS", "DELETE")
+	adminRouter.Handle("/permissions", utils.AuthMiddleware(utils.RequirePermission(utils.PermRoleManage)(http.HandlerFunc(controller.GetPermissions)))).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/roles", utils.AuthMiddleware(utils.RequirePermission(utils.PermRoleManage)(http.HandlerFunc(controller.GetRoles)))).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/roles", utils.AuthMiddleware(utils.RequirePermission(utils.PermRoleManage)(http.HandlerFunc(controller.CreateRole)))).Methods("OPTIONS", "POST")
+	adminRouter.Handle("/roles/{name}", utils.AuthMiddleware(utils.RequirePermission(utils.PermRoleManage)(http.HandlerFunc(controller.UpdateRole)))).Methods("OPTIONS", "PUT")
+	adminRouter.Handle("/roles/{name}", utils.AuthMiddleware(utils.RequirePermission(utils.PermRoleManage)(http.HandlerFunc(controller.DeleteRole)))).Methods("OPTIONS", "DELETE")
+	adminRouter.Handle("/users", utils.AuthMiddleware(utils.RequirePermission(utils.PermUserManage)(http.HandlerFunc(controller.GetUsers)))).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/users/{id:[0-9]+}", utils.AuthMiddleware(utils.RequirePermission(utils.PermUserManage)(http.HandlerFunc(controller.GetUser)))).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/users/{id:[0-9]+}/ban", utils.AuthMiddleware(utils.RequirePermission(utils.PermUserBan)(http.HandlerFunc(controller.BanUser)))).Methods("OPTIONS", "POST")
+	adminRouter.Handle("/users/{id:[0-9]+}/ban", utils.AuthMiddleware(utils.RequirePermission(utils.PermUserBan)(http.HandlerFunc(controller.UnbanUser)))).Methods("OPTIONS", "DELETE")
+	adminRouter.Handle("/users/{id:[0-9]+}/suspend", utils.AuthMiddleware(utils.RequirePermission(utils.PermUserBan)(http.HandlerFunc(controller.SuspendUser)))).Methods("OPTIONS", "POST")
+	adminRouter.Handle("/users/{id:[0-9]+}/suspend", utils.AuthMiddleware(utils.RequirePermission(utils.PermUserBan)(http.HandlerFunc(controller.UnsuspendUser)))).Methods("OPTIONS", "DELETE")
+	adminRouter.Handle("/users/{id:[0-9]+}/password-reset", utils.AuthMiddleware(utils.RequirePermission(utils.PermUserManage)(http.HandlerFunc(controller.ForcePasswordReset)))).Methods("OPTIONS", "POST")
+	adminRouter.Handle("/audit-log", utils.AuthMiddleware(utils.RequirePermission(utils.PermAuditRead)(http.HandlerFunc(controller.GetAuditLog)))).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/users/{id:[0-9]+}/role", utils.AuthMiddleware(utils.RequirePermission(utils.PermUserManage)(http.HandlerFunc(controller.AssignUserRole)))).Methods("OPTIONS", "PUT")
+
 	return router
 }
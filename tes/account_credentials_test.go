@@ -0,0 +1,142 @@
+package tes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// createUserWithPassword membuat pengguna dengan hash bcrypt dari password
+func createUserWithPassword(t *testing.T, name, password string) models.User {
+	t.Helper()
+	user := createTestUser(t, name)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := utils.DB.Model(&user).Update("password", string(hash)).Error; err != nil {
+		t.Fatalf("failed to set password: %v", err)
+	}
+	user.Password = string(hash)
+	return user
+}
+
+func TestChangePasswordRevokesOldTokens(t *testing.T) {
+	setupAuthModels(t)
+	user := createUserWithPassword(t, "changepw", "Old-Passphrase-4821")
+	current, _ := loginAs(t, user)
+	other, _ := loginAs(t, user)
+
+	rec := serveWithToken(utils.SessionAuthMiddleware(http.HandlerFunc(controller.ChangePassword)),
+		http.MethodPost, "/user/password", current,
+		`{"current_password":"Old-Passphrase-4821","new_password":"Fresh-Lantern-Orbit-93"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+
+	var stored models.User
+	utils.DB.First(&stored, user.ID)
+	if stored.TokenVersion != user.TokenVersion+1 {
+		t.Errorf("expected token_version to be bumped to %d, got %d", user.TokenVersion+1, stored.TokenVersion)
+	}
+
+	// JWT lama dari sesi ini maupun sesi lain ditolak
+	auth := utils.AuthMiddleware(okHandler)
+	if code := serveWithToken(auth, http.MethodGet, "/", current, "").Code; code != http.StatusUnauthorized {
+		t.Errorf("expected the old token of the current session to be rejected, got %d", code)
+	}
+	if code := serveWithToken(auth, http.MethodGet, "/", other, "").Code; code != http.StatusUnauthorized {
+		t.Errorf("expected the token of another session to be rejected, got %d", code)
+	}
+	// Token baru dari respons tetap bisa dipakai
+	if code := serveWithToken(auth, http.MethodGet, "/", resp.Token, "").Code; code != http.StatusNoContent {
+		t.Errorf("expected the new token to be accepted, got %d", code)
+	}
+}
+
+func TestChangeEmailAppliesAfterVerification(t *testing.T) {
+	setupAuthModels(t, &models.EmailVerificationToken{})
+	mailer := useCaptureMailer(t)
+	user := createUserWithPassword(t, "changemail", "Old-Passphrase-4821")
+	token, _ := loginAs(t, user)
+	newEmail := "new-" + user.Email
+
+	rec := serveWithToken(utils.SessionAuthMiddleware(http.HandlerFunc(controller.ChangeEmail)),
+		http.MethodPost, "/user/email", token,
+		`{"current_password":"Old-Passphrase-4821","new_email":"`+newEmail+`"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var stored models.User
+	utils.DB.First(&stored, user.ID)
+	if stored.Email != user.Email {
+		t.Fatalf("expected the email to stay %q until verified, got %q", user.Email, stored.Email)
+	}
+
+	// Email verifikasi ke alamat baru dan pemberitahuan ke alamat lama
+	var verifyMail *utils.Mail
+	for i := range mailer.sent {
+		if mailer.sent[i].To == newEmail {
+			verifyMail = &mailer.sent[i]
+		}
+	}
+	if verifyMail == nil || len(mailer.sent) != 2 {
+		t.Fatalf("expected a verification email to %s and a notice to the old address, got %+v", newEmail, mailer.sent)
+	}
+	match := mailTokenPattern.FindStringSubmatch(verifyMail.Body)
+	if match == nil {
+		t.Fatal("no token in verification email")
+	}
+
+	if _, err := utils.VerifyEmailToken(match[1]); err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+	utils.DB.First(&stored, user.ID)
+	if stored.Email != newEmail {
+		t.Errorf("expected the email to change to %q, got %q", newEmail, stored.Email)
+	}
+	if code := serveWithToken(utils.AuthMiddleware(okHandler), http.MethodGet, "/", token, "").Code; code != http.StatusUnauthorized {
+		t.Errorf("expected tokens issued before the email change to be revoked, got %d", code)
+	}
+}
+
+func TestChangeEmailWithoutPassword(t *testing.T) {
+	setupAuthModels(t, &models.EmailVerificationToken{})
+	mailer := useCaptureMailer(t)
+	// Akun dari identity provider tidak punya password
+	user := createTestUser(t, "changemail-idp")
+	if err := utils.DB.Model(&user).Update("password_unset", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	token, _ := loginAs(t, user)
+	newEmail := "new-" + user.Email
+
+	rec := serveWithToken(utils.SessionAuthMiddleware(http.HandlerFunc(controller.ChangeEmail)),
+		http.MethodPost, "/user/email", token, `{"new_email":"`+newEmail+`"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if mailer.count() != 2 {
+		t.Errorf("expected a verification email and a notice, got %d emails", mailer.count())
+	}
+
+	// Akun dengan password tetap wajib mengirim password saat ini
+	withPassword := createUserWithPassword(t, "changemail-pw", "Old-Passphrase-4821")
+	token, _ = loginAs(t, withPassword)
+	rec = serveWithToken(utils.SessionAuthMiddleware(http.HandlerFunc(controller.ChangeEmail)),
+		http.MethodPost, "/user/email", token, `{"new_email":"new-`+withPassword.Email+`"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without current password, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,179 @@
+package tes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"gorm.io/gorm"
+)
+
+// setupDeletionModels membuat semua tabel yang disentuh DeleteAccount
+func setupDeletionModels(t *testing.T) {
+	t.Helper()
+	setupAuthModels(t,
+		&models.PasswordResetToken{}, &models.EmailVerificationToken{}, &models.RecoveryCode{},
+		&models.APIKey{}, &models.LinkedIdentity{}, &models.OAuthState{},
+		&models.Genre{}, &models.Anime{}, &models.Review{}, &models.Favorite{},
+	)
+}
+
+// createReviewedAnime membuat anime dengan satu review per rating dari penulis yang diberikan
+func createReviewedAnime(t *testing.T, reviews map[int]int64) models.Anime {
+	t.Helper()
+	anime := models.Anime{Title: fmt.Sprintf("Deletion test %d", time.Now().UnixNano())}
+	if err := utils.DB.Omit("Genres").Create(&anime).Error; err != nil {
+		t.Fatalf("failed to create anime: %v", err)
+	}
+	for userID, rating := range reviews {
+		review := models.Review{UserID: userID, AnimeID: anime.ID, Rating: rating, Content: "test"}
+		if err := utils.DB.Create(&review).Error; err != nil {
+			t.Fatalf("failed to create review: %v", err)
+		}
+	}
+	if err := models.RecalculateAverageRating(utils.DB, anime.ID); err != nil {
+		t.Fatalf("failed to calculate rating: %v", err)
+	}
+	return anime
+}
+
+func TestScheduleAndCancelAccountDeletion(t *testing.T) {
+	setupDeletionModels(t)
+	t.Setenv("ACCOUNT_DELETION_GRACE_PERIOD", "72h")
+	mailer := useCaptureMailer(t)
+	user := createTestUser(t, "schedule-delete")
+
+	scheduledAt, err := utils.ScheduleAccountDeletion(user, utils.DeletionModeDelete)
+	if err != nil {
+		t.Fatalf("schedule failed: %v", err)
+	}
+	if d := time.Until(scheduledAt); d < 71*time.Hour || d > 73*time.Hour {
+		t.Errorf("expected deletion in about 72h, got %v", d)
+	}
+	if mailer.count() != 1 {
+		t.Errorf("expected a deletion notice, got %d emails", mailer.count())
+	}
+
+	var stored models.User
+	utils.DB.First(&stored, user.ID)
+	if stored.DeletionScheduledAt == nil || stored.DeletionMode != utils.DeletionModeDelete {
+		t.Fatalf("expected the deletion to be stored, got %v %q", stored.DeletionScheduledAt, stored.DeletionMode)
+	}
+	if _, err := utils.ScheduleAccountDeletion(stored, utils.DeletionModeDelete); !errors.Is(err, utils.ErrDeletionAlreadyQueued) {
+		t.Errorf("expected ErrDeletionAlreadyQueued, got %v", err)
+	}
+
+	if err := utils.CancelAccountDeletion(user.ID); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	var cancelled models.User
+	utils.DB.First(&cancelled, user.ID)
+	if cancelled.DeletionScheduledAt != nil || cancelled.DeletionMode != "" {
+		t.Errorf("expected the schedule to be cleared, got %v %q", cancelled.DeletionScheduledAt, cancelled.DeletionMode)
+	}
+	if err := utils.CancelAccountDeletion(user.ID); !errors.Is(err, utils.ErrDeletionNotScheduled) {
+		t.Errorf("expected ErrDeletionNotScheduled, got %v", err)
+	}
+}
+
+func TestProcessDueAccountDeletions(t *testing.T) {
+	setupDeletionModels(t)
+	due := createTestUser(t, "due-delete")
+	later := createTestUser(t, "later-delete")
+	utils.DB.Model(&due).Updates(map[string]interface{}{
+		"deletion_scheduled_at": time.Now().Add(-time.Minute), "deletion_mode": utils.DeletionModeDelete,
+	})
+	utils.DB.Model(&later).Updates(map[string]interface{}{
+		"deletion_scheduled_at": time.Now().Add(time.Hour), "deletion_mode": utils.DeletionModeDelete,
+	})
+
+	deleted, err := utils.ProcessDueAccountDeletions()
+	if err != nil {
+		t.Fatalf("processing failed: %v", err)
+	}
+	if deleted < 1 {
+		t.Errorf("expected at least the due account to be deleted, got %d", deleted)
+	}
+	if err := utils.DB.First(&models.User{}, due.ID).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected the due account to be deleted, got %v", err)
+	}
+	var stored models.User
+	if err := utils.DB.First(&stored, later.ID).Error; err != nil || stored.DeletionScheduledAt == nil {
+		t.Errorf("expected the account still in its grace period to be kept, got %v", err)
+	}
+}
+
+func TestDeleteAccountModes(t *testing.T) {
+	setupDeletionModels(t)
+	anonymized := createTestUser(t, "anonymize")
+	removed := createTestUser(t, "remove")
+	kept := createTestUser(t, "kept-reviewer")
+	anime := createReviewedAnime(t, map[int]int64{anonymized.ID: 4, removed.ID: 1, kept.ID: 5})
+	if anime.ID == 0 {
+		t.Fatal("anime was not created")
+	}
+	var before models.Anime
+	utils.DB.First(&before, anime.ID)
+	if before.AverageRating < 3.3 || before.AverageRating > 3.4 {
+		t.Fatalf("expected an average rating of 3.33, got %v", before.AverageRating)
+	}
+
+	// Anonimkan: akun tetap ada tanpa data pribadi, review tetap dihitung
+	if err := utils.DeleteAccount(anonymized.ID, utils.DeletionModeAnonymize); err != nil {
+		t.Fatalf("anonymize failed: %v", err)
+	}
+	var stored models.User
+	utils.DB.First(&stored, anonymized.ID)
+	if stored.Username != "Deleted user" || stored.Email == anonymized.Email || stored.Password != "" || stored.TokenVersion == anonymized.TokenVersion {
+		t.Errorf("expected the profile to be anonymized, got %+v", stored)
+	}
+	var count int64
+	utils.DB.Model(&models.Review{}).Where("user_id = ?", anonymized.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("expected the anonymized user's review to be kept, got %d", count)
+	}
+
+	// Hapus: akun dan review hilang, rating dihitung ulang dari review yang tersisa
+	if err := utils.DeleteAccount(removed.ID, utils.DeletionModeDelete); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := utils.DB.First(&models.User{}, removed.ID).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected the user to be deleted, got %v", err)
+	}
+	utils.DB.Model(&models.Review{}).Where("user_id = ?", removed.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the deleted user's reviews to be removed, got %d", count)
+	}
+	var after models.Anime
+	utils.DB.First(&after, anime.ID)
+	if after.AverageRating != 4.5 {
+		t.Errorf("expected the average rating to be recalculated to 4.5, got %v", after.AverageRating)
+	}
+}
+
+func TestDeleteAccountImmediateCORS(t *testing.T) {
+	setupDeletionModels(t)
+	t.Setenv("ACCOUNT_DELETION_GRACE_PERIOD", "0")
+	user := createUserWithPassword(t, "delete-now", "Old-Passphrase-4821")
+
+	req := httptest.NewRequest(http.MethodDelete, "/user", strings.NewReader(`{"password":"Old-Passphrase-4821","mode":"delete"}`))
+	req = req.WithContext(context.WithValue(req.Context(), utils.UserIDKey, user.ID))
+	rec := httptest.NewRecorder()
+	controller.DeleteAccount(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") == "" {
+		t.Error("expected the 204 response to carry Access-Control-Allow-Origin")
+	}
+}
@@ -0,0 +1,32 @@
+package tes
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestAccountRestriction(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	if err := utils.AccountRestriction(models.User{}, now); err != nil {
+		t.Errorf("expected no restriction, got %v", err)
+	}
+	if err := utils.AccountRestriction(models.User{BannedAt: &past}, now); !errors.Is(err, utils.ErrAccountBanned) {
+		t.Errorf("expected banned, got %v", err)
+	}
+	if err := utils.AccountRestriction(models.User{SuspendedUntil: &past}, now); err != nil {
+		t.Errorf("expected expired suspension to be ignored, got %v", err)
+	}
+
+	err := utils.AccountRestriction(models.User{SuspendedUntil: &future}, now)
+	var suspended *utils.AccountSuspendedError
+	if !errors.Is(err, utils.ErrAccountSuspended) || !errors.As(err, &suspended) || !suspended.Until.Equal(future) {
+		t.Errorf("expected suspension until %v, got %v", future, err)
+	}
+}
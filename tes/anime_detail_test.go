@@ -0,0 +1,45 @@
+package tes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"NYANIMEBACKEND/utils"
+)
+
+func TestBuildRatingHistogram(t *testing.T) {
+	histogram, total := utils.BuildRatingHistogram([]utils.RatingCount{
+		{Rating: 5, Count: 7},
+		{Rating: 3, Count: 2},
+		{Rating: 0, Count: 1}, // Data lama di luar rentang 1-5
+	})
+	if total != 10 {
+		t.Errorf("expected total 10, got %d", total)
+	}
+	want := map[int]int64{1: 0, 2: 0, 3: 2, 4: 0, 5: 7}
+	for rating, count := range want {
+		if histogram[rating] != count {
+			t.Errorf("rating %d: expected %d, got %d", rating, count, histogram[rating])
+		}
+	}
+	if len(histogram) != 5 {
+		t.Errorf("expected buckets 1-5 only, got %v", histogram)
+	}
+}
+
+func TestOptionalAuthMiddlewareAllowsAnonymous(t *testing.T) {
+	called := false
+	handler := utils.OptionalAuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if _, ok := r.Context().Value(utils.UserIDKey).(int); ok {
+			t.Error("anonymous request should not carry a user ID")
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/anime/1", nil))
+	if !called || rec.Code != http.StatusOK {
+		t.Errorf("expected anonymous request to reach handler, got status %d", rec.Code)
+	}
+}
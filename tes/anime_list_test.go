@@ -0,0 +1,105 @@
+package tes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestParseAnimeListParams(t *testing.T) {
+	query, _ := url.ParseQuery("page=2&limit=500&genre=Action,%20Comedy&year_from=2010&year_to=2020&min_rating=3.5&sort=-rating")
+	params, err := utils.ParseAnimeListParams(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Legacy {
+		t.Error("page and limit should select the paginated response")
+	}
+	if params.Page != 2 || params.Limit != 100 {
+		t.Errorf("expected page 2 and limit capped at 100, got %d/%d", params.Page, params.Limit)
+	}
+	if len(params.Genres) != 2 || params.Genres[1] != "Comedy" {
+		t.Errorf("unexpected genres %v", params.Genres)
+	}
+	if params.YearFrom != 2010 || params.YearTo != 2020 || params.MinRating == nil || *params.MinRating != 3.5 {
+		t.Errorf("unexpected filters %+v", params)
+	}
+	if params.Sort != "rating" || !params.Desc {
+		t.Errorf("expected descending rating sort, got %s desc=%v", params.Sort, params.Desc)
+	}
+
+	for _, bad := range []string{"sort=password", "year_from=2020&year_to=2010", "min_rating=9", "order=sideways", "page=0", "cursor=not-a-cursor"} {
+		q, _ := url.ParseQuery(bad)
+		if _, err := utils.ParseAnimeListParams(q); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestAnimeCursorRoundTrip(t *testing.T) {
+	cursor := utils.AnimeCursor{Sort: "title", Desc: true, Value: "Nichijou", ID: 42}
+
+	query := url.Values{"cursor": {cursor.Encode()}, "sort": {"id"}}
+	params, err := utils.ParseAnimeListParams(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !params.UseCursor || params.Legacy || params.Cursor == nil || *params.Cursor != cursor {
+		t.Fatalf("cursor did not round-trip: %+v", params.Cursor)
+	}
+	// Urutan dari cursor menimpa parameter sort
+	if params.Sort != "title" || !params.Desc {
+		t.Errorf("expected sort from cursor, got %s desc=%v", params.Sort, params.Desc)
+	}
+}
+
+func TestGetAllAnimeResponseShape(t *testing.T) {
+	setupModels(t, &models.Genre{}, &models.Anime{}, &models.AnimeTitle{}, &models.AnimeImage{},
+		&models.Episode{}, &models.Review{})
+	anime := models.Anime{Title: fmt.Sprintf("List shape test %d", time.Now().UnixNano())}
+	if err := utils.DB.Create(&anime).Error; err != nil {
+		t.Fatalf("failed to create anime: %v", err)
+	}
+
+	// Tanpa page, limit atau cursor respons tetap array seperti klien lama harapkan
+	rec := serveWithToken(http.HandlerFunc(controller.GetAllAnime), http.MethodGet, "/anime/", "", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var legacy []models.AnimeListItem
+	if err := json.Unmarshal(rec.Body.Bytes(), &legacy); err != nil {
+		t.Fatalf("expected bare array without pagination params: %v", err)
+	}
+	found := false
+	for _, item := range legacy {
+		found = found || item.ID == anime.ID
+	}
+	if !found {
+		t.Error("legacy response should list every anime")
+	}
+
+	rec = serveWithToken(http.HandlerFunc(controller.GetAllAnime), http.MethodGet, "/anime/?limit=1", "", "")
+	var page struct {
+		Data []models.AnimeListItem `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("expected {data, meta} with limit: %v", err)
+	}
+	if len(page.Data) != 1 || page.Meta["limit"] != float64(1) {
+		t.Errorf("unexpected page %+v", page)
+	}
+	for _, want := range []string{"Link", "X-Total-Count"} {
+		if !strings.Contains(rec.Header().Get("Access-Control-Expose-Headers"), want) {
+			t.Errorf("%s should be exposed to the frontend", want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package tes
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"NYANIMEBACKEND/utils"
+)
+
+func TestAPIKeyFromRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/anime/", nil)
+	req.Header.Set("X-API-Key", "nya_header")
+	if got := utils.APIKeyFromRequest(req); got != "nya_header" {
+		t.Errorf("expected key from X-API-Key, got %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/anime/", nil)
+	req.Header.Set("Authorization", "ApiKey nya_auth")
+	if got := utils.APIKeyFromRequest(req); got != "nya_auth" {
+		t.Errorf("expected key from Authorization, got %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/anime/", nil)
+	req.Header.Set("Authorization", "Bearer eyJ...")
+	if got := utils.APIKeyFromRequest(req); got != "" {
+		t.Errorf("expected no API key for bearer token, got %q", got)
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	perms := []string{utils.PermAnimeWrite}
+
+	if err := utils.ValidateScopes([]string{utils.ScopeRead, utils.ScopeWrite, utils.PermAnimeWrite}, perms); err != nil {
+		t.Errorf("expected scopes to be valid, got %v", err)
+	}
+	if err := utils.ValidateScopes([]string{utils.PermUserManage}, perms); !errors.Is(err, utils.ErrUnknownScope) {
+		t.Errorf("expected permission the user lacks to be rejected, got %v", err)
+	}
+	if err := utils.ValidateScopes([]string{"admin"}, perms); !errors.Is(err, utils.ErrUnknownScope) {
+		t.Errorf("expected unknown scope to be rejected, got %v", err)
+	}
+}
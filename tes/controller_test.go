@@ -29,9 +29,9 @@ func TestRegister(t *testing.T) {
 		body       models.User
 		statusCode int
 	}{
-		{"ValidRegister", models.User{Username: "newsuser", Email: "barus@example.com", Password: "password"}, http.StatusCreated},
-		{"InvalidRegister", models.User{Username: "", Email: "salah@example.com", Password: "password"}, http.StatusBadRequest},
-		{"EmailAlreadyExists", models.User{Username: "existinguser", Email: "sudahadda@example.com", Password: "password"}, http.StatusConflict},
+		{"ValidRegister", models.User{Username: "newsuser", Email: "barus@example.com", Password: "Nyan-Cat!2024"}, http.StatusCreated},
+		{"InvalidRegister", models.User{Username: "", Email: "salah@example.com", Password: "Nyan-Cat!2024"}, http.StatusBadRequest},
+		{"EmailAlreadyExists", models.User{Username: "existinguser", Email: "sudahadda@example.com", Password: "Nyan-Cat!2024"}, http.StatusConflict},
 	}
 
 	for _, tt := range tests {
@@ -59,8 +59,8 @@ func TestLogin(t *testing.T) {
 		body       map[string]string
 		statusCode int
 	}{
-		{"ValidLogin", map[string]string{"username": "newsuser", "password": "password"}, http.StatusOK},
-		{"InvalidLogin", map[string]string{"username": "", "password": "password"}, http.StatusUnauthorized},
+		{"ValidLogin", map[string]string{"username": "newsuser", "password": "Nyan-Cat!2024"}, http.StatusOK},
+		{"InvalidLogin", map[string]string{"username": "", "password": "Nyan-Cat!2024"}, http.StatusUnauthorized},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,86 @@
+package tes
+
+import (
+	"testing"
+
+	"NYANIMEBACKEND/utils"
+)
+
+func TestNormalizeCreditRole(t *testing.T) {
+	cases := map[string]string{
+		"Director":            "director",
+		" Series Composition": "series_composition",
+		"sound-director":      "sound_director",
+	}
+	for in, want := range cases {
+		if got := utils.NormalizeCreditRole(in); got != want {
+			t.Errorf("NormalizeCreditRole(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeStaffLinks(t *testing.T) {
+	links, err := utils.NormalizeStaffLinks([]utils.StaffLink{
+		{PersonID: 1, Role: "Director"},
+		{PersonID: 1, Role: "director"},
+		{PersonID: 1, Role: "Storyboard"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 2 || links[0].Role != "director" || links[1].Role != "storyboard" {
+		t.Errorf("unexpected links %+v", links)
+	}
+	if _, err := utils.NormalizeStaffLinks([]utils.StaffLink{{PersonID: 1}}); err == nil {
+		t.Error("expected error for missing role")
+	}
+	if _, err := utils.NormalizeStudioLinks([]utils.StudioLink{{Role: "animation"}}); err == nil {
+		t.Error("expected error for missing studio_id")
+	}
+}
+
+func TestNormalizeCharacterLinks(t *testing.T) {
+	va := uint(7)
+	links, err := utils.NormalizeCharacterLinks([]utils.CharacterLink{
+		{CharacterID: 1, Role: "Main", VoiceActorID: &va},
+		{CharacterID: 1, Role: "main", Language: "EN"},
+		{CharacterID: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links[0].Language != utils.DefaultVoiceLanguage || links[0].Role != utils.CharacterRoleMain {
+		t.Errorf("unexpected defaults %+v", links[0])
+	}
+	if links[1].Language != "en" || links[2].Role != utils.CharacterRoleSupporting {
+		t.Errorf("unexpected normalization %+v %+v", links[1], links[2])
+	}
+
+	bad := map[string][]utils.CharacterLink{
+		"duplicate language": {{CharacterID: 1}, {CharacterID: 1, Language: "ja"}},
+		"unknown role":       {{CharacterID: 1, Role: "villain"}},
+		"invalid language":   {{CharacterID: 1, Language: "japanese!"}},
+		"missing character":  {{Role: "main"}},
+	}
+	for name, in := range bad {
+		if _, err := utils.NormalizeCharacterLinks(in); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestCreditInputValidate(t *testing.T) {
+	if err := (utils.StudioInput{Name: "  "}).Validate(); err == nil {
+		t.Error("expected error for blank studio name")
+	}
+	long := make([]rune, 129)
+	for i := range long {
+		long[i] = 'a'
+	}
+	if err := (utils.PersonInput{Name: string(long)}).Validate(); err == nil {
+		t.Error("expected error for overlong name")
+	}
+	if err := (utils.CharacterInput{Name: "Frieren"}).Validate(); err != nil {
+		t.Errorf("valid character rejected: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package tes
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/utils"
+)
+
+func TestWriteUserExportZip(t *testing.T) {
+	export := &utils.UserExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    utils.ExportProfile{ID: 3, Username: "neko", Email: "neko@example.com"},
+		Reviews:    []utils.ExportReview{{ID: 1, AnimeID: 9, AnimeTitle: "Nichijou", Rating: 5, Content: "Great"}},
+	}
+
+	var buf bytes.Buffer
+	if err := utils.WriteUserExportZip(&buf, export); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+	for _, name := range []string{"profile.json", "reviews.json", "favorites.json", "sessions.json", "linked_identities.json", "api_keys.json"} {
+		if files[name] == nil {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+
+	rc, err := files["reviews.json"].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	var reviews []utils.ExportReview
+	if err := json.NewDecoder(rc).Decode(&reviews); err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 1 || reviews[0].AnimeTitle != "Nichijou" {
+		t.Errorf("unexpected reviews in archive: %+v", reviews)
+	}
+}
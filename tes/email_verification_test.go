@@ -0,0 +1,171 @@
+package tes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestVerifyEmailToken(t *testing.T) {
+	setupModels(t, &models.User{}, &models.EmailVerificationToken{})
+	mailer := useCaptureMailer(t)
+	user := createTestUser(t, "verify")
+
+	if err := utils.SendVerificationEmail(user, user.Email); err != nil {
+		t.Fatalf("failed to send verification email: %v", err)
+	}
+	raw := mailer.lastToken(t)
+
+	verified, err := utils.VerifyEmailToken(raw)
+	if err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+	if verified.VerifiedAt == nil {
+		t.Error("expected verified_at to be set")
+	}
+
+	var stored models.User
+	utils.DB.First(&stored, user.ID)
+	if stored.VerifiedAt == nil {
+		t.Error("expected verified_at to be saved")
+	}
+
+	if _, err := utils.VerifyEmailToken(raw); !errors.Is(err, utils.ErrVerificationTokenInvalid) {
+		t.Errorf("expected a used token to be rejected, got %v", err)
+	}
+}
+
+func TestResendVerificationCooldown(t *testing.T) {
+	setupModels(t, &models.User{}, &models.EmailVerificationToken{})
+	t.Setenv("EMAIL_VERIFICATION_COOLDOWN", "1h")
+	mailer := useCaptureMailer(t)
+	user := createTestUser(t, "resend")
+
+	resend := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/user/verify/resend", nil)
+		req = req.WithContext(context.WithValue(req.Context(), utils.UserIDKey, user.ID))
+		rec := httptest.NewRecorder()
+		controller.ResendVerification(rec, req)
+		return rec
+	}
+
+	if rec := resend(); rec.Code != http.StatusOK {
+		t.Fatalf("expected the first resend to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := resend()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 during the cooldown, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+	if mailer.count() != 1 {
+		t.Errorf("expected one email, got %d", mailer.count())
+	}
+
+	t.Setenv("EMAIL_VERIFICATION_COOLDOWN", "0s")
+	if rec := resend(); rec.Code != http.StatusOK {
+		t.Errorf("expected resend after the cooldown to succeed, got %d", rec.Code)
+	}
+}
+
+func TestVerifiedEmailMiddleware(t *testing.T) {
+	setupModels(t, &models.User{})
+	unverified := createTestUser(t, "unverified")
+	verified := createTestUser(t, "verified")
+	if err := utils.DB.Model(&verified).Update("verified_at", time.Now()).Error; err != nil {
+		t.Fatalf("failed to verify user: %v", err)
+	}
+
+	handler := utils.VerifiedEmailMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	call := func(userID int) int {
+		req := httptest.NewRequest(http.MethodPost, "/reviews", nil)
+		req = req.WithContext(context.WithValue(req.Context(), utils.UserIDKey, userID))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Setenv("REQUIRE_EMAIL_VERIFICATION", "false")
+	if code := call(unverified.ID); code != http.StatusNoContent {
+		t.Errorf("expected unverified users to pass when the policy is off, got %d", code)
+	}
+
+	t.Setenv("REQUIRE_EMAIL_VERIFICATION", "true")
+	if code := call(unverified.ID); code != http.StatusForbidden {
+		t.Errorf("expected 403 for an unverified user, got %d", code)
+	}
+	if code := call(verified.ID); code != http.StatusNoContent {
+		t.Errorf("expected a verified user to pass, got %d", code)
+	}
+}
+
+func TestMarkExistingUsersVerified(t *testing.T) {
+	setupModels(t, &models.User{})
+
+	// Simulasikan database lama tanpa kolom verified_at
+	if err := DB.Migrator().DropColumn(&models.User{}, "VerifiedAt"); err != nil {
+		t.Fatalf("failed to drop verified_at: %v", err)
+	}
+	t.Cleanup(func() { DB.AutoMigrate(&models.User{}) })
+	legacy := models.User{Username: "legacy", Email: fmt.Sprintf("legacy-%d@example.com", time.Now().UnixNano()), Password: "hash", Role: "user"}
+	if err := DB.Omit("VerifiedAt", "Reviews", "Favorites").Create(&legacy).Error; err != nil {
+		t.Fatalf("failed to create legacy user: %v", err)
+	}
+
+	if !utils.VerifiedAtColumnMissing(DB) {
+		t.Fatal("expected the missing column to be detected")
+	}
+	if err := DB.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := utils.MarkExistingUsersVerified(DB); err != nil {
+		t.Fatalf("backfill failed: %v", err)
+	}
+
+	var stored models.User
+	DB.First(&stored, legacy.ID)
+	if stored.VerifiedAt == nil {
+		t.Error("expected the legacy user to be marked verified")
+	}
+
+	// Setelah kolom ada, backfill tidak dijalankan lagi untuk pendaftar baru
+	if utils.VerifiedAtColumnMissing(DB) {
+		t.Error("expected no backfill once the column exists")
+	}
+}
+
+func TestRegisterIgnoresServerManagedFields(t *testing.T) {
+	setupModels(t, &models.User{}, &models.EmailVerificationToken{}, &models.Review{}, &models.Favorite{})
+	useCaptureMailer(t)
+	email := fmt.Sprintf("register-%d@example.com", time.Now().UnixNano())
+
+	body := fmt.Sprintf(`{"username": "register", "email": %q, "password": "Nyan-Cat!2024",
+		"role": "admin", "verified_at": "2024-01-01T00:00:00Z", "totp_enabled": true,
+		"banned_at": "2024-01-01T00:00:00Z", "password_reset_required": true,
+		"preferred_language": "%s", "reviews": [{"anime_id": 1, "rating": 5}]}`, email, strings.Repeat("x", 100))
+	rec := serveWithToken(http.HandlerFunc(controller.Register), http.MethodPost, "/user/register", "", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var stored models.User
+	if err := utils.DB.Preload("Reviews").Where("email = ?", email).First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Role != utils.RoleUser || stored.VerifiedAt != nil || stored.TOTPEnabled || stored.BannedAt != nil ||
+		stored.PasswordResetRequired || stored.PreferredLanguage != "" || len(stored.Reviews) != 0 {
+		t.Errorf("client-supplied fields should be ignored on register, got %+v", stored)
+	}
+}
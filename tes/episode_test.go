@@ -0,0 +1,76 @@
+package tes
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestDateJSONAndScan(t *testing.T) {
+	var ep utils.EpisodeInput
+	if err := json.Unmarshal([]byte(`{"number":1,"air_date":"2023-09-29","duration_minutes":24}`), &ep); err != nil {
+		t.Fatal(err)
+	}
+	if ep.AirDate == nil || ep.AirDate.String() != "2023-09-29" {
+		t.Fatalf("unexpected air date %v", ep.AirDate)
+	}
+	data, _ := json.Marshal(ep.AirDate)
+	if string(data) != `"2023-09-29"` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"air_date":"29/09/2023"}`), &ep); err == nil {
+		t.Error("expected error for non ISO date")
+	}
+	if err := json.Unmarshal([]byte(`{"air_date":null}`), &ep); err != nil || ep.AirDate != nil {
+		t.Errorf("expected null air date, got %v (%v)", ep.AirDate, err)
+	}
+
+	var d models.Date
+	if err := d.Scan(time.Date(2024, 1, 5, 0, 0, 0, 0, time.Local)); err != nil || d.String() != "2024-01-05" {
+		t.Errorf("Scan(time.Time) = %s, %v", d, err)
+	}
+	if err := d.Scan([]byte("2024-02-10 00:00:00")); err != nil || d.String() != "2024-02-10" {
+		t.Errorf("Scan([]byte) = %s, %v", d, err)
+	}
+	if v, _ := models.NewDate(2024, time.March, 1).Value(); v != "2024-03-01" {
+		t.Errorf("Value = %v", v)
+	}
+}
+
+func TestEpisodeInputValidate(t *testing.T) {
+	airDate := models.NewDate(2023, time.September, 29)
+	if err := (utils.EpisodeInput{Number: 1, DurationMinutes: 24, AirDate: &airDate}).Validate(); err != nil {
+		t.Errorf("valid episode rejected: %v", err)
+	}
+
+	old := models.NewDate(1850, time.January, 1)
+	for name, in := range map[string]utils.EpisodeInput{
+		"zero number":   {Number: 0},
+		"negative time": {Number: 1, DurationMinutes: -5},
+		"too long":      {Number: 1, DurationMinutes: utils.MaxEpisodeDurationMinutes + 1},
+		"ancient date":  {Number: 1, AirDate: &old},
+	} {
+		if err := in.Validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+	if err := (utils.SeasonInput{Number: 0}).Validate(); err == nil {
+		t.Error("expected season number validation error")
+	}
+}
+
+func TestAnimeListSortByEpisodeCount(t *testing.T) {
+	query, _ := url.ParseQuery("sort=-episode_count")
+	params, err := utils.ParseAnimeListParams(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Sort != "episode_count" || !params.Desc {
+		t.Errorf("unexpected sort %s desc=%v", params.Sort, params.Desc)
+	}
+}
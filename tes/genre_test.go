@@ -0,0 +1,78 @@
+package tes
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Action":          "action",
+		"  Slice of Life": "slice-of-life",
+		"Sci-Fi":          "sci-fi",
+		"Sci Fi":          "sci-fi",
+		"Boys' Love":      "boys-love",
+		"Mahō Shōjo":      "mahō-shōjo",
+		"--":              "",
+	}
+	for in, want := range cases {
+		if got := utils.Slugify(in); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSplitGenreString(t *testing.T) {
+	got := utils.SplitGenreString("Action, comedy / Drama;action |  Slice  of Life ,")
+	want := []string{"Action", "comedy", "Drama", "Slice of Life"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitGenreString = %v, want %v", got, want)
+	}
+	if got := utils.SplitGenreString("  "); len(got) != 0 {
+		t.Errorf("expected no genres, got %v", got)
+	}
+}
+
+func TestGenreString(t *testing.T) {
+	got := utils.GenreString([]models.Genre{{Name: "Action"}, {Name: "Comedy"}})
+	if got != "Action, Comedy" {
+		t.Errorf("GenreString = %q", got)
+	}
+	if utils.GenreString(nil) != "" {
+		t.Error("expected empty string for no genres")
+	}
+}
+
+func TestCreateAnimeGenreInputs(t *testing.T) {
+	setupModels(t, &models.Genre{}, &models.Anime{})
+	run := time.Now().UnixNano()
+	name := fmt.Sprintf("legacy genre %d", run)
+
+	// Teks genre lama tetap diterima; genre yang belum ada dibuat otomatis
+	body := fmt.Sprintf(`{"title": "Legacy genre test", "genre": "%s"}`, name)
+	rec := serveWithToken(http.HandlerFunc(controller.CreateAnime), http.MethodPost, "/anime/", "", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for legacy genre text, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var genre models.Genre
+	if err := utils.DB.Where("slug = ?", utils.Slugify(name)).First(&genre).Error; err != nil {
+		t.Fatalf("legacy genre was not created: %v", err)
+	}
+	if genre.Name != fmt.Sprintf("Legacy Genre %d", run) {
+		t.Errorf("expected canonical genre name, got %q", genre.Name)
+	}
+
+	// Daftar "genres" hanya menerima genre yang sudah ada
+	body = fmt.Sprintf(`{"title": "Strict genre test", "genres": ["%s-unknown"]}`, name)
+	rec = serveWithToken(http.HandlerFunc(controller.CreateAnime), http.MethodPost, "/anime/", "", body)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown genre in genres, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,172 @@
+package tes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func solidImage(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResizeImageKeepsSolidColor(t *testing.T) {
+	want := color.RGBA{200, 40, 90, 255}
+	out := utils.ResizeImage(solidImage(301, 157, want), 64, 33)
+	if out.Bounds().Dx() != 64 || out.Bounds().Dy() != 33 {
+		t.Fatalf("unexpected size %v", out.Bounds())
+	}
+	for _, p := range []image.Point{{0, 0}, {63, 32}, {31, 16}} {
+		if got := out.RGBAAt(p.X, p.Y); got != want {
+			t.Errorf("pixel %v = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestResizeImageAveragesStripes(t *testing.T) {
+	// Garis hitam-putih selang-seling menjadi abu-abu saat diperkecil setengah
+	src := image.NewGray(image.Rect(0, 0, 64, 8))
+	for x := 0; x < 64; x += 2 {
+		for y := 0; y < 8; y++ {
+			src.SetGray(x, y, color.Gray{255})
+		}
+	}
+	out := utils.ResizeImage(src, 32, 4)
+	got := out.RGBAAt(16, 2).R
+	if got < 110 || got > 145 {
+		t.Errorf("expected mid gray, got %d", got)
+	}
+}
+
+func TestFitWidth(t *testing.T) {
+	cases := []struct{ w, h, max, wantW, wantH int }{
+		{1000, 1500, 320, 320, 480},
+		{200, 300, 320, 200, 300},
+		{4000, 1, 160, 160, 1},
+	}
+	for _, c := range cases {
+		if w, h := utils.FitWidth(c.w, c.h, c.max); w != c.wantW || h != c.wantH {
+			t.Errorf("FitWidth(%d, %d, %d) = %d, %d; want %d, %d", c.w, c.h, c.max, w, h, c.wantW, c.wantH)
+		}
+	}
+}
+
+func TestProcessImageThumbnails(t *testing.T) {
+	processed, err := utils.ProcessImage(encodePNG(t, solidImage(800, 1200, color.White)), models.ImageKindCover)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if processed.ContentType != "image/png" || processed.Width != 800 || processed.Height != 1200 {
+		t.Errorf("unexpected metadata: %s %dx%d", processed.ContentType, processed.Width, processed.Height)
+	}
+	wantWidths := map[string]int{"small": 160, "medium": 320, "large": 640}
+	for name, width := range wantWidths {
+		config, format, err := image.DecodeConfig(bytes.NewReader(processed.Thumbnails[name]))
+		if err != nil {
+			t.Fatalf("thumbnail %s: %v", name, err)
+		}
+		if format != "png" || config.Width != width || config.Height != width*3/2 {
+			t.Errorf("thumbnail %s = %s %dx%d, want png %dx%d", name, format, config.Width, config.Height, width, width*3/2)
+		}
+	}
+
+	var jpg bytes.Buffer
+	jpeg.Encode(&jpg, solidImage(2000, 500, color.Black), nil)
+	processed, err = utils.ProcessImage(jpg.Bytes(), models.ImageKindBanner)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, format, _ := image.DecodeConfig(bytes.NewReader(processed.Thumbnails["medium"])); format != "jpeg" {
+		t.Errorf("JPEG source should give JPEG thumbnails, got %s", format)
+	}
+}
+
+func TestProcessImageRejects(t *testing.T) {
+	small := encodePNG(t, solidImage(10, 10, color.White))
+
+	if _, err := utils.ProcessImage([]byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>"), models.ImageKindCover); !errors.Is(err, utils.ErrUnsupportedImage) {
+		t.Errorf("expected ErrUnsupportedImage for SVG, got %v", err)
+	}
+	if _, err := utils.ProcessImage([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), models.ImageKindCover); !errors.Is(err, utils.ErrUnsupportedImage) {
+		t.Errorf("expected ErrUnsupportedImage for WebP, got %v", err)
+	}
+	if _, err := utils.ProcessImage(small[:40], models.ImageKindCover); !errors.Is(err, utils.ErrInvalidImage) {
+		t.Errorf("expected ErrInvalidImage for truncated PNG, got %v", err)
+	}
+	if _, err := utils.ProcessImage(small, "poster"); !errors.Is(err, utils.ErrInvalidImageKind) {
+		t.Errorf("expected ErrInvalidImageKind, got %v", err)
+	}
+
+	old := utils.MaxImageBytes
+	utils.MaxImageBytes = int64(len(small) - 1)
+	defer func() { utils.MaxImageBytes = old }()
+	if _, err := utils.ProcessImage(small, models.ImageKindCover); !errors.Is(err, utils.ErrImageTooLarge) {
+		t.Errorf("expected ErrImageTooLarge, got %v", err)
+	}
+}
+
+func TestProcessImageRejectsHugeDimensions(t *testing.T) {
+	// Header PNG dengan ukuran 9000x9000 tanpa data piksel yang valid
+	header := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	header[16], header[17], header[18], header[19] = 0, 0, 0x23, 0x28
+	header[20], header[21], header[22], header[23] = 0, 0, 0x23, 0x28
+	binary.BigEndian.PutUint32(header[29:], crc32.ChecksumIEEE(header[12:29]))
+	if _, err := utils.ProcessImage(header, models.ImageKindCover); !errors.Is(err, utils.ErrImageTooLarge) {
+		t.Errorf("expected ErrImageTooLarge, got %v", err)
+	}
+}
+
+func TestLocalImageStorage(t *testing.T) {
+	dir := t.TempDir()
+	storage := utils.NewLocalImageStorage(dir, "http://localhost:8080/media/")
+
+	if err := storage.Put("anime/1/cover/abc/original.png", []byte("data"), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "anime", "1", "cover", "abc", "original.png"))
+	if err != nil || string(got) != "data" {
+		t.Fatalf("file not written: %q %v", got, err)
+	}
+	if url := storage.URL("anime/1/cover/abc/original.png"); url != "http://localhost:8080/media/anime/1/cover/abc/original.png" {
+		t.Errorf("unexpected URL %s", url)
+	}
+
+	for _, bad := range []string{"../escape.png", "anime/../../escape.png", "/abs.png", ""} {
+		if err := storage.Put(bad, []byte("x"), "image/png"); err == nil {
+			t.Errorf("expected key %q to be rejected", bad)
+		}
+	}
+
+	if err := storage.Delete("anime/1/cover/abc/original.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := storage.Delete("anime/1/cover/abc/original.png"); err != nil {
+		t.Errorf("deleting a missing file should not fail: %v", err)
+	}
+}
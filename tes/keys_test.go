@@ -0,0 +1,122 @@
+package tes
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestKeyManager(t *testing.T, grace time.Duration, retiredAt time.Time) *utils.KeyManager {
+	t.Helper()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ed25519 key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate rsa key: %v", err)
+	}
+
+	active, _ := utils.NewSigningKey("ed-new", utils.AlgEdDSA, edKey, nil)
+	retired, _ := utils.NewSigningKey("rsa-old", utils.AlgRS256, rsaKey, &retiredAt)
+	legacy, _ := utils.NewSigningKey("legacy", utils.AlgHS256, []byte("legacy-secret"), nil)
+
+	km, err := utils.NewKeyManager([]*utils.SigningKey{retired, active, legacy}, "ed-new", grace)
+	if err != nil {
+		t.Fatalf("failed to create key manager: %v", err)
+	}
+	return km
+}
+
+func TestKeyManagerSignAndVerify(t *testing.T) {
+	km := newTestKeyManager(t, time.Hour, time.Now())
+	utils.SetKeyManager(km)
+	defer utils.SetKeyManager(nil)
+
+	tokenString, err := utils.GenerateToken(models.User{ID: 7, Role: "admin"}, models.Session{ID: "test"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	token, claims, err := utils.VerifyToken(tokenString)
+	if err != nil || !token.Valid {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	if token.Header["kid"] != "ed-new" || token.Method.Alg() != utils.AlgEdDSA {
+		t.Errorf("expected EdDSA token signed with ed-new, got %v %s", token.Header["kid"], token.Method.Alg())
+	}
+	if claims.UserID != 7 || claims.Role != "admin" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestKeyManagerRejectsAlgorithmSwitch(t *testing.T) {
+	km := newTestKeyManager(t, time.Hour, time.Now())
+	utils.SetKeyManager(km)
+	defer utils.SetKeyManager(nil)
+
+	// Token HS256 yang mengaku memakai kid kunci EdDSA harus ditolak
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &utils.CustomClaims{UserID: 1, Role: "admin"})
+	forged.Header["kid"] = "ed-new"
+	tokenString, _ := forged.SignedString([]byte("legacy-secret"))
+
+	if _, _, err := utils.VerifyToken(tokenString); err == nil {
+		t.Errorf("expected token with mismatched algorithm to be rejected")
+	}
+}
+
+func TestKeyManagerJWKSGracePeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		retiredAt time.Time
+		wantKIDs  []string
+	}{
+		{"RetiredKeyInGrace", time.Now().Add(-30 * time.Minute), []string{"rsa-old", "ed-new"}},
+		{"RetiredKeyPastGrace", time.Now().Add(-2 * time.Hour), []string{"ed-new"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km := newTestKeyManager(t, time.Hour, tt.retiredAt)
+			set := km.JWKS()
+
+			if len(set.Keys) != len(tt.wantKIDs) {
+				t.Fatalf("expected %d keys, got %d", len(tt.wantKIDs), len(set.Keys))
+			}
+			for i, kid := range tt.wantKIDs {
+				if set.Keys[i].KID != kid {
+					t.Errorf("expected key %d to be %s, got %s", i, kid, set.Keys[i].KID)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadKeyManagerRequiresKeys(t *testing.T) {
+	t.Setenv("JWT_KEYS_FILE", "")
+	t.Setenv("SECRET_KEYS", "")
+
+	t.Setenv("JWT_ALLOW_EPHEMERAL_KEY", "")
+	if _, err := utils.LoadKeyManagerFromEnv(); err == nil {
+		t.Error("expected an error when no JWT keys are configured")
+	}
+
+	t.Setenv("JWT_ALLOW_EPHEMERAL_KEY", "true")
+	if _, err := utils.LoadKeyManagerFromEnv(); err != nil {
+		t.Errorf("expected an ephemeral key in development mode, got %v", err)
+	}
+
+	t.Setenv("JWT_ALLOW_EPHEMERAL_KEY", "")
+	t.Setenv("SECRET_KEYS", "first-secret,second-secret")
+	if _, err := utils.LoadKeyManagerFromEnv(); err != nil {
+		t.Errorf("unexpected error with SECRET_KEYS: %v", err)
+	}
+}
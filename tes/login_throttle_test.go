@@ -0,0 +1,145 @@
+package tes
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	previous := utils.LoginAttempts
+	utils.LoginAttempts = utils.NewMemoryLoginAttemptTracker()
+	defer func() { utils.LoginAttempts = previous }()
+
+	tests := []struct {
+		name       string
+		failures   int
+		blocked    bool
+		locked     bool
+		clearAfter bool
+	}{
+		{"BelowThreshold", 2, false, false, false},
+		{"Backoff", 3, true, false, false},
+		{"Lockout", 10, true, true, false},
+		{"ClearedByAdmin", 10, false, false, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := tt.name + "@example.com"
+			ip := "10.0.0." + string(rune('1'+i))
+
+			for n := 0; n < tt.failures; n++ {
+				if _, err := utils.RecordLoginFailure(email, ip); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if tt.clearAfter {
+				utils.ClearLoginLockout(email, ip)
+			}
+
+			block, err := utils.CheckLogin(email, ip)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if block.Blocked() != tt.blocked || block.Locked != tt.locked {
+				t.Errorf("expected blocked=%v locked=%v, got blocked=%v locked=%v", tt.blocked, tt.locked, block.Blocked(), block.Locked)
+			}
+		})
+	}
+}
+
+func TestLoginThrottleDBConcurrentFailures(t *testing.T) {
+	setupModels(t, &models.LoginAttempt{})
+	previous := utils.LoginAttempts
+	utils.LoginAttempts = utils.NewDBLoginAttemptTracker(DB)
+	defer func() { utils.LoginAttempts = previous }()
+
+	email := fmt.Sprintf("concurrent-%d@example.com", time.Now().UnixNano())
+	ip := "10.1.0.1"
+	defer utils.ClearLoginLockout(email, ip)
+
+	// Kegagalan yang dicatat bersamaan tidak boleh saling menimpa
+	const n = 8
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := utils.RecordLoginFailure(email, ip); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	attempt, err := utils.LoginAttempts.Get("account:" + email)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempt.Failures != n {
+		t.Errorf("expected %d failures, got %d", n, attempt.Failures)
+	}
+}
+
+func TestPurgeLoginAttempts(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		tracker func() utils.LoginAttemptTracker
+	}{
+		{"Memory", func() utils.LoginAttemptTracker { return utils.NewMemoryLoginAttemptTracker() }},
+		{"DB", func() utils.LoginAttemptTracker {
+			setupModels(t, &models.LoginAttempt{})
+			return utils.NewDBLoginAttemptTracker(DB)
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := utils.LoginAttempts
+			utils.LoginAttempts = tt.tracker()
+			defer func() { utils.LoginAttempts = previous }()
+
+			suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+			stale, blocked, recent := "stale-"+suffix, "blocked-"+suffix, "recent-"+suffix
+			defer func() {
+				for _, key := range []string{stale, blocked, recent} {
+					utils.LoginAttempts.Delete(key)
+				}
+			}()
+
+			policy := utils.LoginThrottlePolicy{
+				BackoffAfter: 5, BackoffBase: time.Second, BackoffMax: time.Minute,
+				LockoutAfter: 10, LockoutDuration: time.Hour, FailureWindow: time.Hour,
+			}
+			longAgo := time.Now().Add(-48 * time.Hour)
+			lockout := policy
+			lockout.LockoutAfter, lockout.LockoutDuration = 1, 72*time.Hour
+			for key, add := range map[string]func() (models.LoginAttempt, error){
+				stale:   func() (models.LoginAttempt, error) { return utils.LoginAttempts.AddFailure(stale, policy, longAgo) },
+				blocked: func() (models.LoginAttempt, error) { return utils.LoginAttempts.AddFailure(blocked, lockout, longAgo) },
+				recent:  func() (models.LoginAttempt, error) { return utils.LoginAttempts.AddFailure(recent, policy, time.Now()) },
+			} {
+				if _, err := add(); err != nil {
+					t.Fatalf("%s: unexpected error: %v", key, err)
+				}
+			}
+
+			// Gagal lama yang masih terkunci dan gagal baru tetap disimpan
+			if err := utils.PurgeLoginAttempts(time.Now()); err != nil {
+				t.Fatalf("purge failed: %v", err)
+			}
+			for key, want := range map[string]int{stale: 0, blocked: 1, recent: 1} {
+				attempt, err := utils.LoginAttempts.Get(key)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if attempt.Failures != want {
+					t.Errorf("%s: expected %d failures after purge, got %d", key, want, attempt.Failures)
+				}
+			}
+		})
+	}
+}
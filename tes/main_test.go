@@ -0,0 +1,133 @@
+package tes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestMain(m *testing.M) {
+	// Tes membuat JWT tanpa konfigurasi kunci, jadi kunci acak per proses diizinkan
+	os.Setenv("JWT_ALLOW_EPHEMERAL_KEY", "true")
+	os.Exit(m.Run())
+}
+
+// setupModels menghubungkan utils.DB ke database tes dan membuat tabel yang dibutuhkan tes
+func setupModels(t *testing.T, tables ...interface{}) {
+	t.Helper()
+	setup()
+	if err := DB.AutoMigrate(tables...); err != nil {
+		t.Fatalf("failed to migrate test tables: %v", err)
+	}
+}
+
+// captureMailer menyimpan email yang dikirim agar tes bisa membaca token di dalamnya
+type captureMailer struct {
+	mu   sync.Mutex
+	sent []utils.Mail
+}
+
+func (m *captureMailer) Send(mail utils.Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+func (m *captureMailer) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sent)
+}
+
+var mailTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// lastToken mengambil token dari link di email terakhir
+func (m *captureMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) == 0 {
+		t.Fatal("no email was sent")
+	}
+	match := mailTokenPattern.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	if match == nil {
+		t.Fatalf("no token link in email body: %q", m.sent[len(m.sent)-1].Body)
+	}
+	return match[1]
+}
+
+// useCaptureMailer memasang captureMailer selama satu tes
+func useCaptureMailer(t *testing.T) *captureMailer {
+	t.Helper()
+	previous := utils.GetMailer()
+	mailer := &captureMailer{}
+	utils.SetMailer(mailer)
+	t.Cleanup(func() { utils.SetMailer(previous) })
+	return mailer
+}
+
+// createTestUser membuat pengguna dengan email unik untuk satu kali jalan tes
+func createTestUser(t *testing.T, name string) models.User {
+	t.Helper()
+	user := models.User{
+		Username: name,
+		Email:    fmt.Sprintf("%s-%d@example.com", name, time.Now().UnixNano()),
+		Password: "hash",
+		Role:     "user",
+	}
+	if err := utils.DB.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+// setupAuthModels membuat tabel yang dibutuhkan AuthMiddleware dan mengisi role bawaan
+func setupAuthModels(t *testing.T, tables ...interface{}) {
+	t.Helper()
+	setupModels(t, append([]interface{}{
+		&models.User{}, &models.Session{}, &models.RefreshToken{}, &models.Role{}, &models.Permission{},
+	}, tables...)...)
+	if err := utils.SeedRolesAndPermissions(DB); err != nil {
+		t.Fatalf("failed to seed roles: %v", err)
+	}
+}
+
+// loginAs membuat sesi baru dan JWT untuk pengguna
+func loginAs(t *testing.T, user models.User) (string, *models.Session) {
+	t.Helper()
+	session, err := utils.CreateSession(user.ID, "test-agent", "127.0.0.1", false)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	token, err := utils.GenerateToken(user, *session)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	return token, session
+}
+
+// serveWithToken menjalankan handler dengan header Authorization berisi token
+func serveWithToken(handler http.Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// okHandler dipakai untuk mengecek apakah middleware meneruskan request
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+})
@@ -0,0 +1,280 @@
+package tes
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+)
+
+// mockIssuer adalah OIDC issuer minimal: discovery, JWKS dan token endpoint dengan PKCE
+type mockIssuer struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+	audience  string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{key: key, audience: "nyanime-client"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(utils.JWKSet{Keys: []utils.JWK{{
+			KTY: "RSA", KID: "mock-1", Use: "sig", Alg: "RS256",
+			N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "good-code" || utils.PKCEChallenge(r.Form.Get("code_verifier")) != m.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            m.server.URL,
+			"aud":            m.audience,
+			"sub":            "user-123",
+			"email":          "Neko@Example.com",
+			"email_verified": true,
+			"name":           "Neko",
+			"nonce":          m.nonce,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		})
+		token.Header["kid"] = "mock-1"
+		signed, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockIssuer) provider() *utils.OIDCProvider {
+	return utils.NewOIDCProvider("mock", utils.OIDCConfig{
+		Issuer:      m.server.URL,
+		ClientID:    "nyanime-client",
+		RedirectURL: "http://localhost:8080/auth/mock/callback",
+	}, m.server.Client())
+}
+
+func TestOIDCAuthorizationCodeFlow(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := issuer.provider()
+	ctx := context.Background()
+
+	verifier, challenge, err := utils.NewPKCEVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", challenge)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	parsed, _ := url.Parse(authURL)
+	q := parsed.Query()
+	if q.Get("code_challenge") != challenge || q.Get("code_challenge_method") != "S256" || q.Get("state") != "state-1" {
+		t.Fatalf("authorization URL missing PKCE or state parameters: %s", authURL)
+	}
+
+	// Mock issuer mengingat challenge dan nonce dari langkah authorize
+	issuer.challenge, issuer.nonce = q.Get("code_challenge"), q.Get("nonce")
+
+	identity, err := provider.Exchange(ctx, "good-code", verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.Subject != "user-123" || identity.Email != "neko@example.com" || !identity.EmailVerified || identity.Provider != "mock" {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+
+	if _, err := provider.Exchange(ctx, "good-code", "wrong-verifier", "nonce-1"); err == nil {
+		t.Error("expected exchange with wrong code_verifier to fail")
+	}
+	if _, err := provider.Exchange(ctx, "good-code", verifier, "other-nonce"); !errors.Is(err, utils.ErrIDTokenInvalid) {
+		t.Errorf("expected nonce mismatch to be rejected, got %v", err)
+	}
+}
+
+func TestOIDCRejectsWrongAudience(t *testing.T) {
+	issuer := newMockIssuer(t)
+	issuer.audience = "someone-else"
+	provider := issuer.provider()
+
+	verifier, challenge, _ := utils.NewPKCEVerifier()
+	issuer.challenge, issuer.nonce = challenge, "nonce-1"
+
+	if _, err := provider.Exchange(context.Background(), "good-code", verifier, "nonce-1"); !errors.Is(err, utils.ErrIDTokenInvalid) {
+		t.Errorf("expected token for another audience to be rejected, got %v", err)
+	}
+}
+
+// oidcRouter memasang endpoint login dan penautan OIDC seperti di routes.go
+func oidcRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/auth/{provider}/login", controller.OIDCLogin).Methods("GET")
+	router.HandleFunc("/auth/{provider}/callback", controller.OIDCCallback).Methods("GET")
+	router.Handle("/user/identities/{provider}", utils.SessionAuthMiddleware(http.HandlerFunc(controller.LinkIdentity))).Methods("POST")
+	return router
+}
+
+// startOIDC menjalankan langkah authorize di mock issuer dan mengembalikan state serta cookie browser
+func startOIDC(t *testing.T, issuer *mockIssuer, authURL string, cookies []*http.Cookie) (string, *http.Cookie) {
+	t.Helper()
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid authorization URL %q: %v", authURL, err)
+	}
+	q := parsed.Query()
+	issuer.challenge, issuer.nonce = q.Get("code_challenge"), q.Get("nonce")
+	for _, c := range cookies {
+		if c.Name == utils.OAuthBrowserCookie {
+			if !c.HttpOnly || c.SameSite != http.SameSiteLaxMode || c.MaxAge <= 0 {
+				t.Errorf("expected a short-lived HttpOnly SameSite=Lax cookie, got %+v", c)
+			}
+			return q.Get("state"), c
+		}
+	}
+	t.Fatal("no OAuth browser cookie was set")
+	return "", nil
+}
+
+// oidcCallback memanggil callback dan mengembalikan fragment redirect ke frontend
+func oidcCallback(t *testing.T, router http.Handler, state string, cookie *http.Cookie) url.Values {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/auth/mock/callback?code=good-code&state="+url.QueryEscape(state), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if rec.Code != http.StatusFound || err != nil {
+		t.Fatalf("expected a redirect to the frontend, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	result, _ := url.ParseQuery(location.Fragment)
+	return result
+}
+
+func TestOIDCCallbackRequiresBrowserCookie(t *testing.T) {
+	setupAuthModels(t, &models.LinkedIdentity{}, &models.OAuthState{}, &models.EmailVerificationToken{})
+	useCaptureMailer(t)
+	issuer := newMockIssuer(t)
+	utils.RegisterIdentityProvider(issuer.provider())
+	router := oidcRouter()
+
+	login := func() (string, *http.Cookie) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/mock/login", nil))
+		if rec.Code != http.StatusFound {
+			t.Fatalf("expected a redirect to the provider, got %d", rec.Code)
+		}
+		return startOIDC(t, issuer, rec.Header().Get("Location"), rec.Result().Cookies())
+	}
+
+	// Callback yang diputar ulang di browser lain (tanpa cookie) ditolak, dan state-nya hangus
+	state, cookie := login()
+	if result := oidcCallback(t, router, state, nil); result.Get("error") != "invalid_state" || result.Get("token") != "" {
+		t.Fatalf("expected invalid_state without the browser cookie, got %v", result)
+	}
+	if result := oidcCallback(t, router, state, cookie); result.Get("error") != "invalid_state" {
+		t.Errorf("expected the state to be single use, got %v", result)
+	}
+
+	// Cookie dari alur lain juga tidak cocok
+	state, _ = login()
+	_, otherCookie := login()
+	if result := oidcCallback(t, router, state, otherCookie); result.Get("error") != "invalid_state" {
+		t.Errorf("expected a cookie from another flow to be rejected, got %v", result)
+	}
+
+	state, cookie = login()
+	if result := oidcCallback(t, router, state, cookie); result.Get("token") == "" {
+		t.Errorf("expected login to succeed with the browser cookie, got %v", result)
+	}
+}
+
+func TestOIDCLinkRequiresBrowserCookie(t *testing.T) {
+	setupAuthModels(t, &models.LinkedIdentity{}, &models.OAuthState{})
+	issuer := newMockIssuer(t)
+	utils.RegisterIdentityProvider(issuer.provider())
+	router := oidcRouter()
+
+	attacker := createTestUser(t, "link-attacker")
+	token, _ := loginAs(t, attacker)
+	rec := serveWithToken(router, http.MethodPost, "/user/identities/mock", token, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	state, _ := startOIDC(t, issuer, resp.AuthorizationURL, rec.Result().Cookies())
+
+	// Korban membuka URL penautan milik penyerang: browser korban tidak punya cookie-nya
+	if result := oidcCallback(t, router, state, nil); result.Get("error") != "invalid_state" || result.Get("linked") != "" {
+		t.Fatalf("expected the link callback to be rejected without the cookie, got %v", result)
+	}
+	var count int64
+	utils.DB.Model(&models.LinkedIdentity{}).Where("user_id = ?", attacker.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected no identity to be linked, got %d", count)
+	}
+}
+
+func TestIdentityUsernameTruncatesByCharacter(t *testing.T) {
+	setupModels(t, &models.User{}, &models.LinkedIdentity{}, &models.EmailVerificationToken{})
+	useCaptureMailer(t)
+	run := time.Now().UnixNano()
+	identity := &utils.ExternalIdentity{
+		Provider:      "mock",
+		Subject:       fmt.Sprintf("long-name-%d", run),
+		Email:         fmt.Sprintf("long-name-%d@example.com", run),
+		EmailVerified: true,
+		Name:          strings.Repeat("猫", 60),
+	}
+
+	user, created, err := utils.ResolveIdentityUser(identity)
+	if err != nil || !created {
+		t.Fatalf("expected a new user, got created=%v err=%v", created, err)
+	}
+	var stored models.User
+	utils.DB.First(&stored, user.ID)
+	if !utf8.ValidString(stored.Username) || stored.Username != strings.Repeat("猫", 50) {
+		t.Errorf("expected 50 whole characters, got %q", stored.Username)
+	}
+}
@@ -0,0 +1,121 @@
+package tes
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"NYANIMEBACKEND/utils"
+)
+
+func violationCodes(err error) map[string]bool {
+	codes := map[string]bool{}
+	var policyErr *utils.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		for _, v := range policyErr.Violations {
+			codes[v.Code] = true
+		}
+	}
+	return codes
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := utils.PasswordPolicy{MinLength: 8, MinEntropyBits: 50}
+
+	if err := policy.Validate("Nyan-Cat!2024", "neko", "neko@example.com"); err != nil {
+		t.Errorf("expected strong password to pass, got %v", err)
+	}
+
+	tests := []struct {
+		password string
+		code     string
+	}{
+		{"Ab1!", utils.PasswordTooShort},
+		{"password", utils.PasswordLowEntropy},
+		{"aaaaaaaaaaaa", utils.PasswordLowEntropy},
+		{"abcdefgh12345678", utils.PasswordLowEntropy},
+		{"Xx!9nekochan42", utils.PasswordContainsUsername},
+		{"Q7!tamaneko", utils.PasswordContainsEmail},
+		{strings.Repeat("Ab1!", 20), utils.PasswordTooLong},
+	}
+	for _, tt := range tests {
+		err := policy.Validate(tt.password, "nekochan", "tamaneko@example.com")
+		if !violationCodes(err)[tt.code] {
+			t.Errorf("%q: expected violation %s, got %v", tt.password, tt.code, err)
+		}
+	}
+}
+
+func TestBreachedPasswordList(t *testing.T) {
+	sum := sha1.Sum([]byte("Nyan-Cat!2024"))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	// Direktori range k-anonymity: file bernama 5 karakter awal hash berisi SUFFIX:COUNT
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte("0000000000000000000000000000000000A:1\n"+hash[5:]+":42\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policy := utils.PasswordPolicy{MinLength: 8, BreachedList: dir}
+	if !violationCodes(policy.Validate("Nyan-Cat!2024", "", ""))[utils.PasswordBreached] {
+		t.Error("expected password in range file to be reported as breached")
+	}
+	if err := policy.Validate("Another-Cat!2025", "", ""); err != nil {
+		t.Errorf("expected password without range file to pass, got %v", err)
+	}
+
+	// File tunggal berisi hash lengkap
+	file := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(file, []byte(strings.ToLower(hash)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	breached, err := utils.IsPasswordBreached(file, "Nyan-Cat!2024")
+	if err != nil || !breached {
+		t.Errorf("expected hash file match, got %v, %v", breached, err)
+	}
+}
+
+func TestBreachedPasswordSortedFile(t *testing.T) {
+	// Cukup besar agar binary search benar-benar dipakai sebelum pembacaan berurutan
+	passwords := make([]string, 5000)
+	hashes := make([]string, 0, len(passwords))
+	for i := range passwords {
+		passwords[i] = fmt.Sprintf("leaked-password-%d", i)
+		sum := sha1.Sum([]byte(passwords[i]))
+		hashes = append(hashes, strings.ToUpper(hex.EncodeToString(sum[:])))
+	}
+	sort.Strings(hashes)
+
+	var buf strings.Builder
+	for i, hash := range hashes {
+		buf.WriteString(hash)
+		if i%3 == 0 {
+			fmt.Fprintf(&buf, ":%d", i+1) // Campuran "HASH" dan "HASH:COUNT"
+		}
+		if i < len(hashes)-1 {
+			buf.WriteString("\r\n") // Baris terakhir tanpa newline
+		}
+	}
+	file := filepath.Join(t.TempDir(), "pwned-ordered.txt")
+	if err := os.WriteFile(file, []byte(buf.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, password := range passwords {
+		breached, err := utils.IsPasswordBreached(file, password)
+		if err != nil || !breached {
+			t.Fatalf("expected %q to be found, got %v, %v", password, breached, err)
+		}
+	}
+	for i := 0; i < 200; i++ {
+		password := fmt.Sprintf("safe-password-%d", i)
+		if breached, err := utils.IsPasswordBreached(file, password); err != nil || breached {
+			t.Fatalf("expected %q not to be found, got %v, %v", password, breached, err)
+		}
+	}
+}
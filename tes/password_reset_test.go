@@ -0,0 +1,150 @@
+package tes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestPasswordResetIssueAndConsume(t *testing.T) {
+	setupModels(t, &models.User{}, &models.PasswordResetToken{})
+	t.Setenv("PASSWORD_RESET_COOLDOWN", "0s")
+	mailer := useCaptureMailer(t)
+	user := createTestUser(t, "reset")
+
+	if err := utils.SendPasswordResetEmail(user); err != nil {
+		t.Fatalf("failed to send reset email: %v", err)
+	}
+	first := mailer.lastToken(t)
+
+	// Permintaan baru membatalkan token lama
+	if err := utils.SendPasswordResetEmail(user); err != nil {
+		t.Fatalf("failed to send second reset email: %v", err)
+	}
+	second := mailer.lastToken(t)
+	if _, err := utils.LookupPasswordResetToken(first); !errors.Is(err, utils.ErrResetTokenInvalid) {
+		t.Errorf("expected the previous token to be invalidated, got %v", err)
+	}
+
+	token, err := utils.LookupPasswordResetToken(second)
+	if err != nil {
+		t.Fatalf("lookup of the new token failed: %v", err)
+	}
+	if err := utils.ConsumePasswordResetToken(token, "new-hash"); err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+
+	var updated models.User
+	utils.DB.First(&updated, user.ID)
+	if updated.Password != "new-hash" {
+		t.Errorf("expected the password hash to be updated, got %q", updated.Password)
+	}
+
+	// Token hanya bisa dipakai sekali
+	if _, err := utils.LookupPasswordResetToken(second); !errors.Is(err, utils.ErrResetTokenInvalid) {
+		t.Errorf("expected a used token to be rejected by lookup, got %v", err)
+	}
+	if err := utils.ConsumePasswordResetToken(token, "other-hash"); !errors.Is(err, utils.ErrResetTokenInvalid) {
+		t.Errorf("expected a second consume to fail, got %v", err)
+	}
+}
+
+func TestPasswordResetExpiry(t *testing.T) {
+	setupModels(t, &models.User{}, &models.PasswordResetToken{})
+	t.Setenv("PASSWORD_RESET_COOLDOWN", "0s")
+	mailer := useCaptureMailer(t)
+	user := createTestUser(t, "reset-expiry")
+
+	if err := utils.SendPasswordResetEmail(user); err != nil {
+		t.Fatalf("failed to send reset email: %v", err)
+	}
+	raw := mailer.lastToken(t)
+
+	utils.DB.Model(&models.PasswordResetToken{}).
+		Where("token_hash = ?", utils.HashOpaqueToken(raw)).
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	if _, err := utils.LookupPasswordResetToken(raw); !errors.Is(err, utils.ErrResetTokenInvalid) {
+		t.Errorf("expected an expired token to be rejected, got %v", err)
+	}
+}
+
+func TestPasswordResetCooldown(t *testing.T) {
+	setupModels(t, &models.User{}, &models.PasswordResetToken{})
+	t.Setenv("PASSWORD_RESET_COOLDOWN", "1h")
+	mailer := useCaptureMailer(t)
+	user := createTestUser(t, "reset-cooldown")
+
+	if err := utils.SendPasswordResetEmail(user); err != nil {
+		t.Fatalf("failed to send reset email: %v", err)
+	}
+	raw := mailer.lastToken(t)
+
+	if err := utils.SendPasswordResetEmail(user); !errors.Is(err, utils.ErrPasswordResetCooldown) {
+		t.Fatalf("expected ErrPasswordResetCooldown, got %v", err)
+	}
+	if mailer.count() != 1 {
+		t.Errorf("expected no email during the cooldown, got %d emails", mailer.count())
+	}
+	// Token yang sudah dikirim tetap berlaku selama cooldown
+	if _, err := utils.LookupPasswordResetToken(raw); err != nil {
+		t.Errorf("expected the first token to stay valid, got %v", err)
+	}
+}
+
+func TestForcePasswordResetIgnoresCooldown(t *testing.T) {
+	setupModels(t, &models.User{}, &models.PasswordResetToken{}, &models.Session{}, &models.RefreshToken{}, &models.APIKey{})
+	t.Setenv("PASSWORD_RESET_COOLDOWN", "1h")
+	mailer := useCaptureMailer(t)
+	user := createTestUser(t, "reset-forced")
+
+	if err := utils.SendPasswordResetEmail(user); err != nil {
+		t.Fatalf("failed to send reset email: %v", err)
+	}
+	// Reset paksa dari admin tetap mengirim link baru meskipun pengguna baru saja meminta reset
+	if err := utils.ForcePasswordReset(user); err != nil {
+		t.Fatalf("expected forced reset to ignore the cooldown, got %v", err)
+	}
+	if mailer.count() != 2 {
+		t.Errorf("expected a second reset email, got %d emails", mailer.count())
+	}
+	if _, err := utils.LookupPasswordResetToken(mailer.lastToken(t)); err != nil {
+		t.Errorf("expected the forced reset token to be valid, got %v", err)
+	}
+}
+
+func TestForgotPasswordSameResponse(t *testing.T) {
+	setupModels(t, &models.User{}, &models.PasswordResetToken{})
+	t.Setenv("PASSWORD_RESET_COOLDOWN", "0s")
+	mailer := useCaptureMailer(t)
+	user := createTestUser(t, "forgot")
+
+	post := func(email string) (int, string) {
+		body := strings.NewReader(`{"email":"` + email + `"}`)
+		rec := httptest.NewRecorder()
+		controller.ForgotPassword(rec, httptest.NewRequest(http.MethodPost, "/forgot-password", body))
+		return rec.Code, rec.Body.String()
+	}
+
+	knownCode, knownBody := post(user.Email)
+	unknownCode, unknownBody := post("nobody-" + user.Email)
+	if knownCode != http.StatusOK || unknownCode != http.StatusOK || knownBody != unknownBody {
+		t.Errorf("responses differ: %d %q vs %d %q", knownCode, knownBody, unknownCode, unknownBody)
+	}
+
+	// Email dikirim di background
+	deadline := time.Now().Add(2 * time.Second)
+	for mailer.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if mailer.count() != 1 {
+		t.Errorf("expected one reset email for the registered address, got %d", mailer.count())
+	}
+}
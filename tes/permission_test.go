@@ -0,0 +1,190 @@
+package tes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRequirePermission(t *testing.T) {
+	t.Setenv("REQUIRE_ADMIN_2FA", "false")
+
+	handler := utils.RequirePermission(utils.PermReviewModerate)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name  string
+		perms []string
+		want  int
+	}{
+		{"no permissions", nil, http.StatusForbidden},
+		{"other permission", []string{utils.PermAnimeWrite}, http.StatusForbidden},
+		{"granted", []string{utils.PermAnimeWrite, utils.PermReviewModerate}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodDelete, "/review/1", nil)
+		ctx := context.WithValue(req.Context(), utils.UserIDKey, 1)
+		ctx = context.WithValue(ctx, utils.UserPermissionsKey, tt.perms)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+		if rr.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, rr.Code)
+		}
+	}
+}
+
+func TestRequirePermissionAdmin2FA(t *testing.T) {
+	t.Setenv("REQUIRE_ADMIN_2FA", "true")
+
+	handler := utils.RequirePermission(utils.PermAnimeWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, mfa := range []bool{false, true} {
+		req := httptest.NewRequest(http.MethodPost, "/anime/", nil)
+		ctx := context.WithValue(req.Context(), utils.UserRoleKey, utils.RoleAdmin)
+		ctx = context.WithValue(ctx, utils.UserPermissionsKey, []string{utils.PermAnimeWrite})
+		ctx = context.WithValue(ctx, utils.UserMFAKey, mfa)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		want := http.StatusForbidden
+		if mfa {
+			want = http.StatusOK
+		}
+		if rr.Code != want {
+			t.Errorf("mfa=%v: expected status %d, got %d", mfa, want, rr.Code)
+		}
+	}
+}
+
+func TestRequirePermission2FAFollowsRolePermissions(t *testing.T) {
+	setupAuthModels(t)
+	t.Setenv("REQUIRE_ADMIN_2FA", "true")
+	run := time.Now().UnixNano()
+	privileged := fmt.Sprintf("support-lead-%d", run)
+	editor := fmt.Sprintf("editor-%d", run)
+	if _, err := utils.CreateRole(privileged, "Support lead", []string{utils.PermUserManage, utils.PermAnimeWrite}); err != nil {
+		t.Fatalf("failed to create role: %v", err)
+	}
+	if _, err := utils.CreateRole(editor, "Editor", []string{utils.PermAnimeWrite}); err != nil {
+		t.Fatalf("failed to create role: %v", err)
+	}
+
+	handler := utils.RequirePermission(utils.PermAnimeWrite)(okHandler)
+	// Role kustom dengan user:manage wajib 2FA seperti admin; role tanpa permission admin tidak
+	for role, want := range map[string]int{privileged: http.StatusForbidden, editor: http.StatusNoContent} {
+		req := httptest.NewRequest(http.MethodPost, "/anime/", nil)
+		ctx := context.WithValue(req.Context(), utils.UserRoleKey, role)
+		ctx = context.WithValue(ctx, utils.UserPermissionsKey, []string{utils.PermAnimeWrite})
+		ctx = context.WithValue(ctx, utils.UserMFAKey, false)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+		if rr.Code != want {
+			t.Errorf("role %s without 2FA: expected status %d, got %d", role, want, rr.Code)
+		}
+	}
+}
+
+func TestAssignUserRoleRequiresRolePermissions(t *testing.T) {
+	setupAuthModels(t, &models.AuditLog{})
+	support := fmt.Sprintf("support-%d", time.Now().UnixNano())
+	if _, err := utils.CreateRole(support, "User support", []string{utils.PermUserManage}); err != nil {
+		t.Fatalf("failed to create role: %v", err)
+	}
+	target := createTestUser(t, "assign-target")
+	admin := createTestUser(t, "assign-admin")
+	utils.DB.Model(&admin).Update("role", utils.RoleAdmin)
+
+	assign := func(targetID int, role string, perms []string) int {
+		req := httptest.NewRequest(http.MethodPut, "/admin/users/role", strings.NewReader(`{"role":"`+role+`"}`))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(targetID)})
+		ctx := context.WithValue(req.Context(), utils.UserIDKey, 1)
+		ctx = context.WithValue(ctx, utils.UserPermissionsKey, perms)
+		rr := httptest.NewRecorder()
+		controller.AssignUserRole(rr, req.WithContext(ctx))
+		return rr.Code
+	}
+	supportPerms := []string{utils.PermUserManage}
+
+	tests := []struct {
+		name     string
+		targetID int
+		role     string
+		perms    []string
+		want     int
+	}{
+		{"grant admin without the admin permissions", target.ID, utils.RoleAdmin, supportPerms, http.StatusForbidden},
+		{"grant moderator without moderation permissions", target.ID, utils.RoleModerator, supportPerms, http.StatusForbidden},
+		{"grant own role", target.ID, support, supportPerms, http.StatusOK},
+		{"grant role without permissions", target.ID, utils.RoleUser, supportPerms, http.StatusOK},
+		{"demote an admin", admin.ID, utils.RoleUser, supportPerms, http.StatusForbidden},
+		{"role manager grants admin", target.ID, utils.RoleAdmin, []string{utils.PermUserManage, utils.PermRoleManage}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if code := assign(tt.targetID, tt.role, tt.perms); code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, code)
+		}
+	}
+
+	var stored models.User
+	utils.DB.First(&stored, admin.ID)
+	if stored.Role != utils.RoleAdmin {
+		t.Errorf("expected the admin to keep the admin role, got %q", stored.Role)
+	}
+}
+
+func TestForcePasswordResetRequiresTargetPermissions(t *testing.T) {
+	setupAuthModels(t, &models.AuditLog{}, &models.PasswordResetToken{}, &models.APIKey{})
+	useCaptureMailer(t)
+	support := createTestUser(t, "reset-support")
+	target := createTestUser(t, "reset-target")
+	admin := createTestUser(t, "reset-admin")
+	utils.DB.Model(&admin).Update("role", utils.RoleAdmin)
+
+	forceReset := func(targetID int, perms []string) int {
+		req := httptest.NewRequest(http.MethodPost, "/admin/users/password-reset", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(targetID)})
+		ctx := context.WithValue(req.Context(), utils.UserIDKey, support.ID)
+		ctx = context.WithValue(ctx, utils.UserPermissionsKey, perms)
+		rr := httptest.NewRecorder()
+		controller.ForcePasswordReset(rr, req.WithContext(ctx))
+		return rr.Code
+	}
+	supportPerms := []string{utils.PermUserManage}
+
+	tests := []struct {
+		name     string
+		targetID int
+		perms    []string
+		want     int
+	}{
+		{"own account", support.ID, supportPerms, http.StatusForbidden},
+		{"admin without the admin permissions", admin.ID, supportPerms, http.StatusForbidden},
+		{"regular user", target.ID, supportPerms, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if code := forceReset(tt.targetID, tt.perms); code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, code)
+		}
+	}
+
+	var stored models.User
+	utils.DB.First(&stored, admin.ID)
+	if stored.PasswordResetRequired {
+		t.Error("expected the admin account to stay unlocked")
+	}
+}
@@ -0,0 +1,71 @@
+package tes
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	setupModels(t, &models.Session{}, &models.RefreshToken{})
+
+	session, err := utils.CreateSession(9001, "test-agent", "127.0.0.1", false)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	first, err := utils.IssueRefreshToken(9001, session.ID)
+	if err != nil {
+		t.Fatalf("failed to issue refresh token: %v", err)
+	}
+
+	second, current, err := utils.RotateRefreshToken(first)
+	if err != nil {
+		t.Fatalf("rotation failed: %v", err)
+	}
+	if second == "" || second == first {
+		t.Fatalf("expected a new refresh token, got %q", second)
+	}
+	if current.UserID != 9001 || current.FamilyID != session.ID {
+		t.Errorf("rotated token belongs to user %d family %s", current.UserID, current.FamilyID)
+	}
+
+	third, _, err := utils.RotateRefreshToken(second)
+	if err != nil {
+		t.Fatalf("rotating the new token failed: %v", err)
+	}
+
+	// Token lama dipakai lagi: dianggap dicuri, seluruh family dan sesinya dicabut
+	if _, _, err := utils.RotateRefreshToken(first); !errors.Is(err, utils.ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	if _, _, err := utils.RotateRefreshToken(third); !errors.Is(err, utils.ErrRefreshTokenReused) {
+		t.Errorf("expected the latest token of the family to be revoked, got %v", err)
+	}
+	if _, err := utils.ActiveSession(session.ID); !errors.Is(err, utils.ErrSessionInactive) {
+		t.Errorf("expected the session to be revoked with its family, got %v", err)
+	}
+}
+
+func TestRefreshTokenInvalidAndExpired(t *testing.T) {
+	setupModels(t, &models.Session{}, &models.RefreshToken{})
+
+	if _, _, err := utils.RotateRefreshToken("not-a-real-token"); !errors.Is(err, utils.ErrRefreshTokenInvalid) {
+		t.Errorf("expected ErrRefreshTokenInvalid, got %v", err)
+	}
+
+	raw, err := utils.IssueRefreshToken(9002, "expired-family")
+	if err != nil {
+		t.Fatalf("failed to issue refresh token: %v", err)
+	}
+	if err := DB.Model(&models.RefreshToken{}).
+		Where("token_hash = ?", utils.HashOpaqueToken(raw)).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("failed to expire token: %v", err)
+	}
+	if _, _, err := utils.RotateRefreshToken(raw); !errors.Is(err, utils.ErrRefreshTokenExpired) {
+		t.Errorf("expected ErrRefreshTokenExpired, got %v", err)
+	}
+}
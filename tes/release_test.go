@@ -0,0 +1,166 @@
+package tes
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func mustPartialDate(t *testing.T, s string) models.PartialDate {
+	t.Helper()
+	d, err := models.ParsePartialDate(s)
+	if err != nil {
+		t.Fatalf("ParsePartialDate(%q): %v", s, err)
+	}
+	return d
+}
+
+func TestPartialDateJSON(t *testing.T) {
+	for _, s := range []string{"2027", "2027-04", "2027-04-05"} {
+		var d models.PartialDate
+		if err := json.Unmarshal([]byte(`"`+s+`"`), &d); err != nil {
+			t.Fatalf("Unmarshal(%q): %v", s, err)
+		}
+		out, _ := json.Marshal(d)
+		if string(out) != `"`+s+`"` {
+			t.Errorf("round trip of %q = %s", s, out)
+		}
+	}
+
+	var d models.PartialDate
+	if err := json.Unmarshal([]byte("null"), &d); err != nil || d.Known() {
+		t.Errorf("null should give an unknown date, got %v (err %v)", d, err)
+	}
+	if out, _ := json.Marshal(d); string(out) != "null" {
+		t.Errorf("unknown date marshals to %s, want null", out)
+	}
+	for _, bad := range []string{`"27"`, `"2027-13"`, `"2027-02-30"`, `"April 2027"`} {
+		if err := json.Unmarshal([]byte(bad), &d); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestParseLegacyReleaseDate(t *testing.T) {
+	cases := map[string]string{
+		"2013-04-07":           "2013-04-07",
+		"2013/4/7":             "2013-04-07",
+		"2013-04-07T00:00:00Z": "2013-04-07",
+		"April 7, 2013":        "2013-04-07",
+		"7 Apr 2013":           "2013-04-07",
+		"Apr 2013":             "2013-04",
+		"2013":                 "2013",
+		" Spring  2013 ":       "2013-04",
+		"2013 autumn":          "2013-10",
+	}
+	for in, want := range cases {
+		got, ok := utils.ParseLegacyReleaseDate(in)
+		if !ok || got.String() != want {
+			t.Errorf("ParseLegacyReleaseDate(%q) = %q, %v; want %q", in, got.String(), ok, want)
+		}
+	}
+	for _, bad := range []string{"", "07/04/2013", "soon", "Monsoon 2013"} {
+		if _, ok := utils.ParseLegacyReleaseDate(bad); ok {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestBroadcastSeason(t *testing.T) {
+	cases := map[string]string{
+		"2026-01-10": "winter",
+		"2026-03":    "winter",
+		"2026-04-01": "spring",
+		"2026-09-30": "summer",
+		"2026-12":    "fall",
+	}
+	for in, want := range cases {
+		season, year := utils.BroadcastSeason(mustPartialDate(t, in))
+		if season != want || year == nil || *year != 2026 {
+			t.Errorf("BroadcastSeason(%q) = %q, %v; want %q 2026", in, season, year, want)
+		}
+	}
+	if season, year := utils.BroadcastSeason(mustPartialDate(t, "2026")); season != "" || year != nil {
+		t.Errorf("year-only date should have no season, got %q %v", season, year)
+	}
+}
+
+func TestDeriveAiringStatus(t *testing.T) {
+	now := time.Date(2026, time.May, 15, 12, 0, 0, 0, time.UTC)
+	none := models.PartialDate{}
+	cases := []struct {
+		start, end string
+		want       string
+	}{
+		{"2026-06-01", "", models.AiringStatusUpcoming},
+		{"2026-05-15", "", models.AiringStatusAiring},
+		{"2026-04-05", "2026-06-28", models.AiringStatusAiring},
+		{"2026-01-05", "2026-05-14", models.AiringStatusFinished},
+		{"2026-04-05", "2026-05-15", models.AiringStatusAiring},
+		{"2026-05", "", models.AiringStatusUpcoming}, // Hari pertama belum diketahui
+		{"2026-04", "", models.AiringStatusAiring},
+		{"2026", "", models.AiringStatusUpcoming},
+		{"", "", models.AiringStatusUpcoming},
+	}
+	for _, c := range cases {
+		start, end := none, none
+		if c.start != "" {
+			start = mustPartialDate(t, c.start)
+		}
+		if c.end != "" {
+			end = mustPartialDate(t, c.end)
+		}
+		if got := utils.DeriveAiringStatus(start, end, now); got != c.want {
+			t.Errorf("DeriveAiringStatus(%q, %q) = %q, want %q", c.start, c.end, got, c.want)
+		}
+	}
+}
+
+func TestApplyReleaseInfo(t *testing.T) {
+	now := time.Date(2026, time.May, 15, 0, 0, 0, 0, time.UTC)
+
+	anime := models.Anime{ReleaseDate: "April 5, 2026"}
+	if err := utils.ApplyReleaseInfo(&anime, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if anime.ReleaseDate != "2026-04-05" || anime.StartDate.String() != "2026-04-05" {
+		t.Errorf("release date not normalized: %q / %q", anime.ReleaseDate, anime.StartDate.String())
+	}
+	if anime.AiringStatus != models.AiringStatusAiring || anime.BroadcastSeason != models.SeasonSpring || anime.SeasonYear == nil || *anime.SeasonYear != 2026 {
+		t.Errorf("unexpected derived info: %q %q %v", anime.AiringStatus, anime.BroadcastSeason, anime.SeasonYear)
+	}
+
+	cancelled := models.Anime{StartDate: mustPartialDate(t, "2026-07"), AiringStatus: models.AiringStatusCancelled}
+	if err := utils.ApplyReleaseInfo(&cancelled, now); err != nil || cancelled.AiringStatus != models.AiringStatusCancelled {
+		t.Errorf("explicit status should be kept, got %q (err %v)", cancelled.AiringStatus, err)
+	}
+
+	for _, bad := range []models.Anime{
+		{ReleaseDate: "someday"},
+		{AiringStatus: "paused"},
+		{StartDate: mustPartialDate(t, "2026-04-05"), EndDate: mustPartialDate(t, "2026-03")},
+	} {
+		if err := utils.ApplyReleaseInfo(&bad, now); !errors.Is(err, utils.ErrInvalidReleaseInfo) {
+			t.Errorf("expected ErrInvalidReleaseInfo for %+v, got %v", bad, err)
+		}
+	}
+}
+
+func TestParseAnimeListParamsSeason(t *testing.T) {
+	params, err := utils.ParseAnimeListParams(map[string][]string{
+		"status": {"airing"}, "season": {"Autumn"}, "season_year": {"2026"}, "sort": {"-start_date"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.Status != "airing" || params.Season != "fall" || params.SeasonYear != 2026 || params.Sort != "start_date" || !params.Desc {
+		t.Errorf("unexpected params: %+v", params)
+	}
+	if _, err := utils.ParseAnimeListParams(map[string][]string{"status": {"paused"}}); err == nil {
+		t.Error("expected error for unknown status")
+	}
+}
@@ -0,0 +1,66 @@
+package tes
+
+import (
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestMemoryRevocationStore(t *testing.T) {
+	store := utils.NewMemoryRevocationStore()
+
+	store.Revoke("active", time.Now().Add(time.Hour))
+	store.Revoke("expired", time.Now().Add(-time.Minute))
+
+	tests := []struct {
+		name    string
+		jti     string
+		revoked bool
+	}{
+		{"RevokedToken", "active", true},
+		{"ExpiredEntry", "expired", false},
+		{"UnknownToken", "unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := store.IsRevoked(tt.jti)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if revoked != tt.revoked {
+				t.Errorf("expected revoked %v, got %v", tt.revoked, revoked)
+			}
+		})
+	}
+
+	if err := store.PurgeExpired(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if revoked, _ := store.IsRevoked("active"); !revoked {
+		t.Errorf("purge removed an entry that has not expired")
+	}
+}
+
+func TestBlacklistUsesJTI(t *testing.T) {
+	previous := utils.Revocations
+	utils.Revocations = utils.NewMemoryRevocationStore()
+	defer func() { utils.Revocations = previous }()
+
+	token, err := utils.GenerateToken(models.User{ID: 1, Role: "user"}, models.Session{ID: "test"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	other, _ := utils.GenerateToken(models.User{ID: 1, Role: "user"}, models.Session{ID: "test"})
+
+	utils.AddToBlacklist(token)
+
+	if !utils.IsBlacklisted(token) {
+		t.Errorf("expected token to be blacklisted")
+	}
+	if utils.IsBlacklisted(other) {
+		t.Errorf("expected a different token of the same user to stay valid")
+	}
+}
@@ -0,0 +1,94 @@
+package tes
+
+import (
+	"reflect"
+	"testing"
+
+	"NYANIMEBACKEND/utils"
+)
+
+func newTestSearchIndex() *utils.MemorySearchIndex {
+	idx := utils.NewMemorySearchIndex()
+	idx.Index(utils.SearchDocument{ID: 1, Title: "Frieren: Beyond Journey's End", AltTitles: []string{"Sousou no Frieren"}, Description: "An elf mage outlives her party."})
+	idx.Index(utils.SearchDocument{ID: 2, Title: "Fullmetal Alchemist: Brotherhood", Description: "Two brothers search for the philosopher's stone."})
+	idx.Index(utils.SearchDocument{ID: 3, Title: "Mushoku Tensei", Description: "A reincarnated mage learns magic. Frieren fans will like it."})
+	idx.Index(utils.SearchDocument{ID: 4, Title: "葬送のフリーレン"})
+	return idx
+}
+
+func searchIDs(t *testing.T, idx utils.SearchIndex, query string) []uint {
+	t.Helper()
+	hits, err := idx.Search(query, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	got := utils.Tokenize("Frieren: Beyond Journey's End")
+	want := []string{"frieren", "beyond", "journeys", "end"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize = %v, want %v", got, want)
+	}
+	if got := utils.Tokenize("フリーレン"); len(got) != 4 || got[0] != "フリ" {
+		t.Errorf("expected CJK bigrams, got %v", got)
+	}
+}
+
+func TestSearchRanksTitleAboveDescription(t *testing.T) {
+	ids := searchIDs(t, newTestSearchIndex(), "frieren")
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("expected [1 3], got %v", ids)
+	}
+}
+
+func TestSearchPrefixAndAltTitles(t *testing.T) {
+	idx := newTestSearchIndex()
+	if ids := searchIDs(t, idx, "fullme"); len(ids) == 0 || ids[0] != 2 {
+		t.Errorf("prefix search: got %v", ids)
+	}
+	if ids := searchIDs(t, idx, "sousou"); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("alternate title search: got %v", ids)
+	}
+	if ids := searchIDs(t, idx, "フリーレン"); len(ids) == 0 || ids[0] != 4 {
+		t.Errorf("CJK search: got %v", ids)
+	}
+}
+
+func TestSearchTypoTolerance(t *testing.T) {
+	idx := newTestSearchIndex()
+	if ids := searchIDs(t, idx, "freiren"); len(ids) == 0 || ids[0] != 1 {
+		t.Errorf("transposition: got %v", ids)
+	}
+	if ids := searchIDs(t, idx, "alchemst brotherhod"); len(ids) == 0 || ids[0] != 2 {
+		t.Errorf("missing letters: got %v", ids)
+	}
+	// Term pendek tidak mendapat toleransi typo
+	if ids := searchIDs(t, idx, "elg"); len(ids) != 0 {
+		t.Errorf("expected no fuzzy match for short term, got %v", ids)
+	}
+}
+
+func TestSearchIndexUpdateAndRemove(t *testing.T) {
+	idx := newTestSearchIndex()
+	idx.Index(utils.SearchDocument{ID: 2, Title: "Hunter x Hunter"})
+	if ids := searchIDs(t, idx, "alchemist"); len(ids) != 0 {
+		t.Errorf("stale terms still indexed: %v", ids)
+	}
+	if ids := searchIDs(t, idx, "hunter"); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("updated document not found: %v", ids)
+	}
+
+	idx.Remove(1)
+	if ids := searchIDs(t, idx, "frieren"); len(ids) != 1 || ids[0] != 3 {
+		t.Errorf("removed document still returned: %v", ids)
+	}
+	if ids := searchIDs(t, idx, "   "); len(ids) != 0 {
+		t.Errorf("empty query returned %v", ids)
+	}
+}
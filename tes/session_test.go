@@ -0,0 +1,126 @@
+package tes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// sessionRouter memasang endpoint sesi seperti di routes.go
+func sessionRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.Handle("/user/sessions", utils.SessionAuthMiddleware(http.HandlerFunc(controller.GetSessions))).Methods("GET")
+	router.Handle("/user/sessions", utils.SessionAuthMiddleware(http.HandlerFunc(controller.RevokeAllSessions))).Methods("DELETE")
+	router.Handle("/user/sessions/{id}", utils.SessionAuthMiddleware(http.HandlerFunc(controller.RevokeSession))).Methods("DELETE")
+	return router
+}
+
+func listSessions(t *testing.T, router http.Handler, token string) []models.Session {
+	t.Helper()
+	rec := serveWithToken(router, http.MethodGet, "/user/sessions", token, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 when listing sessions, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var sessions []models.Session
+	if err := json.NewDecoder(rec.Body).Decode(&sessions); err != nil {
+		t.Fatalf("invalid session list: %v", err)
+	}
+	return sessions
+}
+
+func TestListSessions(t *testing.T) {
+	setupAuthModels(t)
+	router := sessionRouter()
+	user := createTestUser(t, "sessions")
+	other := createTestUser(t, "sessions-other")
+	token, current := loginAs(t, user)
+	_, second := loginAs(t, user)
+	loginAs(t, other)
+
+	sessions := listSessions(t, router, token)
+	if len(sessions) != 2 {
+		t.Fatalf("expected the user's 2 sessions, got %d", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.ID != current.ID && s.ID != second.ID {
+			t.Errorf("unexpected session %s in the list", s.ID)
+		}
+		if s.Current != (s.ID == current.ID) {
+			t.Errorf("session %s: expected current=%v", s.ID, s.ID == current.ID)
+		}
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	setupAuthModels(t)
+	router := sessionRouter()
+	user := createTestUser(t, "revoke-one")
+	other := createTestUser(t, "revoke-one-other")
+	token, _ := loginAs(t, user)
+	secondToken, second := loginAs(t, user)
+	_, foreign := loginAs(t, other)
+
+	if rec := serveWithToken(router, http.MethodDelete, "/user/sessions/"+second.ID, token, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if code := serveWithToken(router, http.MethodGet, "/user/sessions", secondToken, "").Code; code != http.StatusUnauthorized {
+		t.Errorf("expected the revoked session's token to be rejected, got %d", code)
+	}
+	if sessions := listSessions(t, router, token); len(sessions) != 1 {
+		t.Errorf("expected 1 remaining session, got %d", len(sessions))
+	}
+
+	// Sesi milik pengguna lain diperlakukan seperti tidak ada
+	if code := serveWithToken(router, http.MethodDelete, "/user/sessions/"+foreign.ID, token, "").Code; code != http.StatusNotFound {
+		t.Errorf("expected 404 when revoking another user's session, got %d", code)
+	}
+	if _, err := utils.ActiveSession(foreign.ID); err != nil {
+		t.Errorf("expected the other user's session to stay active, got %v", err)
+	}
+
+	// Sesi yang sudah dicabut tidak bisa dicabut lagi
+	if code := serveWithToken(router, http.MethodDelete, "/user/sessions/"+second.ID, token, "").Code; code != http.StatusNotFound {
+		t.Errorf("expected 404 for an already revoked session, got %d", code)
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	setupAuthModels(t)
+	router := sessionRouter()
+	user := createTestUser(t, "revoke-all")
+	other := createTestUser(t, "revoke-all-other")
+	token, current := loginAs(t, user)
+	_, second := loginAs(t, user)
+	_, foreign := loginAs(t, other)
+	refresh, err := utils.IssueRefreshToken(user.ID, second.ID)
+	if err != nil {
+		t.Fatalf("failed to issue refresh token: %v", err)
+	}
+
+	if rec := serveWithToken(router, http.MethodDelete, "/user/sessions", token, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Termasuk sesi saat ini dan refresh token semua sesi
+	for _, id := range []string{current.ID, second.ID} {
+		if _, err := utils.ActiveSession(id); !errors.Is(err, utils.ErrSessionInactive) {
+			t.Errorf("expected session %s to be revoked, got %v", id, err)
+		}
+	}
+	if code := serveWithToken(router, http.MethodGet, "/user/sessions", token, "").Code; code != http.StatusUnauthorized {
+		t.Errorf("expected the current token to be rejected, got %d", code)
+	}
+	if _, _, err := utils.RotateRefreshToken(refresh); err == nil {
+		t.Error("expected refresh tokens of revoked sessions to be rejected")
+	}
+	if _, err := utils.ActiveSession(foreign.ID); err != nil {
+		t.Errorf("expected the other user's session to stay active, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package tes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	got := utils.ParseAcceptLanguage("fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5, ja;q=0")
+	want := []string{"fr-ch", "fr", "en", "de"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAcceptLanguage = %v, want %v", got, want)
+	}
+
+	got = utils.ParseAcceptLanguage("en;q=0.5, id")
+	if want := []string{"id", "en"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAcceptLanguage = %v, want %v", got, want)
+	}
+	if got := utils.ParseAcceptLanguage(""); len(got) != 0 {
+		t.Errorf("expected no languages, got %v", got)
+	}
+}
+
+func TestRequestLanguagesQueryFirst(t *testing.T) {
+	r := httptest.NewRequest("GET", "/anime/?lang=ja_Latn", nil)
+	r.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	got := utils.RequestLanguages(r)
+	want := []string{"ja-latn", "en-us", "en"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RequestLanguages = %v, want %v", got, want)
+	}
+}
+
+func TestPreferredTitle(t *testing.T) {
+	titles := []models.AnimeTitle{
+		{Title: "Shingeki no Kyojin", Language: "ja-latn", Type: models.TitleTypeOfficial},
+		{Title: "進撃の巨人", Language: "ja", Type: models.TitleTypeOfficial},
+		{Title: "AoT", Language: "en", Type: models.TitleTypeShort},
+		{Title: "Attack on Titan", Language: "en", Type: models.TitleTypeOfficial},
+	}
+	cases := []struct {
+		langs []string
+		want  string
+		ok    bool
+	}{
+		{[]string{"ja"}, "進撃の巨人", true},
+		{[]string{"ja-latn"}, "Shingeki no Kyojin", true},
+		{[]string{"en-us"}, "Attack on Titan", true},
+		{[]string{"ja-jp"}, "進撃の巨人", true},
+		{[]string{"fr", "en"}, "Attack on Titan", true},
+		{[]string{"fr"}, "", false},
+		{nil, "", false},
+	}
+	for _, c := range cases {
+		got, ok := utils.PreferredTitle(titles, c.langs)
+		if got != c.want || ok != c.ok {
+			t.Errorf("PreferredTitle(%v) = %q, %v; want %q, %v", c.langs, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestNormalizeTitleInputs(t *testing.T) {
+	got, err := utils.NormalizeTitleInputs([]utils.TitleInput{
+		{Title: "  Attack  on Titan ", Language: "EN"},
+		{Title: "attack on titan", Language: "en", Type: "synonym"},
+		{Title: "AoT", Language: "en", Type: "Short"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []utils.TitleInput{
+		{Title: "Attack on Titan", Language: "en", Type: models.TitleTypeOfficial},
+		{Title: "AoT", Language: "en", Type: models.TitleTypeShort},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeTitleInputs = %v, want %v", got, want)
+	}
+
+	for _, bad := range []utils.TitleInput{
+		{Title: "", Language: "en"},
+		{Title: "Title", Language: "english!"},
+		{Title: "Title", Language: "en", Type: "nickname"},
+	} {
+		if _, err := utils.NormalizeTitleInputs([]utils.TitleInput{bad}); err == nil {
+			t.Errorf("expected error for %+v", bad)
+		}
+	}
+}
+
+func TestSearchAltTitles(t *testing.T) {
+	idx := utils.NewMemorySearchIndex()
+	idx.Index(utils.AnimeSearchDocument(
+		models.Anime{ID: 1, Title: "Attack on Titan"},
+		[]models.AnimeTitle{{Title: "Shingeki no Kyojin"}, {Title: "進撃の巨人"}},
+	))
+	for _, q := range []string{"shingeki", "進撃"} {
+		hits, err := idx.Search(q, 10)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", q, err)
+		}
+		if len(hits) != 1 || hits[0].ID != 1 {
+			t.Errorf("Search(%q) = %v, want anime 1", q, hits)
+		}
+	}
+}
+
+func TestEditUserProfileKeepsPreferredLanguage(t *testing.T) {
+	setupModels(t, &models.User{})
+	user := createTestUser(t, "profile-lang")
+
+	edit := func(body string) string {
+		req := httptest.NewRequest(http.MethodPut, "/user/profile", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), utils.UserIDKey, user.ID))
+		rec := httptest.NewRecorder()
+		controller.EditUserProfile(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d: %s", body, rec.Code, rec.Body.String())
+		}
+		var stored models.User
+		utils.DB.First(&stored, user.ID)
+		return stored.PreferredLanguage
+	}
+
+	if lang := edit(`{"username":"profile-lang","preferred_language":"ja-Latn"}`); lang != "ja-latn" {
+		t.Fatalf("expected the preference to be saved, got %q", lang)
+	}
+	// Frontend lama hanya mengirim username dan bio
+	if lang := edit(`{"username":"profile-lang","bio":"hello"}`); lang != "ja-latn" {
+		t.Errorf("expected the preference to be kept when the field is absent, got %q", lang)
+	}
+	if lang := edit(`{"username":"profile-lang","preferred_language":""}`); lang != "" {
+		t.Errorf("expected an empty string to clear the preference, got %q", lang)
+	}
+}
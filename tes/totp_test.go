@@ -0,0 +1,56 @@
+package tes
+
+import (
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/utils"
+)
+
+// Secret ASCII "12345678901234567890" dari lampiran B RFC 6238
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		name string
+		unix int64
+		code string
+	}{
+		{"T59", 59, "287082"},
+		{"T1111111109", 1111111109, "081804"},
+		{"T1234567890", 1234567890, "005924"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := utils.TOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if code != tt.code {
+				t.Errorf("expected code %s, got %s", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	code, _ := utils.TOTPCode(rfcSecret, now)
+	previous, _ := utils.TOTPCode(rfcSecret, now.Add(-30*time.Second))
+	stale, _ := utils.TOTPCode(rfcSecret, now.Add(-5*time.Minute))
+
+	counter, ok := utils.ValidateTOTP(rfcSecret, code, now, 0)
+	if !ok {
+		t.Fatalf("expected current code to be valid")
+	}
+	if _, ok := utils.ValidateTOTP(rfcSecret, previous, now, 0); !ok {
+		t.Errorf("expected code from previous period to be accepted")
+	}
+	if _, ok := utils.ValidateTOTP(rfcSecret, stale, now, 0); ok {
+		t.Errorf("expected stale code to be rejected")
+	}
+	if _, ok := utils.ValidateTOTP(rfcSecret, code, now, counter); ok {
+		t.Errorf("expected replayed code to be rejected")
+	}
+}
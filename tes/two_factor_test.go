@@ -0,0 +1,72 @@
+package tes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/controller"
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestEnableTwoFactorOnce(t *testing.T) {
+	setupAuthModels(t, &models.RecoveryCode{})
+	user := createTestUser(t, "enable2fa")
+	_, session := loginAs(t, user)
+
+	codes, err := utils.EnableTwoFactor(user.ID, 1, session.ID)
+	if err != nil {
+		t.Fatalf("enable failed: %v", err)
+	}
+	if len(codes) != utils.RecoveryCodeCount {
+		t.Errorf("expected %d recovery codes, got %d", utils.RecoveryCodeCount, len(codes))
+	}
+
+	// Request kedua tidak boleh mengganti kode pemulihan yang sudah diberikan
+	if _, err := utils.EnableTwoFactor(user.ID, 2, session.ID); !errors.Is(err, utils.ErrTwoFactorAlreadyEnabled) {
+		t.Fatalf("expected ErrTwoFactorAlreadyEnabled, got %v", err)
+	}
+	if ok, err := utils.ConsumeRecoveryCode(user.ID, codes[0]); err != nil || !ok {
+		t.Errorf("expected the first set of recovery codes to stay valid, got ok=%v err=%v", ok, err)
+	}
+
+	stored, err := utils.ActiveSession(session.ID)
+	if err != nil || !stored.MFA {
+		t.Errorf("expected the session to be marked as MFA, got %+v err=%v", stored, err)
+	}
+}
+
+func TestEnableTwoFactorRevokedSession(t *testing.T) {
+	setupAuthModels(t, &models.RecoveryCode{})
+	user := createTestUser(t, "enable2fa-revoked")
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("failed to generate secret: %v", err)
+	}
+	utils.DB.Model(&user).Update("totp_secret", secret)
+	_, session := loginAs(t, user)
+	if err := utils.RevokeSession(user.ID, session.ID); err != nil {
+		t.Fatalf("failed to revoke session: %v", err)
+	}
+
+	code, _ := utils.TOTPCode(secret, time.Now())
+	req := httptest.NewRequest(http.MethodPost, "/user/2fa/enable", strings.NewReader(`{"code":"`+code+`"}`))
+	ctx := context.WithValue(req.Context(), utils.UserIDKey, user.ID)
+	ctx = context.WithValue(ctx, utils.SessionIDKey, session.ID)
+	rec := httptest.NewRecorder()
+	controller.EnableTwoFactor(rec, req.WithContext(ctx))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a revoked session, got %d", rec.Code)
+	}
+
+	var stored models.User
+	utils.DB.First(&stored, user.ID)
+	if stored.TOTPEnabled {
+		t.Error("expected two-factor authentication to stay disabled")
+	}
+}
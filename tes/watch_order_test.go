@@ -0,0 +1,107 @@
+package tes
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"NYANIMEBACKEND/models"
+	"NYANIMEBACKEND/utils"
+)
+
+func TestWatchOrderFollowsRelations(t *testing.T) {
+	// 1 -> 2 (sekuel), 2 -> 4 (sekuel), 1 -> 3 (side story). Anime 3 rilis sebelum 2,
+	// jadi di antara anime yang sama-sama siap, 3 didahulukan.
+	nodes := []utils.WatchOrderNode{
+		{ID: 4, ReleaseDate: "2015-01-01"},
+		{ID: 2, ReleaseDate: "2012-01-01"},
+		{ID: 3, ReleaseDate: "2011-06-01"},
+		{ID: 1, ReleaseDate: "2010-01-01"},
+		{ID: 5}, // Tanggal rilis belum diketahui
+	}
+	var edges []utils.WatchOrderEdge
+	for _, rel := range []models.AnimeRelation{
+		{AnimeID: 1, RelatedID: 2, Type: utils.RelationSequel},
+		{AnimeID: 4, RelatedID: 2, Type: utils.RelationPrequel},
+		{AnimeID: 1, RelatedID: 3, Type: utils.RelationSideStory},
+		{AnimeID: 1, RelatedID: 5, Type: utils.RelationAlternativeVersion},
+	} {
+		if edge, ok := utils.RelationWatchEdge(rel); ok {
+			edges = append(edges, edge)
+		}
+	}
+
+	order, err := utils.WatchOrder(nodes, edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint{1, 3, 2, 4, 5}; !reflect.DeepEqual(order, want) {
+		t.Errorf("WatchOrder = %v, want %v", order, want)
+	}
+}
+
+func TestWatchOrderDetectsCycle(t *testing.T) {
+	nodes := []utils.WatchOrderNode{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	edges := []utils.WatchOrderEdge{{Before: 4, After: 1}, {Before: 1, After: 2}, {Before: 2, After: 3}, {Before: 3, After: 1}}
+
+	_, err := utils.WatchOrder(nodes, edges)
+	var cycleErr *utils.RelationCycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("expected RelationCycleError, got %v", err)
+	}
+	if want := []uint{1, 2, 3, 1}; !reflect.DeepEqual(cycleErr.Cycle, want) {
+		t.Errorf("cycle = %v, want %v", cycleErr.Cycle, want)
+	}
+
+	if _, err := utils.WatchOrder(nodes[:1], []utils.WatchOrderEdge{{Before: 1, After: 1}}); !errors.As(err, &cycleErr) {
+		t.Errorf("expected self-loop to be reported as a cycle, got %v", err)
+	}
+}
+
+func TestInverseRelation(t *testing.T) {
+	for _, relationType := range utils.RelationTypes() {
+		inverse, ok := utils.InverseRelation(relationType)
+		if !ok {
+			t.Fatalf("%s has no inverse", relationType)
+		}
+		// Kebalikan dari kebalikan harus kembali ke jenis asal
+		if back, _ := utils.InverseRelation(inverse); back != relationType {
+			t.Errorf("inverse %s of %s maps back to %q", inverse, relationType, back)
+		}
+	}
+	if inverse, _ := utils.InverseRelation(utils.RelationSequel); inverse != utils.RelationPrequel {
+		t.Errorf("inverse of sequel = %s", inverse)
+	}
+	if _, ok := utils.InverseRelation("remake"); ok {
+		t.Error("unknown relation type should not have an inverse")
+	}
+}
+
+func TestMigrateSpinOffInverses(t *testing.T) {
+	setupModels(t, &models.AnimeRelation{})
+	base := uint(time.Now().UnixNano() % 1000000000)
+	rows := []models.AnimeRelation{
+		{AnimeID: base, RelatedID: base + 1, Type: utils.RelationSpinOff},
+		{AnimeID: base + 1, RelatedID: base, Type: utils.RelationParentStory},
+		{AnimeID: base, RelatedID: base + 2, Type: utils.RelationSideStory},
+		{AnimeID: base + 2, RelatedID: base, Type: utils.RelationParentStory},
+	}
+	if err := utils.DB.Create(&rows).Error; err != nil {
+		t.Fatalf("failed to create relations: %v", err)
+	}
+
+	if err := utils.MigrateSpinOffInverses(utils.DB); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{utils.RelationSpinOff, utils.RelationOriginalStory, utils.RelationSideStory, utils.RelationParentStory}
+	for i, row := range rows {
+		var stored models.AnimeRelation
+		if err := utils.DB.First(&stored, row.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.Type != want[i] {
+			t.Errorf("relation %d->%d: expected %s, got %s", row.AnimeID, row.RelatedID, want[i], stored.Type)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// Cara menangani review saat akun dihapus
+const (
+	DeletionModeAnonymize = "anonymize" // Review tetap ada atas nama "Deleted user"
+	DeletionModeDelete    = "delete"    // Review ikut dihapus dan rating anime dihitung ulang
+)
+
+// deletedUsername adalah nama yang ditampilkan untuk akun yang sudah dianonimkan
+const deletedUsername = "Deleted user"
+
+var (
+	ErrInvalidDeletionMode   = errors.New("deletion mode must be anonymize or delete")
+	ErrDeletionNotScheduled  = errors.New("account deletion is not scheduled")
+	ErrDeletionAlreadyQueued = errors.New("account deletion is already scheduled")
+)
+
+// AccountDeletionGracePeriod dibaca dari ACCOUNT_DELETION_GRACE_PERIOD (default 14 hari).
+// Nilai "0" berarti akun langsung dihapus.
+func AccountDeletionGracePeriod() time.Duration {
+	if os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD") == "0" {
+		return 0
+	}
+	return envDuration("ACCOUNT_DELETION_GRACE_PERIOD", 14*24*time.Hour)
+}
+
+// ValidDeletionMode mengecek mode penghapusan
+func ValidDeletionMode(mode string) bool {
+	return mode == DeletionModeAnonymize || mode == DeletionModeDelete
+}
+
+// ScheduleAccountDeletion menjadwalkan penghapusan akun setelah masa tenggang.
+// Jika masa tenggang 0, akun langsung dihapus dan waktu nol dikembalikan.
+func ScheduleAccountDeletion(user models.User, mode string) (time.Time, error) {
+	if !ValidDeletionMode(mode) {
+		return time.Time{}, ErrInvalidDeletionMode
+	}
+	if user.DeletionScheduledAt != nil {
+		return time.Time{}, ErrDeletionAlreadyQueued
+	}
+
+	grace := AccountDeletionGracePeriod()
+	if grace == 0 {
+		return time.Time{}, DeleteAccount(user.ID, mode)
+	}
+
+	scheduledAt := time.Now().Add(grace)
+	err := DB.Model(&user).Updates(map[string]interface{}{
+		"deletion_scheduled_at": scheduledAt,
+		"deletion_mode":         mode,
+	}).Error
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	err = GetMailer().Send(Mail{
+		To:      user.Email,
+		Subject: "Your Nyanime account is scheduled for deletion",
+		Body: fmt.Sprintf("Hi %s,\n\nYour Nyanime account will be permanently deleted on %s.\n"+
+			"If you change your mind, log in before then and cancel the deletion from your account settings.\n",
+			user.Username, scheduledAt.UTC().Format("2 January 2006 15:04 MST")),
+	})
+	if err != nil {
+		log.Println("Error sending deletion notice:", err)
+	}
+	return scheduledAt, nil
+}
+
+// CancelAccountDeletion membatalkan penghapusan yang sudah dijadwalkan
+func CancelAccountDeletion(userID int) error {
+	res := DB.Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL", userID).
+		Updates(map[string]interface{}{"deletion_scheduled_at": nil, "deletion_mode": ""})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrDeletionNotScheduled
+	}
+	return nil
+}
+
+// DeleteAccount menghapus data pribadi pengguna. Mode anonymize mempertahankan review dengan
+// profil yang dikosongkan; mode delete menghapus pengguna beserta review-nya.
+func DeleteAccount(userID int, mode string) error {
+	if !ValidDeletionMode(mode) {
+		return ErrInvalidDeletionMode
+	}
+
+	var user models.User
+	if err := DB.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	var affectedAnime []uint
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// Data yang terikat ke akun selalu dihapus
+		for _, model := range []interface{}{
+			&models.RefreshToken{}, &models.Session{}, &models.PasswordResetToken{},
+			&models.EmailVerificationToken{}, &models.RecoveryCode{}, &models.APIKey{},
+			&models.LinkedIdentity{}, &models.Favorite{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("link_user_id = ?", userID).Delete(&models.OAuthState{}).Error; err != nil {
+			return err
+		}
+
+		if mode == DeletionModeDelete {
+			if err := tx.Model(&models.Review{}).Where("user_id = ?", userID).Distinct().Pluck("anime_id", &affectedAnime).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("user_id = ?", userID).Delete(&models.Review{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&models.User{}, userID).Error
+		}
+
+		// Anonimkan: profil dikosongkan dan akun tidak bisa dipakai login lagi
+		return tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"username":              deletedUsername,
+			"email":                 fmt.Sprintf("deleted-%d@deleted.invalid", userID),
+			"password":              "",
+			"password_unset":        true,
+			"bio":                   "",
+			"verified_at":           nil,
+			"totp_secret":           "",
+			"totp_enabled":          false,
+			"token_version":         gorm.Expr("token_version + 1"),
+			"deletion_scheduled_at": nil,
+			"deletion_mode":         "",
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, animeID := range affectedAnime {
+		if err := models.RecalculateAverageRating(DB, animeID); err != nil {
+			log.Printf("Error recalculating rating for anime %d: %v", animeID, err)
+		}
+	}
+	if err := LoginAttempts.Delete(accountKey(user.Email)); err != nil {
+		log.Println("Error clearing login attempts:", err)
+	}
+
+	log.Printf("Account %d deleted (mode=%s)", userID, mode)
+	return nil
+}
+
+// ProcessDueAccountDeletions menghapus akun yang masa tenggangnya sudah habis
+func ProcessDueAccountDeletions() (int, error) {
+	var due []models.User
+	if err := DB.Select("id", "deletion_mode").
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", time.Now()).
+		Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, user := range due {
+		mode := user.DeletionMode
+		if !ValidDeletionMode(mode) {
+			mode = DeletionModeAnonymize
+		}
+		if err := DeleteAccount(user.ID, mode); err != nil {
+			log.Printf("Error deleting account %d: %v", user.ID, err)
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// StartAccountDeletionWorker menjalankan ProcessDueAccountDeletions secara berkala di background
+func StartAccountDeletionWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := ProcessDueAccountDeletions(); err != nil {
+				log.Println("Error processing account deletions:", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"NYANIMEBACKEND/models"
+)
+
+var (
+	ErrAccountBanned         = errors.New("account has been banned")
+	ErrAccountSuspended      = errors.New("account is suspended")
+	ErrPasswordResetRequired = errors.New("password reset required")
+)
+
+// AccountSuspendedError membawa waktu berakhirnya suspensi
+type AccountSuspendedError struct {
+	Until time.Time
+}
+
+func (e *AccountSuspendedError) Error() string {
+	return fmt.Sprintf("account is suspended until %s", e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *AccountSuspendedError) Unwrap() error {
+	return ErrAccountSuspended
+}
+
+// AccountRestriction mengecek apakah akun sedang di-ban atau di-suspend
+func AccountRestriction(user models.User, now time.Time) error {
+	if user.BannedAt != nil {
+		return ErrAccountBanned
+	}
+	if user.SuspendedUntil != nil && now.Before(*user.SuspendedUntil) {
+		return &AccountSuspendedError{Until: *user.SuspendedUntil}
+	}
+	return nil
+}
+
+// BanUser mem-ban akun tanpa batas waktu dan mencabut semua sesinya
+func BanUser(userID int, reason string) error {
+	err := DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"banned_at":  time.Now(),
+		"ban_reason": reason,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return InvalidateUserTokens(userID, "")
+}
+
+// UnbanUser mencabut ban
+func UnbanUser(userID int) error {
+	return DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"banned_at":  nil,
+		"ban_reason": "",
+	}).Error
+}
+
+// SuspendUser menangguhkan akun sampai waktu tertentu dan mencabut semua sesinya
+func SuspendUser(userID int, until time.Time, reason string) error {
+	err := DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"suspended_until": until,
+		"suspend_reason":  reason,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return InvalidateUserTokens(userID, "")
+}
+
+// LiftSuspension mengakhiri suspensi lebih awal
+func LiftSuspension(userID int) error {
+	return DB.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"suspended_until": nil,
+		"suspend_reason":  "",
+	}).Error
+}
+
+// ForcePasswordReset mewajibkan pengguna mengganti password: semua sesi dicabut,
+// login ditolak sampai password direset, dan link reset dikirim ke email pengguna
+func ForcePasswordReset(user models.User) error {
+	if err := DB.Model(&user).Update("password_reset_required", true).Error; err != nil {
+		return err
+	}
+	if err := InvalidateUserTokens(user.ID, ""); err != nil {
+		return err
+	}
+	if err := RevokeUserAPIKeys(user.ID); err != nil {
+		return err
+	}
+	// Tanpa cooldown: akun sudah terkunci, jadi link reset harus selalu terkirim
+	return sendPasswordResetEmail(user)
+}
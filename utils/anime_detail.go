@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"errors"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// RatingCount adalah jumlah review untuk satu nilai rating
+type RatingCount struct {
+	Rating int64
+	Count  int64
+}
+
+// BuildRatingHistogram menyusun histogram rating 1-5 dan total review.
+// Rating di luar 1-5 (data lama) tetap dihitung di total tetapi tidak di histogram.
+func BuildRatingHistogram(rows []RatingCount) (map[int]int64, int64) {
+	histogram := map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	var total int64
+	for _, row := range rows {
+		total += row.Count
+		if row.Rating >= 1 && row.Rating <= 5 {
+			histogram[int(row.Rating)] += row.Count
+		}
+	}
+	return histogram, total
+}
+
+// GetAnimeDetail mengambil anime beserta statistiknya. viewerID 0 berarti pemanggil anonim.
+func GetAnimeDetail(db *gorm.DB, animeID uint, viewerID int, recentLimit int) (*models.AnimeDetail, error) {
+	var detail models.AnimeDetail
+	if err := db.Preload("Genres", func(db *gorm.DB) *gorm.DB { return db.Order("genres.name") }).First(&detail.Anime, animeID).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []RatingCount
+	if err := db.Model(&models.Review{}).
+		Select("rating, COUNT(*) AS count").
+		Where("anime_id = ?", animeID).
+		Group("rating").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	detail.RatingHistogram, detail.ReviewCount = BuildRatingHistogram(counts)
+
+	if err := db.Model(&models.Favorite{}).Where("anime_id = ?", animeID).Count(&detail.FavoriteCount).Error; err != nil {
+		return nil, err
+	}
+	var err error
+	if detail.Studios, err = animeStudioCredits(db, animeID); err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&models.Season{}).Where("anime_id = ?", animeID).Count(&detail.SeasonCount).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Episode{}).Where("anime_id = ?", animeID).Count(&detail.EpisodeCount).Error; err != nil {
+		return nil, err
+	}
+
+	detail.RecentReviews = []models.ReviewWithUser{}
+	if recentLimit > 0 {
+		if err := db.Table("reviews_new").
+			Select("reviews_new.*, users.username").
+			Joins("LEFT JOIN users ON users.id = reviews_new.user_id").
+			Where("reviews_new.anime_id = ?", animeID).
+			Order("reviews_new.created_at DESC, reviews_new.id DESC").
+			Limit(recentLimit).
+			Scan(&detail.RecentReviews).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if viewerID != 0 {
+		viewer := &models.AnimeViewer{}
+
+		var review models.Review
+		err := db.Where("anime_id = ? AND user_id = ?", animeID, viewerID).First(&review).Error
+		if err == nil {
+			viewer.Review = &review
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		var favorite models.Favorite
+		err = db.Where("anime_id = ? AND user_id = ?", animeID, viewerID).First(&favorite).Error
+		if err == nil {
+			viewer.IsFavorite = true
+			viewer.FavoriteID = &favorite.ID
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		detail.Viewer = viewer
+	}
+
+	return &detail, nil
+}
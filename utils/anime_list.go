@@ -0,0 +1,325 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// Ekspresi statistik review dan episode, dihitung dari subquery reviewStatsJoin dan episodeStatsJoin
+const (
+	animeRatingExpr       = "COALESCE(rs.avg_rating, 0)"
+	animeReviewCountExpr  = "COALESCE(rs.review_count, 0)"
+	animeEpisodeCountExpr = "COALESCE(es.episode_count, 0)"
+	animeReleaseYearExpr  = "YEAR(animes.start_date)"
+	animeStartDateExpr    = "COALESCE(animes.start_date, '" + unknownStartDate + "')" // Anime tanpa tanggal di akhir urutan naik
+	reviewStatsJoin       = "LEFT JOIN (SELECT anime_id, AVG(rating) AS avg_rating, COUNT(*) AS review_count FROM reviews_new GROUP BY anime_id) rs ON rs.anime_id = animes.id"
+	episodeStatsJoin      = "LEFT JOIN (SELECT anime_id, COUNT(*) AS episode_count FROM episodes GROUP BY anime_id) es ON es.anime_id = animes.id"
+)
+
+// unknownStartDate menggantikan start_date kosong saat mengurutkan, agar cursor tetap bisa dibandingkan
+const unknownStartDate = "9999-12-31"
+
+// animeSortColumns memetakan nilai parameter sort ke kolom SQL
+var animeSortColumns = map[string]string{
+	"id":            "animes.id",
+	"title":         "animes.title",
+	"rating":        animeRatingExpr,
+	"release_date":  animeStartDateExpr,
+	"start_date":    animeStartDateExpr,
+	"review_count":  animeReviewCountExpr,
+	"episode_count": animeEpisodeCountExpr,
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// AnimeListParams adalah parameter query GET /anime/
+type AnimeListParams struct {
+	Page       int
+	Limit      int
+	Cursor     *AnimeCursor // Posisi terakhir dari halaman sebelumnya
+	UseCursor  bool         // Klien memakai pagination cursor (parameter cursor ada, boleh kosong untuk halaman pertama)
+	Legacy     bool         // Tidak ada page, limit maupun cursor: klien lama yang mengharapkan array semua anime
+	Genres     []string
+	Status     string
+	Season     string
+	SeasonYear int
+	YearFrom   int
+	YearTo     int
+	MinRating  *float64
+	Sort       string
+	Desc       bool
+}
+
+// AnimeCursor menandai posisi terakhir pada urutan sort tertentu (keyset pagination)
+type AnimeCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// Encode mengubah cursor menjadi string opaque untuk klien
+func (c AnimeCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeAnimeCursor membaca cursor dari klien
+func DecodeAnimeCursor(s string) (*AnimeCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c AnimeCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if _, ok := animeSortColumns[c.Sort]; !ok {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ParseAnimeListParams membaca dan memvalidasi parameter:
+// page, limit, cursor (tanpa ketiganya request dianggap klien lama, lihat Legacy), genre (nama atau slug dipisah koma, semua harus cocok), year_from, year_to,
+// min_rating, status, season, season_year, sort (id|title|rating|release_date|start_date|review_count|episode_count,
+// awalan "-" untuk descending) dan order (asc|desc). Tahun dan sort tanggal memakai start_date.
+func ParseAnimeListParams(query url.Values) (AnimeListParams, error) {
+	params := AnimeListParams{Page: 1, Limit: 20, Sort: "id"}
+
+	if v := query.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return params, errors.New("page must be a positive integer")
+		}
+		params.Page = page
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return params, errors.New("limit must be a positive integer")
+		}
+		if limit > 100 {
+			limit = 100
+		}
+		params.Limit = limit
+	}
+
+	for _, g := range strings.Split(query.Get("genre"), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			params.Genres = append(params.Genres, g)
+		}
+	}
+
+	for name, target := range map[string]*int{"year_from": &params.YearFrom, "year_to": &params.YearTo} {
+		if v := query.Get(name); v != "" {
+			year, err := strconv.Atoi(v)
+			if err != nil || year < 1900 || year > 9999 {
+				return params, fmt.Errorf("%s must be a four-digit year", name)
+			}
+			*target = year
+		}
+	}
+	if params.YearFrom != 0 && params.YearTo != 0 && params.YearFrom > params.YearTo {
+		return params, errors.New("year_from must not be after year_to")
+	}
+
+	if v := query.Get("status"); v != "" {
+		if !ValidAiringStatus(v) {
+			return params, fmt.Errorf("status must be one of %s", strings.Join(AiringStatuses, ", "))
+		}
+		params.Status = v
+	}
+	if v := query.Get("season"); v != "" {
+		season, ok := ParseBroadcastSeason(v)
+		if !ok {
+			return params, fmt.Errorf("season must be one of %s", strings.Join(BroadcastSeasons, ", "))
+		}
+		params.Season = season
+	}
+	if v := query.Get("season_year"); v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil || year < 1900 || year > 9999 {
+			return params, errors.New("season_year must be a four-digit year")
+		}
+		params.SeasonYear = year
+	}
+
+	if v := query.Get("min_rating"); v != "" {
+		rating, err := strconv.ParseFloat(v, 64)
+		if err != nil || rating < 0 || rating > 5 {
+			return params, errors.New("min_rating must be between 0 and 5")
+		}
+		params.MinRating = &rating
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		if strings.HasPrefix(sort, "-") {
+			params.Desc = true
+			sort = sort[1:]
+		}
+		if _, ok := animeSortColumns[sort]; !ok {
+			return params, fmt.Errorf("unsupported sort %q", sort)
+		}
+		params.Sort = sort
+	}
+	switch strings.ToLower(query.Get("order")) {
+	case "":
+	case "asc":
+		params.Desc = false
+	case "desc":
+		params.Desc = true
+	default:
+		return params, errors.New("order must be asc or desc")
+	}
+
+	params.UseCursor = query.Has("cursor")
+	params.Legacy = !query.Has("page") && !query.Has("limit") && !params.UseCursor
+	if params.UseCursor && query.Get("cursor") != "" {
+		cursor, err := DecodeAnimeCursor(query.Get("cursor"))
+		if err != nil {
+			return params, err
+		}
+		// Cursor selalu mengikuti urutan saat cursor dibuat
+		params.Cursor = cursor
+		params.Sort, params.Desc = cursor.Sort, cursor.Desc
+	}
+
+	return params, nil
+}
+
+// AnimeListResult adalah satu halaman hasil daftar anime
+type AnimeListResult struct {
+	Items      []models.AnimeListItem
+	Total      int64
+	NextCursor string
+}
+
+// filtered menerapkan semua filter (tanpa pagination) pada query animes
+func (p AnimeListParams) filtered(db *gorm.DB) *gorm.DB {
+	query := db.Table("animes").Joins(reviewStatsJoin).Joins(episodeStatsJoin)
+
+	for _, genre := range p.Genres {
+		// Anime harus punya semua genre yang diminta; nama maupun slug diterima
+		query = query.Where("EXISTS (SELECT 1 FROM anime_genres JOIN genres ON genres.id = anime_genres.genre_id WHERE anime_genres.anime_id = animes.id AND genres.slug = ?)", Slugify(genre))
+	}
+	if p.YearFrom != 0 {
+		query = query.Where(animeReleaseYearExpr+" >= ?", p.YearFrom)
+	}
+	if p.YearTo != 0 {
+		query = query.Where(animeReleaseYearExpr+" <= ?", p.YearTo)
+	}
+	if p.Status != "" {
+		query = query.Where("animes.airing_status = ?", p.Status)
+	}
+	if p.Season != "" {
+		query = query.Where("animes.season = ?", p.Season)
+	}
+	if p.SeasonYear != 0 {
+		query = query.Where("animes.season_year = ?", p.SeasonYear)
+	}
+	if p.MinRating != nil {
+		query = query.Where(animeRatingExpr+" >= ?", *p.MinRating)
+	}
+	return query
+}
+
+// ListAnime menjalankan query daftar anime sesuai parameter
+func ListAnime(db *gorm.DB, p AnimeListParams) (*AnimeListResult, error) {
+	result := &AnimeListResult{Items: []models.AnimeListItem{}}
+
+	if err := p.filtered(db).Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	column := animeSortColumns[p.Sort]
+	direction, cmp := "ASC", ">"
+	if p.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	query := p.filtered(db).
+		Select("animes.*, " + animeRatingExpr + " AS average_rating, " + animeReviewCountExpr + " AS review_count, " + animeEpisodeCountExpr + " AS episode_count")
+	if p.Cursor != nil {
+		query = query.Where(
+			fmt.Sprintf("(%s %s ?) OR (%s = ? AND animes.id %s ?)", column, cmp, column, cmp),
+			p.Cursor.Value, p.Cursor.Value, p.Cursor.ID,
+		)
+	} else if !p.Legacy {
+		query = query.Offset((p.Page - 1) * p.Limit)
+	}
+	query = query.Order(column + " " + direction).Order("animes.id " + direction)
+
+	// Klien lama menerima semua anime tanpa pagination, seperti sebelum ada parameter page/limit
+	if p.Legacy {
+		if err := query.Scan(&result.Items).Error; err != nil {
+			return nil, err
+		}
+		if err := attachGenres(db, result.Items); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	// Ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya
+	if err := query.Limit(p.Limit + 1).Scan(&result.Items).Error; err != nil {
+		return nil, err
+	}
+
+	if len(result.Items) > p.Limit {
+		result.Items = result.Items[:p.Limit]
+		last := result.Items[len(result.Items)-1]
+		result.NextCursor = AnimeCursor{Sort: p.Sort, Desc: p.Desc, Value: animeSortValue(last, p.Sort), ID: last.ID}.Encode()
+	}
+	if err := attachGenres(db, result.Items); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GenreFacets menghitung jumlah anime per genre di antara hasil yang cocok dengan filter
+func GenreFacets(db *gorm.DB, p AnimeListParams) ([]models.GenreCount, error) {
+	facets := []models.GenreCount{}
+	err := p.filtered(db).
+		Select("genres.*, COUNT(DISTINCT animes.id) AS anime_count").
+		Joins("JOIN anime_genres ON anime_genres.anime_id = animes.id").
+		Joins("JOIN genres ON genres.id = anime_genres.genre_id").
+		Group("genres.id").
+		Order("anime_count DESC, genres.name").
+		Scan(&facets).Error
+	return facets, err
+}
+
+// animeSortValue mengambil nilai kolom sort dari item untuk disimpan di cursor
+func animeSortValue(item models.AnimeListItem, sort string) string {
+	switch sort {
+	case "title":
+		return item.Title
+	case "rating":
+		return strconv.FormatFloat(item.AverageRating, 'f', -1, 64)
+	case "release_date", "start_date":
+		if !item.StartDate.Known() {
+			return unknownStartDate
+		}
+		return item.StartDate.Date.String()
+	case "review_count":
+		return strconv.FormatInt(item.ReviewCount, 10)
+	case "episode_count":
+		return strconv.FormatInt(item.EpisodeCount, 10)
+	}
+	return strconv.FormatUint(uint64(item.ID), 10)
+}
+
+// EscapeLike meng-escape karakter wildcard LIKE dari input pengguna
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// Scope dasar API key. Selain ini, nama permission (mis. anime:write) juga bisa dipakai
+// sebagai scope selama role pemilik kunci memilikinya.
+const (
+	ScopeRead  = "read"  // Request GET/HEAD
+	ScopeWrite = "write" // Request yang mengubah data
+)
+
+// MaxAPIKeysPerUser membatasi jumlah API key aktif per pengguna
+const MaxAPIKeysPerUser = 25
+
+const (
+	apiKeyPrefix        = "nya_"
+	apiKeyDisplayLength = 12
+	apiKeyTouchInterval = time.Minute
+	apiKeyAuthScheme    = "ApiKey "
+	maxAPIKeyNameLength = 100
+)
+
+var (
+	ErrAPIKeyInvalid      = errors.New("invalid or revoked API key")
+	ErrAPIKeyLimitReached = errors.New("API key limit reached")
+	ErrUnknownScope       = errors.New("unknown scope")
+)
+
+// APIKeyFromRequest mengambil API key dari header X-API-Key atau "Authorization: ApiKey ..."
+func APIKeyFromRequest(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, apiKeyAuthScheme) {
+		return strings.TrimSpace(strings.TrimPrefix(auth, apiKeyAuthScheme))
+	}
+	return ""
+}
+
+// ValidateScopes memastikan setiap scope dikenal dan, untuk scope permission, dimiliki pengguna
+func ValidateScopes(scopes []string, userPermissions []string) error {
+	owned := make(map[string]bool, len(userPermissions))
+	for _, p := range userPermissions {
+		owned[p] = true
+	}
+	for _, scope := range scopes {
+		if scope == ScopeRead || scope == ScopeWrite || owned[scope] {
+			continue
+		}
+		return fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+	}
+	return nil
+}
+
+// CreateAPIKey membuat API key baru. Kunci mentah hanya dikembalikan sekali ini.
+func CreateAPIKey(userID int, name string, scopes []string, expiresAt *time.Time, mfa bool) (string, *models.APIKey, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	raw := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "API key " + time.Now().Format("2006-01-02")
+	}
+	if len(name) > maxAPIKeyNameLength {
+		name = name[:maxAPIKeyNameLength]
+	}
+
+	key := models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:apiKeyDisplayLength],
+		KeyHash:   HashOpaqueToken(raw),
+		Scopes:    scopes,
+		MFA:       mfa,
+		ExpiresAt: expiresAt,
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= MaxAPIKeysPerUser {
+			return ErrAPIKeyLimitReached
+		}
+		return tx.Create(&key).Error
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return raw, &key, nil
+}
+
+// LookupAPIKey mencari API key aktif berdasarkan kunci mentah
+func LookupAPIKey(raw string) (*models.APIKey, error) {
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+	var key models.APIKey
+	if err := DB.Where("key_hash = ?", HashOpaqueToken(raw)).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyInvalid
+		}
+		return nil, err
+	}
+	if !key.Active() {
+		return nil, ErrAPIKeyInvalid
+	}
+	return &key, nil
+}
+
+// TouchAPIKey mencatat pemakaian terakhir, paling sering sekali per apiKeyTouchInterval
+func TouchAPIKey(key *models.APIKey, ip string) error {
+	if key.LastUsedAt != nil && time.Since(*key.LastUsedAt) < apiKeyTouchInterval && key.LastUsedIP == ip {
+		return nil
+	}
+	return DB.Model(&models.APIKey{}).
+		Where("id = ?", key.ID).
+		Updates(map[string]interface{}{"last_used_at": time.Now(), "last_used_ip": ip}).Error
+}
+
+// ListAPIKeys mengembalikan API key aktif milik pengguna
+func ListAPIKeys(userID int) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	err := DB.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey mencabut satu API key milik pengguna
+func RevokeAPIKey(userID int, keyID uint) error {
+	res := DB.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAPIKeyInvalid
+	}
+	return nil
+}
+
+// RevokeUserAPIKeys mencabut semua API key pengguna (dipakai saat akun mungkin disusupi)
+func RevokeUserAPIKeys(userID int) error {
+	return DB.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// apiKeyPermissions menghitung permission efektif: permission role yang juga ada di scope kunci
+func apiKeyPermissions(rolePermissions, scopes []string) []string {
+	granted := make(map[string]bool, len(scopes))
+	for _, s := range scopes {
+		granted[s] = true
+	}
+	perms := []string{}
+	for _, p := range rolePermissions {
+		if granted[p] {
+			perms = append(perms, p)
+		}
+	}
+	return perms
+}
+
+// apiKeyAllowsMethod mengecek scope read/write terhadap method HTTP
+func apiKeyAllowsMethod(scopes []string, method string) bool {
+	need := ScopeWrite
+	if method == http.MethodGet || method == http.MethodHead {
+		need = ScopeRead
+	}
+	for _, s := range scopes {
+		if s == need || (need == ScopeRead && s == ScopeWrite) {
+			return true
+		}
+	}
+	return false
+}
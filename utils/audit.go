@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"NYANIMEBACKEND/models"
+)
+
+// Aksi yang dicatat di audit log
+const (
+	AuditUserBan            = "user.ban"
+	AuditUserUnban          = "user.unban"
+	AuditUserSuspend        = "user.suspend"
+	AuditUserUnsuspend      = "user.unsuspend"
+	AuditUserRoleChange     = "user.role_change"
+	AuditUserPasswordReset  = "user.force_password_reset"
+	AuditLockoutClear       = "lockout.clear"
+	AuditRoleCreate         = "role.create"
+	AuditRoleUpdate         = "role.update"
+	AuditRoleDelete         = "role.delete"
+	AuditTargetUser         = "user"
+	AuditTargetRole         = "role"
+	AuditTargetLoginAttempt = "login_attempt"
+)
+
+// RecordAudit menyimpan satu entri audit untuk aktor pada request ini.
+// Kegagalan menulis audit hanya dicatat di log agar tindakan admin tetap berhasil.
+func RecordAudit(r *http.Request, action, targetType string, targetID interface{}, details map[string]interface{}) {
+	actorID, _ := r.Context().Value(UserIDKey).(int)
+
+	entry := models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprint(targetID),
+		IP:         ClientIP(r),
+	}
+	if len(details) > 0 {
+		data, err := json.Marshal(details)
+		if err != nil {
+			log.Println("Error encoding audit details:", err)
+		} else {
+			entry.Details = data
+		}
+	}
+
+	if err := DB.Create(&entry).Error; err != nil {
+		log.Printf("Error writing audit log (%s by %d): %v", action, actorID, err)
+	}
+}
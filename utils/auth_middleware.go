@@ -2,11 +2,13 @@ package utils
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
 
+	"NYANIMEBACKEND/models"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -15,10 +17,57 @@ type ContextKey string
 
 const UserIDKey ContextKey = "userID"
 const UserRoleKey ContextKey = "userRole"
+const UserMFAKey ContextKey = "userMFA"
+const SessionIDKey ContextKey = "sessionID"
+const UserPermissionsKey ContextKey = "userPermissions"
+const AuthMethodKey ContextKey = "authMethod"
+
+// Nilai AuthMethodKey
+const (
+	AuthMethodJWT    = "jwt"
+	AuthMethodAPIKey = "api_key"
+)
 
-// Middleware untuk autentikasi
+// Middleware untuk autentikasi, menerima JWT maupun API key
 func AuthMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, true)
+}
+
+// SessionAuthMiddleware hanya menerima JWT dari sesi login. Dipakai untuk endpoint yang
+// mengelola akun (password, 2FA, sesi, API key) agar API key tidak bisa mengambil alih akun.
+func SessionAuthMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, false)
+}
+
+// OptionalAuthMiddleware dipakai untuk endpoint publik yang menampilkan data tambahan bagi
+// user yang login. Request tanpa kredensial diteruskan sebagai anonim; kredensial yang
+// dikirim tetap harus valid.
+func OptionalAuthMiddleware(next http.Handler) http.Handler {
+	auth := authenticate(next, true)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" && APIKeyFromRequest(r) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		auth.ServeHTTP(w, r)
+	})
+}
+
+func authenticate(next http.Handler, allowAPIKey bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rawKey := APIKeyFromRequest(r); rawKey != "" {
+			if !allowAPIKey {
+				http.Error(w, "Forbidden: API keys cannot be used for this endpoint", http.StatusForbidden)
+				return
+			}
+			ctx, ok := apiKeyContext(w, r, rawKey)
+			if !ok {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
 			http.Error(w, "Authorization header required", http.StatusUnauthorized)
@@ -27,59 +76,116 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
 
+		// Verifikasi token
+		token, claims, err := VerifyToken(tokenString)
+		if err != nil || !token.Valid {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		if claims.MFAPending {
+			http.Error(w, "Two-factor authentication required", http.StatusUnauthorized)
+			return
+		}
+
 		if IsBlacklisted(tokenString) {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		// Verifikasi token
-		token, claims, err := VerifyToken(tokenString)
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+		// Token yang dibuat sebelum perubahan kredensial terakhir tidak berlaku lagi
+		var user models.User
+		if err := DB.Select("id", "token_version", "banned_at", "suspended_until").First(&user, claims.UserID).Error; err != nil || user.TokenVersion != claims.TokenVersion {
+			http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+			return
+		}
+
+		// Akun yang di-ban atau di-suspend tidak boleh mengakses API
+		if err := AccountRestriction(user, time.Now()); err != nil {
+			http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+			return
+		}
+
+		// Setiap token harus terikat pada sesi yang masih aktif
+		session, err := ActiveSession(claims.SessionID)
+		if err != nil || session.UserID != claims.UserID {
+			http.Error(w, "Session has been revoked", http.StatusUnauthorized)
 			return
 		}
+		if err := TouchSession(session, ClientIP(r)); err != nil {
+			log.Println("Error updating session last seen:", err)
+		}
 
 		// Simpan informasi user ke context
 		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+		ctx = context.WithValue(ctx, UserMFAKey, claims.MFA)
+		ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
+		ctx = context.WithValue(ctx, UserPermissionsKey, claims.Permissions)
+		ctx = context.WithValue(ctx, AuthMethodKey, AuthMethodJWT)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// apiKeyContext memverifikasi API key dan membangun context yang sama seperti JWT
+func apiKeyContext(w http.ResponseWriter, r *http.Request, rawKey string) (context.Context, bool) {
+	key, err := LookupAPIKey(rawKey)
+	if err != nil {
+		http.Error(w, "Invalid or revoked API key", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	var user models.User
+	if err := DB.Select("id", "role", "banned_at", "suspended_until").First(&user, key.UserID).Error; err != nil {
+		http.Error(w, "Invalid or revoked API key", http.StatusUnauthorized)
+		return nil, false
+	}
+	if err := AccountRestriction(user, time.Now()); err != nil {
+		http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+		return nil, false
+	}
+
+	if !apiKeyAllowsMethod(key.Scopes, r.Method) {
+		http.Error(w, "Forbidden: API key scope does not allow this request", http.StatusForbidden)
+		return nil, false
+	}
+
+	// Permission dihitung ulang setiap request sehingga perubahan role langsung berlaku
+	rolePerms, err := ResolvePermissions(user.Role)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if err := TouchAPIKey(key, ClientIP(r)); err != nil {
+		log.Println("Error updating API key last used:", err)
+	}
+
+	ctx := context.WithValue(r.Context(), UserIDKey, user.ID)
+	ctx = context.WithValue(ctx, UserRoleKey, user.Role)
+	ctx = context.WithValue(ctx, UserMFAKey, key.MFA)
+	ctx = context.WithValue(ctx, UserPermissionsKey, apiKeyPermissions(rolePerms, key.Scopes))
+	ctx = context.WithValue(ctx, AuthMethodKey, AuthMethodAPIKey)
+	return ctx, true
+}
+
 // VerifyToken memverifikasi JWT dan mengembalikan klaim
 func VerifyToken(tokenString string) (*jwt.Token, *CustomClaims, error) {
 	claims := &CustomClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte("G3n3r@t3dS3cr3tK3y!2024"), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, CurrentKeys().Keyfunc,
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}))
 	return token, claims, err
 }
 
 // CustomClaims untuk JWT
 type CustomClaims struct {
-	UserID int    `json:"user_id"`
-	Role   string `json:"role"`
+	UserID       int      `json:"user_id"`
+	Role         string   `json:"role"`
+	TokenVersion int      `json:"ver"`
+	SessionID    string   `json:"sid,omitempty"`
+	Permissions  []string `json:"perms,omitempty"`
+	MFA          bool     `json:"mfa,omitempty"`         // Login dilakukan dengan 2FA
+	MFAPending   bool     `json:"mfa_pending,omitempty"` // Password benar, kode 2FA belum diverifikasi
 	jwt.RegisteredClaims
 }
-
-// Blacklist untuk menyimpan token yang diblacklist
-var tokenBlacklist = struct {
-	sync.RWMutex
-	m map[string]time.Time
-}{m: make(map[string]time.Time)}
-
-// AddToBlacklist menambahkan token ke blacklist
-func AddToBlacklist(token string) {
-	tokenBlacklist.Lock()
-	defer tokenBlacklist.Unlock()
-	tokenBlacklist.m[token] = time.Now() // Menyimpan waktu saat token diblacklist
-}
-
-// IsBlacklisted memeriksa apakah token ada di blacklist
-func IsBlacklisted(token string) bool {
-	tokenBlacklist.RLock()
-	defer tokenBlacklist.RUnlock()
-	_, exists := tokenBlacklist.m[token]
-	return exists
-}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ClientIP mengembalikan alamat IP klien. Header X-Forwarded-For/X-Real-IP hanya dipercaya
+// jika TRUST_PROXY=true (server berada di belakang reverse proxy).
+func ClientIP(r *http.Request) string {
+	if trust, _ := strconv.ParseBool(os.Getenv("TRUST_PROXY")); trust {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return strings.TrimSpace(realIP)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -9,7 +9,8 @@ func SetupCORS() *cors.Cors {
 	return cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://127.0.0.1:5500"}, // Ganti dengan domain frontend Anda jika perlu
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-API-Key"},
+		ExposedHeaders:   []string{"Link", "X-Total-Count"}, // Header pagination GET /anime/
 		AllowCredentials: true,
 		Debug:            true,
 	})
@@ -0,0 +1,614 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrStudioNotFound    = errors.New("studio not found")
+	ErrStudioExists      = errors.New("studio already exists")
+	ErrPersonNotFound    = errors.New("person not found")
+	ErrCharacterNotFound = errors.New("character not found")
+	ErrUnknownCreditRef  = errors.New("unknown credit reference")
+)
+
+// Peran tokoh di sebuah anime
+const (
+	CharacterRoleMain       = "main"
+	CharacterRoleSupporting = "supporting"
+	CharacterRoleBackground = "background"
+)
+
+// DefaultVoiceLanguage dipakai jika bahasa pengisi suara tidak disebutkan
+const DefaultVoiceLanguage = "ja"
+
+// maxCreditNameLength sama dengan ukuran kolom nama di database
+const maxCreditNameLength = 128
+
+var languageTagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// StudioInput adalah data yang bisa diubah pada studio
+type StudioInput struct {
+	Name        string `json:"name"`
+	Website     string `json:"website"`
+	Description string `json:"description"`
+}
+
+// PersonInput adalah data yang bisa diubah pada staf/pengisi suara
+type PersonInput struct {
+	Name       string       `json:"name"`
+	NativeName string       `json:"native_name"`
+	Bio        string       `json:"bio"`
+	BirthDate  *models.Date `json:"birth_date"`
+}
+
+// CharacterInput adalah data yang bisa diubah pada tokoh
+type CharacterInput struct {
+	Name        string `json:"name"`
+	NativeName  string `json:"native_name"`
+	Description string `json:"description"`
+}
+
+// StudioLink adalah satu studio dan perannya di sebuah anime
+type StudioLink struct {
+	StudioID uint   `json:"studio_id"`
+	Role     string `json:"role"`
+}
+
+// StaffLink adalah satu staf dan perannya di sebuah anime
+type StaffLink struct {
+	PersonID uint   `json:"person_id"`
+	Role     string `json:"role"`
+}
+
+// CharacterLink adalah satu tokoh di sebuah anime beserta pengisi suaranya
+type CharacterLink struct {
+	CharacterID  uint   `json:"character_id"`
+	Role         string `json:"role"`
+	VoiceActorID *uint  `json:"voice_actor_id"`
+	Language     string `json:"language"`
+}
+
+// validateCreditName memeriksa nama wajib dan panjang maksimal
+func validateCreditName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if len([]rune(name)) > maxCreditNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxCreditNameLength)
+	}
+	return nil
+}
+
+func (in StudioInput) Validate() error    { return validateCreditName(in.Name) }
+func (in PersonInput) Validate() error    { return validateCreditName(in.Name) }
+func (in CharacterInput) Validate() error { return validateCreditName(in.Name) }
+
+// NormalizeCreditRole merapikan nama peran: "Series Composition" -> "series_composition"
+func NormalizeCreditRole(role string) string {
+	return strings.ReplaceAll(Slugify(role), "-", "_")
+}
+
+// findEntity mengambil baris berdasarkan ID, mengembalikan notFound jika tidak ada
+func findEntity(tx *gorm.DB, dest interface{}, id uint, notFound error) error {
+	err := tx.First(dest, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return err
+}
+
+// listNamed mengambil satu halaman entitas, difilter berdasarkan nama jika q tidak kosong
+func listNamed(model, dest interface{}, q string, page, limit int) (int64, error) {
+	query := DB.Model(model)
+	if q = strings.TrimSpace(q); q != "" {
+		query = query.Where("name LIKE ?", "%"+EscapeLike(q)+"%")
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, err
+	}
+	err := query.Order("name").Order("id").Offset((page - 1) * limit).Limit(limit).Find(dest).Error
+	return total, err
+}
+
+// ensureExists mengecek bahwa semua ID ada di tabel model
+func ensureExists(tx *gorm.DB, model interface{}, ids []uint, label string) error {
+	unique := make(map[uint]bool)
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	list := make([]uint, 0, len(unique))
+	for id := range unique {
+		list = append(list, id)
+	}
+	var count int64
+	if err := tx.Model(model).Where("id IN ?", list).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != int64(len(list)) {
+		return fmt.Errorf("%w: one or more %s IDs do not exist", ErrUnknownCreditRef, label)
+	}
+	return nil
+}
+
+// ListStudios mengambil daftar studio
+func ListStudios(q string, page, limit int) ([]models.Studio, int64, error) {
+	studios := []models.Studio{}
+	total, err := listNamed(&models.Studio{}, &studios, q, page, limit)
+	return studios, total, err
+}
+
+// GetStudio mengambil satu studio
+func GetStudio(id uint) (models.Studio, error) {
+	var studio models.Studio
+	err := findEntity(DB, &studio, id, ErrStudioNotFound)
+	return studio, err
+}
+
+// studioNameTaken mengecek nama studio yang sama selain studio excludeID
+func studioNameTaken(tx *gorm.DB, name string, excludeID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Studio{}).Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error
+	return count > 0, err
+}
+
+// CreateStudio membuat studio baru
+func CreateStudio(in StudioInput) (models.Studio, error) {
+	studio := models.Studio{Name: strings.TrimSpace(in.Name), Website: strings.TrimSpace(in.Website), Description: in.Description}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		taken, err := studioNameTaken(tx, studio.Name, 0)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrStudioExists
+		}
+		return tx.Create(&studio).Error
+	})
+	return studio, err
+}
+
+// UpdateStudio mengganti data studio
+func UpdateStudio(id uint, in StudioInput) (models.Studio, error) {
+	var studio models.Studio
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := findEntity(tx, &studio, id, ErrStudioNotFound); err != nil {
+			return err
+		}
+		name := strings.TrimSpace(in.Name)
+		taken, err := studioNameTaken(tx, name, id)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrStudioExists
+		}
+		studio.Name, studio.Website, studio.Description = name, strings.TrimSpace(in.Website), in.Description
+		return tx.Save(&studio).Error
+	})
+	return studio, err
+}
+
+// DeleteStudio menghapus studio beserta kreditnya di semua anime
+func DeleteStudio(id uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var studio models.Studio
+		if err := findEntity(tx, &studio, id, ErrStudioNotFound); err != nil {
+			return err
+		}
+		if err := tx.Where("studio_id = ?", id).Delete(&models.AnimeStudio{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&studio).Error
+	})
+}
+
+// ListPeople mengambil daftar staf/pengisi suara
+func ListPeople(q string, page, limit int) ([]models.Person, int64, error) {
+	people := []models.Person{}
+	total, err := listNamed(&models.Person{}, &people, q, page, limit)
+	return people, total, err
+}
+
+// GetPerson mengambil satu staf/pengisi suara
+func GetPerson(id uint) (models.Person, error) {
+	var person models.Person
+	err := findEntity(DB, &person, id, ErrPersonNotFound)
+	return person, err
+}
+
+// CreatePerson membuat data staf/pengisi suara baru
+func CreatePerson(in PersonInput) (models.Person, error) {
+	person := models.Person{Name: strings.TrimSpace(in.Name), NativeName: strings.TrimSpace(in.NativeName), Bio: in.Bio, BirthDate: in.BirthDate}
+	err := DB.Create(&person).Error
+	return person, err
+}
+
+// UpdatePerson mengganti data staf/pengisi suara
+func UpdatePerson(id uint, in PersonInput) (models.Person, error) {
+	var person models.Person
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := findEntity(tx, &person, id, ErrPersonNotFound); err != nil {
+			return err
+		}
+		person.Name, person.NativeName = strings.TrimSpace(in.Name), strings.TrimSpace(in.NativeName)
+		person.Bio, person.BirthDate = in.Bio, in.BirthDate
+		return tx.Save(&person).Error
+	})
+	return person, err
+}
+
+// DeletePerson menghapus staf/pengisi suara. Kredit stafnya dihapus, sedangkan tokoh
+// yang disuarakannya tetap ada tanpa pengisi suara.
+func DeletePerson(id uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var person models.Person
+		if err := findEntity(tx, &person, id, ErrPersonNotFound); err != nil {
+			return err
+		}
+		if err := tx.Where("person_id = ?", id).Delete(&models.AnimeStaff{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.AnimeCharacter{}).Where("voice_actor_id = ?", id).Update("voice_actor_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&person).Error
+	})
+}
+
+// ListCharacters mengambil daftar tokoh
+func ListCharacters(q string, page, limit int) ([]models.Character, int64, error) {
+	characters := []models.Character{}
+	total, err := listNamed(&models.Character{}, &characters, q, page, limit)
+	return characters, total, err
+}
+
+// GetCharacter mengambil satu tokoh
+func GetCharacter(id uint) (models.Character, error) {
+	var character models.Character
+	err := findEntity(DB, &character, id, ErrCharacterNotFound)
+	return character, err
+}
+
+// CreateCharacter membuat tokoh baru
+func CreateCharacter(in CharacterInput) (models.Character, error) {
+	character := models.Character{Name: strings.TrimSpace(in.Name), NativeName: strings.TrimSpace(in.NativeName), Description: in.Description}
+	err := DB.Create(&character).Error
+	return character, err
+}
+
+// UpdateCharacter mengganti data tokoh
+func UpdateCharacter(id uint, in CharacterInput) (models.Character, error) {
+	var character models.Character
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := findEntity(tx, &character, id, ErrCharacterNotFound); err != nil {
+			return err
+		}
+		character.Name, character.NativeName = strings.TrimSpace(in.Name), strings.TrimSpace(in.NativeName)
+		character.Description = in.Description
+		return tx.Save(&character).Error
+	})
+	return character, err
+}
+
+// DeleteCharacter menghapus tokoh dari semua anime
+func DeleteCharacter(id uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var character models.Character
+		if err := findEntity(tx, &character, id, ErrCharacterNotFound); err != nil {
+			return err
+		}
+		if err := tx.Where("character_id = ?", id).Delete(&models.AnimeCharacter{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&character).Error
+	})
+}
+
+// NormalizeStudioLinks merapikan peran dan membuang duplikat
+func NormalizeStudioLinks(links []StudioLink) ([]StudioLink, error) {
+	seen := make(map[StudioLink]bool)
+	result := []StudioLink{}
+	for _, link := range links {
+		link.Role = NormalizeCreditRole(link.Role)
+		if link.StudioID == 0 || link.Role == "" {
+			return nil, errors.New("each studio needs studio_id and role")
+		}
+		if !seen[link] {
+			seen[link] = true
+			result = append(result, link)
+		}
+	}
+	return result, nil
+}
+
+// NormalizeStaffLinks merapikan peran dan membuang duplikat
+func NormalizeStaffLinks(links []StaffLink) ([]StaffLink, error) {
+	seen := make(map[StaffLink]bool)
+	result := []StaffLink{}
+	for _, link := range links {
+		link.Role = NormalizeCreditRole(link.Role)
+		if link.PersonID == 0 || link.Role == "" {
+			return nil, errors.New("each staff entry needs person_id and role")
+		}
+		if !seen[link] {
+			seen[link] = true
+			result = append(result, link)
+		}
+	}
+	return result, nil
+}
+
+// NormalizeCharacterLinks memvalidasi peran dan bahasa. Satu tokoh hanya boleh muncul
+// sekali per bahasa.
+func NormalizeCharacterLinks(links []CharacterLink) ([]CharacterLink, error) {
+	type key struct {
+		characterID uint
+		language    string
+	}
+	seen := make(map[key]bool)
+	result := []CharacterLink{}
+	for _, link := range links {
+		if link.CharacterID == 0 {
+			return nil, errors.New("each character entry needs character_id")
+		}
+		link.Role = strings.ToLower(strings.TrimSpace(link.Role))
+		if link.Role == "" {
+			link.Role = CharacterRoleSupporting
+		}
+		switch link.Role {
+		case CharacterRoleMain, CharacterRoleSupporting, CharacterRoleBackground:
+		default:
+			return nil, fmt.Errorf("character role must be %s, %s or %s", CharacterRoleMain, CharacterRoleSupporting, CharacterRoleBackground)
+		}
+		link.Language = strings.ToLower(strings.TrimSpace(link.Language))
+		if link.Language == "" {
+			link.Language = DefaultVoiceLanguage
+		}
+		if !languageTagPattern.MatchString(link.Language) {
+			return nil, fmt.Errorf("invalid language %q", link.Language)
+		}
+		k := key{link.CharacterID, link.Language}
+		if seen[k] {
+			return nil, fmt.Errorf("character %d is listed twice for language %s", link.CharacterID, link.Language)
+		}
+		seen[k] = true
+		result = append(result, link)
+	}
+	return result, nil
+}
+
+// SetAnimeStudios mengganti daftar studio sebuah anime
+func SetAnimeStudios(animeID uint, links []StudioLink) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureAnime(tx, animeID); err != nil {
+			return err
+		}
+		ids := make([]uint, len(links))
+		rows := make([]models.AnimeStudio, len(links))
+		for i, link := range links {
+			ids[i] = link.StudioID
+			rows[i] = models.AnimeStudio{AnimeID: animeID, StudioID: link.StudioID, Role: link.Role}
+		}
+		if err := ensureExists(tx, &models.Studio{}, ids, "studio"); err != nil {
+			return err
+		}
+		if err := tx.Where("anime_id = ?", animeID).Delete(&models.AnimeStudio{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// SetAnimeStaff mengganti daftar staf sebuah anime
+func SetAnimeStaff(animeID uint, links []StaffLink) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureAnime(tx, animeID); err != nil {
+			return err
+		}
+		ids := make([]uint, len(links))
+		rows := make([]models.AnimeStaff, len(links))
+		for i, link := range links {
+			ids[i] = link.PersonID
+			rows[i] = models.AnimeStaff{AnimeID: animeID, PersonID: link.PersonID, Role: link.Role}
+		}
+		if err := ensureExists(tx, &models.Person{}, ids, "person"); err != nil {
+			return err
+		}
+		if err := tx.Where("anime_id = ?", animeID).Delete(&models.AnimeStaff{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// SetAnimeCharacters mengganti daftar tokoh dan pengisi suara sebuah anime
+func SetAnimeCharacters(animeID uint, links []CharacterLink) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureAnime(tx, animeID); err != nil {
+			return err
+		}
+		var characterIDs, personIDs []uint
+		rows := make([]models.AnimeCharacter, len(links))
+		for i, link := range links {
+			characterIDs = append(characterIDs, link.CharacterID)
+			if link.VoiceActorID != nil {
+				personIDs = append(personIDs, *link.VoiceActorID)
+			}
+			rows[i] = models.AnimeCharacter{AnimeID: animeID, CharacterID: link.CharacterID, Role: link.Role, VoiceActorID: link.VoiceActorID, Language: link.Language}
+		}
+		if err := ensureExists(tx, &models.Character{}, characterIDs, "character"); err != nil {
+			return err
+		}
+		if err := ensureExists(tx, &models.Person{}, personIDs, "voice actor"); err != nil {
+			return err
+		}
+		if err := tx.Where("anime_id = ?", animeID).Delete(&models.AnimeCharacter{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+}
+
+// characterRoleOrder mengurutkan tokoh utama lebih dulu
+const characterRoleOrder = "CASE anime_characters.role WHEN 'main' THEN 0 WHEN 'supporting' THEN 1 ELSE 2 END"
+
+// animeStudioCredits mengambil studio sebuah anime beserta perannya
+func animeStudioCredits(db *gorm.DB, animeID uint) ([]models.StudioCredit, error) {
+	studios := []models.StudioCredit{}
+	err := db.Table("anime_studios").
+		Select("studios.*, anime_studios.role").
+		Joins("JOIN studios ON studios.id = anime_studios.studio_id").
+		Where("anime_studios.anime_id = ?", animeID).
+		Order("anime_studios.role, studios.name").
+		Scan(&studios).Error
+	return studios, err
+}
+
+// GetAnimeCredits mengambil studio, staf dan tokoh sebuah anime
+func GetAnimeCredits(db *gorm.DB, animeID uint) (*models.AnimeCredits, error) {
+	if err := ensureAnime(db, animeID); err != nil {
+		return nil, err
+	}
+	credits := &models.AnimeCredits{
+		Staff:      []models.StaffCredit{},
+		Characters: []models.CharacterCredit{},
+	}
+
+	var err error
+	if credits.Studios, err = animeStudioCredits(db, animeID); err != nil {
+		return nil, err
+	}
+
+	if err := db.Table("anime_staff").
+		Select("people.*, anime_staff.role").
+		Joins("JOIN people ON people.id = anime_staff.person_id").
+		Where("anime_staff.anime_id = ?", animeID).
+		Order("anime_staff.role, people.name").
+		Scan(&credits.Staff).Error; err != nil {
+		return nil, err
+	}
+
+	var links []models.AnimeCharacter
+	if err := db.Where("anime_id = ?", animeID).
+		Order(characterRoleOrder).
+		Order("language, id").
+		Find(&links).Error; err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return credits, nil
+	}
+
+	var characterIDs, personIDs []uint
+	for _, link := range links {
+		characterIDs = append(characterIDs, link.CharacterID)
+		if link.VoiceActorID != nil {
+			personIDs = append(personIDs, *link.VoiceActorID)
+		}
+	}
+	var characters []models.Character
+	if err := db.Where("id IN ?", characterIDs).Find(&characters).Error; err != nil {
+		return nil, err
+	}
+	charactersByID := make(map[uint]models.Character, len(characters))
+	for _, c := range characters {
+		charactersByID[c.ID] = c
+	}
+	peopleByID := make(map[uint]models.Person)
+	if len(personIDs) > 0 {
+		var people []models.Person
+		if err := db.Where("id IN ?", personIDs).Find(&people).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range people {
+			peopleByID[p.ID] = p
+		}
+	}
+
+	for _, link := range links {
+		credit := models.CharacterCredit{Character: charactersByID[link.CharacterID], Role: link.Role, Language: link.Language}
+		if link.VoiceActorID != nil {
+			if person, ok := peopleByID[*link.VoiceActorID]; ok {
+				credit.VoiceActor = &person
+			}
+		}
+		credits.Characters = append(credits.Characters, credit)
+	}
+	return credits, nil
+}
+
+// GetStudioAnime mengambil anime yang dikerjakan sebuah studio
+func GetStudioAnime(studioID uint) ([]models.StudioAnime, error) {
+	if _, err := GetStudio(studioID); err != nil {
+		return nil, err
+	}
+	animes := []models.StudioAnime{}
+	err := DB.Table("anime_studios").
+		Select("animes.*, anime_studios.role").
+		Joins("JOIN animes ON animes.id = anime_studios.anime_id").
+		Where("anime_studios.studio_id = ?", studioID).
+		Order("animes.start_date DESC, animes.id").
+		Scan(&animes).Error
+	return animes, err
+}
+
+// GetPersonCredits mengambil semua peran staf dan pengisi suara seseorang
+func GetPersonCredits(personID uint) (*models.PersonCredits, error) {
+	person, err := GetPerson(personID)
+	if err != nil {
+		return nil, err
+	}
+	credits := &models.PersonCredits{Person: person, Staff: []models.PersonStaffCredit{}, Voices: []models.PersonVoiceCredit{}}
+
+	if err := DB.Table("anime_staff").
+		Select("animes.id AS anime_id, animes.title AS anime_title, anime_staff.role").
+		Joins("JOIN animes ON animes.id = anime_staff.anime_id").
+		Where("anime_staff.person_id = ?", personID).
+		Order("animes.start_date DESC, animes.id, anime_staff.role").
+		Scan(&credits.Staff).Error; err != nil {
+		return nil, err
+	}
+
+	if err := DB.Table("anime_characters").
+		Select("animes.id AS anime_id, animes.title AS anime_title, characters.id AS character_id, characters.name AS character_name, anime_characters.role, anime_characters.language").
+		Joins("JOIN animes ON animes.id = anime_characters.anime_id").
+		Joins("JOIN characters ON characters.id = anime_characters.character_id").
+		Where("anime_characters.voice_actor_id = ?", personID).
+		Order("animes.start_date DESC, animes.id, characters.name").
+		Scan(&credits.Voices).Error; err != nil {
+		return nil, err
+	}
+	return credits, nil
+}
+
+// DeleteAnimeCredits menghapus semua kredit sebuah anime, dipakai saat anime dihapus
+func DeleteAnimeCredits(tx *gorm.DB, animeID uint) error {
+	for _, model := range []interface{}{&models.AnimeStudio{}, &models.AnimeStaff{}, &models.AnimeCharacter{}} {
+		if err := tx.Where("anime_id = ?", animeID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
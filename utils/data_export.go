@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"NYANIMEBACKEND/models"
+)
+
+// ExportProfile adalah data profil di arsip ekspor (tanpa hash password dan secret 2FA)
+type ExportProfile struct {
+	ID                  int        `json:"id"`
+	Username            string     `json:"username"`
+	Email               string     `json:"email"`
+	Role                string     `json:"role"`
+	Bio                 string     `json:"bio"`
+	VerifiedAt          *time.Time `json:"verified_at"`
+	TOTPEnabled         bool       `json:"totp_enabled"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+}
+
+// ExportReview adalah satu review beserta judul anime-nya
+type ExportReview struct {
+	ID         int       `json:"id"`
+	AnimeID    uint      `json:"anime_id"`
+	AnimeTitle string    `json:"anime_title"`
+	Rating     int64     `json:"rating"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UserExport adalah semua data yang kita simpan tentang satu pengguna
+type UserExport struct {
+	ExportedAt time.Time               `json:"exported_at"`
+	Profile    ExportProfile           `json:"profile"`
+	Reviews    []ExportReview          `json:"reviews"`
+	Favorites  []models.Favorite       `json:"favorites"`
+	Sessions   []models.Session        `json:"sessions"`
+	Identities []models.LinkedIdentity `json:"linked_identities"`
+	APIKeys    []models.APIKey         `json:"api_keys"`
+}
+
+// BuildUserExport mengumpulkan data pribadi pengguna untuk diunduh
+func BuildUserExport(userID int) (*UserExport, error) {
+	var user models.User
+	if err := DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	export := &UserExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: ExportProfile{
+			ID:                  user.ID,
+			Username:            user.Username,
+			Email:               user.Email,
+			Role:                user.Role,
+			Bio:                 user.Bio,
+			VerifiedAt:          user.VerifiedAt,
+			TOTPEnabled:         user.TOTPEnabled,
+			DeletionScheduledAt: user.DeletionScheduledAt,
+		},
+		Reviews:    []ExportReview{},
+		Favorites:  []models.Favorite{},
+		Sessions:   []models.Session{},
+		Identities: []models.LinkedIdentity{},
+		APIKeys:    []models.APIKey{},
+	}
+
+	if err := DB.Table("reviews_new AS r").
+		Select("r.id, r.anime_id, a.title AS anime_title, r.rating, r.content, r.created_at").
+		Joins("LEFT JOIN animes a ON a.id = r.anime_id").
+		Where("r.user_id = ?", userID).
+		Order("r.created_at").
+		Scan(&export.Reviews).Error; err != nil {
+		return nil, err
+	}
+	if err := DB.Where("user_id = ?", userID).Order("created_at").Find(&export.Favorites).Error; err != nil {
+		return nil, err
+	}
+	if err := DB.Where("user_id = ?", userID).Order("created_at").Find(&export.Sessions).Error; err != nil {
+		return nil, err
+	}
+	if err := DB.Where("user_id = ?", userID).Order("created_at").Find(&export.Identities).Error; err != nil {
+		return nil, err
+	}
+	if err := DB.Where("user_id = ?", userID).Order("created_at").Find(&export.APIKeys).Error; err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// WriteUserExportZip menulis ekspor sebagai arsip ZIP berisi satu file JSON per bagian
+func WriteUserExportZip(w io.Writer, export *UserExport) error {
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"reviews.json", export.Reviews},
+		{"favorites.json", export.Favorites},
+		{"sessions.json", export.Sessions},
+		{"linked_identities.json", export.Identities},
+		{"api_keys.json", export.APIKeys},
+	}
+
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv" // Import godotenv
 	"gorm.io/driver/mysql"
@@ -55,6 +56,23 @@ func InitDB() {
 
 	// Opsional: Auto-migrate model
 	autoMigrateModels()
+
+	// Simpan pencabutan token di database agar berlaku lintas replika dan restart
+	Revocations = NewDBRevocationStore(DB)
+	StartRevocationPurger(time.Hour)
+
+	// Catatan login gagal juga disimpan di database agar lockout berlaku di semua replika
+	LoginAttempts = NewDBLoginAttemptTracker(DB)
+	StartLoginAttemptPurger(time.Hour)
+
+	// Hapus akun yang masa tenggang penghapusannya sudah habis
+	StartAccountDeletionWorker(time.Hour)
+
+	// Status tayang anime maju sendiri saat tanggal mulai/selesai terlewati
+	StartAiringStatusWorker(time.Hour)
+
+	// Index pencarian anime
+	InitSearch()
 }
 
 func loadEnv(file string) {
@@ -65,12 +83,59 @@ func loadEnv(file string) {
 }
 
 func autoMigrateModels() {
+	// Dicek sebelum AutoMigrate menambahkan kolomnya
+	backfillVerified := VerifiedAtColumnMissing(DB)
+
 	err := DB.AutoMigrate(
 		&models.User{},
+		&models.Genre{},
 		&models.Anime{},
+		&models.AnimeTitle{},
+		&models.AnimeImage{},
+		&models.Season{},
+		&models.Episode{},
+		&models.Studio{},
+		&models.Person{},
+		&models.Character{},
+		&models.AnimeStudio{},
+		&models.AnimeStaff{},
+		&models.AnimeCharacter{},
+		&models.AnimeRelation{},
+		&models.Franchise{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.PasswordResetToken{},
+		&models.EmailVerificationToken{},
+		&models.LoginAttempt{},
+		&models.RecoveryCode{},
+		&models.Session{},
+		&models.Permission{},
+		&models.Role{},
+		&models.AuditLog{},
+		&models.APIKey{},
+		&models.LinkedIdentity{},
+		&models.OAuthState{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to auto-migrate models: %v", err)
 	}
+	if backfillVerified {
+		if err := MarkExistingUsersVerified(DB); err != nil {
+			log.Fatalf("Failed to mark existing users as verified: %v", err)
+		}
+		log.Println("Existing users marked as verified")
+	}
+	if err := SeedRolesAndPermissions(DB); err != nil {
+		log.Fatalf("Failed to seed roles and permissions: %v", err)
+	}
+	if err := MigrateLegacyGenres(DB); err != nil {
+		log.Fatalf("Failed to migrate anime genres: %v", err)
+	}
+	if err := MigrateReleaseDates(DB); err != nil {
+		log.Fatalf("Failed to migrate anime release dates: %v", err)
+	}
+	if err := MigrateSpinOffInverses(DB); err != nil {
+		log.Fatalf("Failed to migrate anime relations: %v", err)
+	}
 	log.Println("Database models migrated successfully!")
 }
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// EmailVerificationTTL adalah masa berlaku link verifikasi email
+const EmailVerificationTTL = 24 * time.Hour
+
+var (
+	ErrVerificationTokenInvalid = errors.New("invalid or expired verification token")
+	ErrEmailTaken               = errors.New("email already registered")
+)
+
+// RequireVerifiedEmail mengecek REQUIRE_EMAIL_VERIFICATION; jika true, akun yang belum
+// terverifikasi tidak boleh menulis review atau menambah favorit
+func RequireVerifiedEmail() bool {
+	v, _ := strconv.ParseBool(os.Getenv("REQUIRE_EMAIL_VERIFICATION"))
+	return v
+}
+
+// VerificationResendCooldown adalah jeda minimum antar pengiriman email verifikasi
+// (EMAIL_VERIFICATION_COOLDOWN, default 60s)
+func VerificationResendCooldown() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("EMAIL_VERIFICATION_COOLDOWN")); err == nil {
+		return d
+	}
+	return time.Minute
+}
+
+// VerifiedAtColumnMissing mengecek apakah tabel users sudah ada tapi belum punya kolom verified_at,
+// artinya database berasal dari versi sebelum verifikasi email. Harus dipanggil sebelum AutoMigrate.
+func VerifiedAtColumnMissing(db *gorm.DB) bool {
+	migrator := db.Migrator()
+	return migrator.HasTable(&models.User{}) && !migrator.HasColumn(&models.User{}, "VerifiedAt")
+}
+
+// MarkExistingUsersVerified menandai semua akun lama sebagai terverifikasi. Dijalankan sekali,
+// tepat setelah kolom verified_at ditambahkan, agar akun yang mendaftar sebelum verifikasi email
+// diberlakukan tidak terkunci oleh REQUIRE_EMAIL_VERIFICATION.
+func MarkExistingUsersVerified(db *gorm.DB) error {
+	return db.Model(&models.User{}).Where("verified_at IS NULL").Update("verified_at", time.Now()).Error
+}
+
+// SendVerificationEmail membuat token verifikasi untuk alamat email dan mengirimkannya.
+// Token lama untuk pengguna yang sama dibatalkan.
+func SendVerificationEmail(user models.User, email string) error {
+	raw, hash, err := NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailVerificationToken{
+			UserID:    user.ID,
+			Email:     email,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(EmailVerificationTTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/user/verify?token=%s", PublicURL(), url.QueryEscape(raw))
+	return GetMailer().Send(Mail{
+		To:      email,
+		Subject: "Verify your Nyanime email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm that this is your email address by opening the link below:\n\n%s\n\n"+
+			"The link expires in %d hours.\n", user.Username, link, int(EmailVerificationTTL.Hours())),
+	})
+}
+
+// VerificationCooldownRemaining mengembalikan sisa waktu sebelum email verifikasi boleh dikirim ulang
+func VerificationCooldownRemaining(userID int) (time.Duration, error) {
+	var last models.EmailVerificationToken
+	err := DB.Where("user_id = ?", userID).Order("created_at DESC").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	remaining := time.Until(last.CreatedAt.Add(VerificationResendCooldown()))
+	if remaining < 0 {
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+// VerifyEmailToken memakai token verifikasi dan menandai email pengguna sebagai terverifikasi.
+// Jika token dibuat untuk alamat baru (ganti email), email pengguna diganti dan semua token lamanya dicabut.
+func VerifyEmailToken(raw string) (*models.User, error) {
+	var user models.User
+	emailChanged := false
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var token models.EmailVerificationToken
+		if err := tx.Where("token_hash = ?", HashOpaqueToken(raw)).First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVerificationTokenInvalid
+			}
+			return err
+		}
+		if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+			return ErrVerificationTokenInvalid
+		}
+
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			return err
+		}
+		if token.Email != user.Email {
+			// Alamat bisa saja sudah dipakai akun lain sejak permintaan ganti email dibuat
+			var count int64
+			if err := tx.Model(&models.User{}).Where("email = ? AND id <> ?", token.Email, user.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrEmailTaken
+			}
+			emailChanged = true
+		}
+
+		res := tx.Model(&models.EmailVerificationToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrVerificationTokenInvalid
+		}
+
+		now := time.Now()
+		user.VerifiedAt = &now
+		user.Email = token.Email
+		return tx.Model(&user).Updates(map[string]interface{}{"verified_at": now, "email": token.Email}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if emailChanged {
+		if err := InvalidateUserTokens(user.ID, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &user, nil
+}
+
+// VerifiedEmailMiddleware menolak akun yang belum verifikasi email jika kebijakan diaktifkan.
+// Harus dipasang setelah AuthMiddleware.
+func VerifiedEmailMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || !RequireVerifiedEmail() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, ok := r.Context().Value(UserIDKey).(int)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		if err := DB.Select("id", "verified_at").First(&user, userID).Error; err != nil {
+			http.Error(w, "User not found", http.StatusUnauthorized)
+			return
+		}
+		if user.VerifiedAt == nil {
+			http.Error(w, "Forbidden: Email verification required", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
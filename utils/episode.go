@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAnimeNotFound   = errors.New("anime not found")
+	ErrSeasonNotFound  = errors.New("season not found")
+	ErrSeasonExists    = errors.New("season number already exists for this anime")
+	ErrEpisodeNotFound = errors.New("episode not found")
+	ErrEpisodeExists   = errors.New("episode number already exists in this season")
+)
+
+// MaxEpisodeDurationMinutes membatasi durasi satu episode (film panjang masih muat)
+const MaxEpisodeDurationMinutes = 600
+
+// SeasonInput adalah data yang bisa diubah pada season
+type SeasonInput struct {
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	Synopsis string `json:"synopsis"`
+}
+
+// EpisodeInput adalah data yang bisa diubah pada episode
+type EpisodeInput struct {
+	SeasonID        *uint        `json:"season_id"`
+	Number          int          `json:"number"`
+	Title           string       `json:"title"`
+	AirDate         *models.Date `json:"air_date"`
+	DurationMinutes int          `json:"duration_minutes"`
+	Synopsis        string       `json:"synopsis"`
+}
+
+// Validate memeriksa nilai season
+func (in SeasonInput) Validate() error {
+	if in.Number < 1 {
+		return errors.New("season number must be a positive integer")
+	}
+	return nil
+}
+
+// Validate memeriksa nilai episode
+func (in EpisodeInput) Validate() error {
+	if in.Number < 1 {
+		return errors.New("episode number must be a positive integer")
+	}
+	if in.DurationMinutes < 0 || in.DurationMinutes > MaxEpisodeDurationMinutes {
+		return errors.New("duration_minutes must be between 0 and 600")
+	}
+	if in.AirDate != nil && in.AirDate.Year() < 1900 {
+		return errors.New("air_date must not be before 1900")
+	}
+	return nil
+}
+
+// ensureAnime mengecek bahwa anime ada
+func ensureAnime(tx *gorm.DB, animeID uint) error {
+	var count int64
+	if err := tx.Model(&models.Anime{}).Where("id = ?", animeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrAnimeNotFound
+	}
+	return nil
+}
+
+// ListSeasons mengambil season sebuah anime beserta episodenya, urut nomor
+func ListSeasons(animeID uint) ([]models.Season, error) {
+	if err := ensureAnime(DB, animeID); err != nil {
+		return nil, err
+	}
+	seasons := []models.Season{}
+	err := DB.Preload("Episodes", func(db *gorm.DB) *gorm.DB { return db.Order("number") }).
+		Where("anime_id = ?", animeID).
+		Order("number").
+		Find(&seasons).Error
+	return seasons, err
+}
+
+// findSeason mengambil season milik anime tertentu
+func findSeason(tx *gorm.DB, animeID, seasonID uint) (models.Season, error) {
+	var season models.Season
+	err := tx.Where("id = ? AND anime_id = ?", seasonID, animeID).First(&season).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return season, ErrSeasonNotFound
+	}
+	return season, err
+}
+
+// seasonNumberTaken mengecek nomor season yang sama pada anime, selain season excludeID
+func seasonNumberTaken(tx *gorm.DB, animeID uint, number int, excludeID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Season{}).
+		Where("anime_id = ? AND number = ? AND id <> ?", animeID, number, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateSeason menambahkan season baru pada anime
+func CreateSeason(animeID uint, in SeasonInput) (models.Season, error) {
+	season := models.Season{AnimeID: animeID, Number: in.Number, Title: strings.TrimSpace(in.Title), Synopsis: in.Synopsis}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureAnime(tx, animeID); err != nil {
+			return err
+		}
+		taken, err := seasonNumberTaken(tx, animeID, in.Number, 0)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrSeasonExists
+		}
+		return tx.Create(&season).Error
+	})
+	return season, err
+}
+
+// UpdateSeason mengganti data season
+func UpdateSeason(animeID, seasonID uint, in SeasonInput) (models.Season, error) {
+	var season models.Season
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if season, err = findSeason(tx, animeID, seasonID); err != nil {
+			return err
+		}
+		taken, err := seasonNumberTaken(tx, animeID, in.Number, seasonID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrSeasonExists
+		}
+		season.Number, season.Title, season.Synopsis = in.Number, strings.TrimSpace(in.Title), in.Synopsis
+		return tx.Save(&season).Error
+	})
+	return season, err
+}
+
+// DeleteSeason menghapus season beserta episodenya
+func DeleteSeason(animeID, seasonID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		season, err := findSeason(tx, animeID, seasonID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("season_id = ?", season.ID).Delete(&models.Episode{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&season).Error
+	})
+}
+
+// ListEpisodes mengambil episode sebuah anime, urut season lalu nomor episode.
+// seasonID tidak nil membatasi hasil pada satu season.
+func ListEpisodes(animeID uint, seasonID *uint) ([]models.Episode, error) {
+	if err := ensureAnime(DB, animeID); err != nil {
+		return nil, err
+	}
+	query := DB.Model(&models.Episode{}).
+		Select("episodes.*").
+		Joins("LEFT JOIN seasons ON seasons.id = episodes.season_id").
+		Where("episodes.anime_id = ?", animeID)
+	if seasonID != nil {
+		query = query.Where("episodes.season_id = ?", *seasonID)
+	}
+	episodes := []models.Episode{}
+	err := query.Order("COALESCE(seasons.number, 0), episodes.number").Find(&episodes).Error
+	return episodes, err
+}
+
+// GetEpisode mengambil satu episode milik anime
+func GetEpisode(animeID, episodeID uint) (models.Episode, error) {
+	return findEpisode(DB, animeID, episodeID)
+}
+
+func findEpisode(tx *gorm.DB, animeID, episodeID uint) (models.Episode, error) {
+	var episode models.Episode
+	err := tx.Where("id = ? AND anime_id = ?", episodeID, animeID).First(&episode).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return episode, ErrEpisodeNotFound
+	}
+	return episode, err
+}
+
+// checkEpisodeSlot memastikan season milik anime yang sama dan nomor episode belum dipakai
+func checkEpisodeSlot(tx *gorm.DB, animeID uint, in EpisodeInput, excludeID uint) error {
+	query := tx.Model(&models.Episode{}).Where("anime_id = ? AND number = ? AND id <> ?", animeID, in.Number, excludeID)
+	if in.SeasonID != nil {
+		if _, err := findSeason(tx, animeID, *in.SeasonID); err != nil {
+			return err
+		}
+		query = query.Where("season_id = ?", *in.SeasonID)
+	} else {
+		query = query.Where("season_id IS NULL")
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEpisodeExists
+	}
+	return nil
+}
+
+// applyEpisodeInput menyalin input ke model episode
+func applyEpisodeInput(episode *models.Episode, in EpisodeInput) {
+	episode.SeasonID = in.SeasonID
+	episode.Number = in.Number
+	episode.Title = strings.TrimSpace(in.Title)
+	episode.AirDate = in.AirDate
+	episode.DurationMinutes = in.DurationMinutes
+	episode.Synopsis = in.Synopsis
+}
+
+// CreateEpisode menambahkan episode pada anime
+func CreateEpisode(animeID uint, in EpisodeInput) (models.Episode, error) {
+	episode := models.Episode{AnimeID: animeID}
+	applyEpisodeInput(&episode, in)
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureAnime(tx, animeID); err != nil {
+			return err
+		}
+		if err := checkEpisodeSlot(tx, animeID, in, 0); err != nil {
+			return err
+		}
+		return tx.Create(&episode).Error
+	})
+	return episode, err
+}
+
+// UpdateEpisode mengganti data episode
+func UpdateEpisode(animeID, episodeID uint, in EpisodeInput) (models.Episode, error) {
+	var episode models.Episode
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if episode, err = findEpisode(tx, animeID, episodeID); err != nil {
+			return err
+		}
+		if err := checkEpisodeSlot(tx, animeID, in, episodeID); err != nil {
+			return err
+		}
+		applyEpisodeInput(&episode, in)
+		return tx.Save(&episode).Error
+	})
+	return episode, err
+}
+
+// DeleteEpisode menghapus satu episode
+func DeleteEpisode(animeID, episodeID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		episode, err := findEpisode(tx, animeID, episodeID)
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&episode).Error
+	})
+}
+
+// DeleteAnimeEpisodes menghapus semua season dan episode sebuah anime, dipakai saat anime dihapus
+func DeleteAnimeEpisodes(tx *gorm.DB, animeID uint) error {
+	if err := tx.Where("anime_id = ?", animeID).Delete(&models.Episode{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("anime_id = ?", animeID).Delete(&models.Season{}).Error
+}
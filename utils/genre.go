@@ -0,0 +1,329 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGenreNotFound = errors.New("genre not found")
+	ErrGenreExists   = errors.New("genre already exists")
+	ErrInvalidGenre  = errors.New("genre name is required")
+	ErrUnknownGenre  = errors.New("unknown genre")
+)
+
+// Slugify mengubah nama menjadi slug huruf kecil: "Slice of Life" -> "slice-of-life", "Sci-Fi" -> "sci-fi"
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// SplitGenreString memecah kolom genre lama ("Action, comedy / Drama") menjadi nama-nama genre unik
+func SplitGenreString(s string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '/' || r == ';' || r == '|'
+	}) {
+		name := strings.Join(strings.Fields(part), " ")
+		slug := Slugify(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// canonicalGenreName merapikan nama genre baru: huruf pertama setiap kata dijadikan kapital
+func canonicalGenreName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		runes := []rune(word)
+		if len(runes) > 0 && unicode.IsLower(runes[0]) {
+			for j, r := range runes {
+				// "sci-fi" -> "Sci-Fi"
+				if j == 0 || runes[j-1] == '-' {
+					runes[j] = unicode.ToUpper(r)
+				}
+			}
+		}
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// GenreString menggabungkan nama genre untuk kolom animes.genre yang masih dipakai frontend lama
+func GenreString(genres []models.Genre) string {
+	names := make([]string, len(genres))
+	for i, g := range genres {
+		names[i] = g.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// ResolveGenres mencari genre berdasarkan nama atau slug, error jika ada yang tidak dikenal
+func ResolveGenres(tx *gorm.DB, names []string) ([]models.Genre, error) {
+	genres := []models.Genre{}
+	var slugs []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		slug := Slugify(name)
+		if slug != "" && !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	if len(slugs) == 0 {
+		return genres, nil
+	}
+
+	var found []models.Genre
+	if err := tx.Where("slug IN ?", slugs).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	bySlug := make(map[string]models.Genre, len(found))
+	for _, g := range found {
+		bySlug[g.Slug] = g
+	}
+	// Urutan mengikuti input
+	for _, slug := range slugs {
+		g, ok := bySlug[slug]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownGenre, slug)
+		}
+		genres = append(genres, g)
+	}
+	return genres, nil
+}
+
+// EnsureGenres membuat genre yang belum ada, untuk teks genre bebas dari data dan frontend lama
+func EnsureGenres(tx *gorm.DB, names []string) error {
+	for _, name := range names {
+		slug := Slugify(name)
+		if slug == "" {
+			continue
+		}
+		genre := models.Genre{Slug: slug}
+		if err := tx.Where(models.Genre{Slug: slug}).
+			Attrs(models.Genre{Name: canonicalGenreName(name)}).
+			FirstOrCreate(&genre).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetAnimeGenres mengganti genre sebuah anime dan menyamakan kolom genre lama
+func SetAnimeGenres(tx *gorm.DB, anime *models.Anime, names []string) error {
+	genres, err := ResolveGenres(tx, names)
+	if err != nil {
+		return err
+	}
+	sort.Slice(genres, func(i, j int) bool { return genres[i].Name < genres[j].Name })
+	if err := tx.Model(anime).Association("Genres").Replace(genres); err != nil {
+		return err
+	}
+	anime.Genres = genres
+	anime.Genre = GenreString(genres)
+	return tx.Model(&models.Anime{}).Where("id = ?", anime.ID).UpdateColumn("genre", anime.Genre).Error
+}
+
+// syncGenreStrings menulis ulang kolom genre lama untuk anime yang terpengaruh perubahan genre
+func syncGenreStrings(tx *gorm.DB, animeIDs []uint) error {
+	for _, id := range animeIDs {
+		anime := models.Anime{ID: id}
+		var genres []models.Genre
+		if err := tx.Model(&anime).Order("genres.name").Association("Genres").Find(&genres); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Anime{}).Where("id = ?", id).UpdateColumn("genre", GenreString(genres)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// genreAnimeIDs mengambil ID anime yang memakai genre tertentu
+func genreAnimeIDs(tx *gorm.DB, genreID uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Table("anime_genres").Where("genre_id = ?", genreID).Pluck("anime_id", &ids).Error
+	return ids, err
+}
+
+// ListGenres mengambil semua genre beserta jumlah anime-nya
+func ListGenres(db *gorm.DB) ([]models.GenreCount, error) {
+	genres := []models.GenreCount{}
+	err := db.Table("genres").
+		Select("genres.*, COUNT(anime_genres.anime_id) AS anime_count").
+		Joins("LEFT JOIN anime_genres ON anime_genres.genre_id = genres.id").
+		Group("genres.id").
+		Order("genres.name").
+		Scan(&genres).Error
+	return genres, err
+}
+
+// CreateGenre membuat genre baru; slug dibuat dari nama jika kosong
+func CreateGenre(name, slug string) (models.Genre, error) {
+	genre := models.Genre{Name: strings.Join(strings.Fields(name), " "), Slug: Slugify(slug)}
+	if genre.Slug == "" {
+		genre.Slug = Slugify(name)
+	}
+	if genre.Name == "" || genre.Slug == "" {
+		return genre, ErrInvalidGenre
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Genre{}).Where("slug = ?", genre.Slug).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrGenreExists
+		}
+		return tx.Create(&genre).Error
+	})
+	return genre, err
+}
+
+// UpdateGenre mengganti nama dan slug genre, lalu menyamakan kolom genre anime yang memakainya
+func UpdateGenre(id uint, name, slug string) (models.Genre, error) {
+	var genre models.Genre
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return genre, ErrInvalidGenre
+	}
+	if slug = Slugify(slug); slug == "" {
+		slug = Slugify(name)
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&genre, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrGenreNotFound
+			}
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.Genre{}).Where("slug = ? AND id <> ?", slug, id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrGenreExists
+		}
+
+		genre.Name, genre.Slug = name, slug
+		if err := tx.Save(&genre).Error; err != nil {
+			return err
+		}
+		animeIDs, err := genreAnimeIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		return syncGenreStrings(tx, animeIDs)
+	})
+	return genre, err
+}
+
+// DeleteGenre menghapus genre dari semua anime lalu menghapus genre itu sendiri
+func DeleteGenre(id uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var genre models.Genre
+		if err := tx.First(&genre, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrGenreNotFound
+			}
+			return err
+		}
+		animeIDs, err := genreAnimeIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM anime_genres WHERE genre_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&genre).Error; err != nil {
+			return err
+		}
+		return syncGenreStrings(tx, animeIDs)
+	})
+}
+
+// MigrateLegacyGenres memecah kolom genre lama menjadi relasi anime_genres.
+// Hanya anime yang belum punya relasi genre yang diproses, sehingga aman dijalankan setiap start.
+func MigrateLegacyGenres(db *gorm.DB) error {
+	var animes []models.Anime
+	if err := db.Select("id", "genre").
+		Where("genre <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM anime_genres WHERE anime_genres.anime_id = animes.id)").
+		Find(&animes).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i := range animes {
+			names := SplitGenreString(animes[i].Genre)
+			if err := EnsureGenres(tx, names); err != nil {
+				return err
+			}
+			if err := SetAnimeGenres(tx, &animes[i], names); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// attachGenres mengisi field Genres untuk item daftar anime dengan satu query
+func attachGenres(db *gorm.DB, items []models.AnimeListItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+		items[i].Genres = []models.Genre{}
+	}
+
+	var rows []struct {
+		AnimeID uint
+		models.Genre
+	}
+	if err := db.Table("anime_genres").
+		Select("anime_genres.anime_id, genres.*").
+		Joins("JOIN genres ON genres.id = anime_genres.genre_id").
+		Where("anime_genres.anime_id IN ?", ids).
+		Order("genres.name").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	index := make(map[uint]int, len(items))
+	for i, item := range items {
+		index[item.ID] = i
+	}
+	for _, row := range rows {
+		items[index[row.AnimeID]].Genres = append(items[index[row.AnimeID]].Genres, row.Genre)
+	}
+	return nil
+}
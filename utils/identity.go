@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// OAuthStateTTL adalah batas waktu menyelesaikan login di identity provider
+const OAuthStateTTL = 10 * time.Minute
+
+var (
+	ErrOAuthStateInvalid       = errors.New("invalid or expired login state")
+	ErrIdentityLinkedElsewhere = errors.New("identity is already linked to another account")
+	ErrProviderAlreadyLinked   = errors.New("a different account from this provider is already linked")
+	ErrIdentityEmailMissing    = errors.New("identity provider did not return an email address")
+	ErrIdentityEmailTaken      = errors.New("an account with this email already exists; log in and link the provider instead")
+	ErrIdentityNotLinked       = errors.New("identity not linked")
+	ErrLastLoginMethod         = errors.New("cannot remove the only way to sign in; set a password first")
+)
+
+// OAuthBrowserCookie menyimpan rahasia per alur OAuth di browser yang memulai alur tersebut.
+// Callback hanya diterima dari browser yang membawa cookie ini, sehingga URL authorization
+// milik orang lain tidak bisa dipakai untuk login CSRF atau menautkan identitas ke akun lain.
+const OAuthBrowserCookie = "nyanime_oauth"
+
+// BeginOAuth menyimpan state, nonce, PKCE verifier dan hash rahasia browser lalu mengembalikan
+// URL authorization provider beserta rahasia yang harus disimpan dengan SetOAuthBrowserCookie.
+// linkUserID diisi jika alur ini untuk menautkan provider ke akun yang sudah login.
+func BeginOAuth(ctx context.Context, provider IdentityProvider, linkUserID *int) (string, string, error) {
+	state, stateHash, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, _, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	browserSecret, browserHash, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, challenge, err := NewPKCEVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return "", "", err
+	}
+
+	// State kedaluwarsa dibersihkan sambil jalan
+	if err := DB.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{}).Error; err != nil {
+		log.Println("Error purging expired OAuth states:", err)
+	}
+
+	err = DB.Create(&models.OAuthState{
+		StateHash:    stateHash,
+		Provider:     provider.Name(),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		BrowserHash:  browserHash,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(OAuthStateTTL),
+	}).Error
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, browserSecret, nil
+}
+
+// ConsumeOAuthState mengambil state callback dan menghapusnya sehingga hanya bisa dipakai sekali.
+// browserSecret adalah isi OAuthBrowserCookie pada request callback.
+func ConsumeOAuthState(provider, state, browserSecret string) (*models.OAuthState, error) {
+	if state == "" {
+		return nil, ErrOAuthStateInvalid
+	}
+
+	var st models.OAuthState
+	if err := DB.Where("state_hash = ? AND provider = ?", HashOpaqueToken(state), provider).First(&st).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOAuthStateInvalid
+		}
+		return nil, err
+	}
+
+	res := DB.Where("state_hash = ?", st.StateHash).Delete(&models.OAuthState{})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 || time.Now().After(st.ExpiresAt) {
+		return nil, ErrOAuthStateInvalid
+	}
+	if browserSecret == "" || subtle.ConstantTimeCompare([]byte(HashOpaqueToken(browserSecret)), []byte(st.BrowserHash)) != 1 {
+		return nil, ErrOAuthStateInvalid
+	}
+	return &st, nil
+}
+
+// SetOAuthBrowserCookie menyimpan rahasia alur OAuth di cookie HttpOnly yang hanya dikirim ke /auth/.
+// SameSite=Lax tetap mengirim cookie pada redirect GET top-level dari provider kembali ke callback.
+func SetOAuthBrowserCookie(w http.ResponseWriter, secret string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     OAuthBrowserCookie,
+		Value:    secret,
+		Path:     "/auth/",
+		MaxAge:   int(OAuthStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(PublicURL(), "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearOAuthBrowserCookie menghapus cookie alur OAuth setelah callback diproses
+func ClearOAuthBrowserCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     OAuthBrowserCookie,
+		Value:    "",
+		Path:     "/auth/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(PublicURL(), "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ResolveIdentityUser mencari pengguna yang tertaut ke identitas eksternal, atau membuat akun baru
+// pada login pertama. Email yang sudah dipakai akun lokal tidak ditautkan otomatis agar akun
+// tidak bisa diambil alih lewat provider yang tidak memverifikasi email.
+func ResolveIdentityUser(identity *ExternalIdentity) (*models.User, bool, error) {
+	var user models.User
+	created := false
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var linked models.LinkedIdentity
+		err := tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&linked).Error
+		if err == nil {
+			if err := tx.First(&user, linked.UserID).Error; err != nil {
+				return err
+			}
+			now := time.Now()
+			return tx.Model(&linked).Updates(map[string]interface{}{"last_login_at": now, "email": identity.Email}).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if identity.Email == "" {
+			return ErrIdentityEmailMissing
+		}
+		var count int64
+		if err := tx.Model(&models.User{}).Where("email = ?", identity.Email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrIdentityEmailTaken
+		}
+
+		user = models.User{
+			Username:      identityUsername(identity),
+			Email:         identity.Email,
+			Role:          RoleUser,
+			PasswordUnset: true,
+		}
+		if identity.EmailVerified {
+			now := time.Now()
+			user.VerifiedAt = &now
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		created = true
+		return tx.Create(&models.LinkedIdentity{
+			UserID:      user.ID,
+			Provider:    identity.Provider,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			LastLoginAt: &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if created && user.VerifiedAt == nil {
+		if err := SendVerificationEmail(user, user.Email); err != nil {
+			log.Println("Error sending verification email:", err)
+		}
+	}
+	return &user, created, nil
+}
+
+// identityUsername memilih username awal dari nama atau bagian lokal email
+func identityUsername(identity *ExternalIdentity) string {
+	name := strings.TrimSpace(identity.Name)
+	if name == "" {
+		name = strings.SplitN(identity.Email, "@", 2)[0]
+	}
+	// Dipotong per karakter agar nama multi-byte (misalnya Jepang) tetap UTF-8 yang valid
+	if runes := []rune(strings.ToValidUTF8(name, "")); len(runes) > 50 {
+		name = string(runes[:50])
+	}
+	return strings.ToValidUTF8(name, "")
+}
+
+// LinkIdentity menautkan identitas eksternal ke pengguna yang sedang login
+func LinkIdentity(userID int, identity *ExternalIdentity) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.LinkedIdentity
+		err := tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&existing).Error
+		if err == nil {
+			if existing.UserID == userID {
+				return nil
+			}
+			return ErrIdentityLinkedElsewhere
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.LinkedIdentity{}).Where("user_id = ? AND provider = ?", userID, identity.Provider).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrProviderAlreadyLinked
+		}
+
+		return tx.Create(&models.LinkedIdentity{
+			UserID:   userID,
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		}).Error
+	})
+}
+
+// UnlinkIdentity melepas provider dari akun, kecuali jika itu satu-satunya cara login
+func UnlinkIdentity(userID int, provider string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var identity models.LinkedIdentity
+		if err := tx.Where("user_id = ? AND provider = ?", userID, provider).First(&identity).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrIdentityNotLinked
+			}
+			return err
+		}
+
+		var user models.User
+		if err := tx.Select("id", "password_unset").First(&user, userID).Error; err != nil {
+			return err
+		}
+		var others int64
+		if err := tx.Model(&models.LinkedIdentity{}).Where("user_id = ? AND id <> ?", userID, identity.ID).Count(&others).Error; err != nil {
+			return err
+		}
+		if user.PasswordUnset && others == 0 {
+			return ErrLastLoginMethod
+		}
+
+		return tx.Delete(&identity).Error
+	})
+}
+
+// ListIdentities mengembalikan provider yang tertaut ke pengguna
+func ListIdentities(userID int) ([]models.LinkedIdentity, error) {
+	identities := []models.LinkedIdentity{}
+	err := DB.Where("user_id = ?", userID).Order("provider").Find(&identities).Error
+	return identities, err
+}
@@ -0,0 +1,316 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Decoder GIF untuk image.Decode
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/http"
+	"strings"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type, expected JPEG, PNG or GIF")
+	ErrImageTooLarge    = errors.New("image is too large")
+	ErrInvalidImage     = errors.New("invalid image")
+	ErrImageNotFound    = errors.New("image not found")
+	ErrInvalidImageKind = errors.New("image kind must be cover or banner")
+)
+
+// MaxImageBytes adalah ukuran maksimal file yang diunggah, bisa diubah lewat IMAGE_MAX_BYTES
+var MaxImageBytes int64 = 5 << 20
+
+// maxImageSide membatasi lebar/tinggi gambar agar file kecil dengan resolusi raksasa
+// tidak menghabiskan memori saat di-decode
+const maxImageSide = 8000
+
+// ThumbnailSize adalah satu ukuran thumbnail, dengan lebar maksimal dalam piksel
+type ThumbnailSize struct {
+	Name  string
+	Width int
+}
+
+// ThumbnailSizes adalah ukuran thumbnail yang dibuat untuk setiap jenis gambar
+var ThumbnailSizes = map[string][]ThumbnailSize{
+	models.ImageKindCover:  {{"small", 160}, {"medium", 320}, {"large", 640}},
+	models.ImageKindBanner: {{"small", 640}, {"medium", 1280}},
+}
+
+// imageExtensions memetakan content type yang diterima ke ekstensi file
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ProcessedImage adalah gambar yang sudah divalidasi beserta thumbnail yang sudah di-encode
+type ProcessedImage struct {
+	ContentType string
+	Width       int
+	Height      int
+	Original    []byte
+	Thumbnails  map[string][]byte // Nama ukuran -> isi file
+}
+
+// thumbnailContentType menentukan format thumbnail: JPEG untuk sumber JPEG, PNG untuk lainnya
+// (GIF animasi hanya diambil frame pertamanya dan transparansi tetap terjaga)
+func thumbnailContentType(original string) string {
+	if original == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// ProcessImage memvalidasi file gambar dari isi file (bukan dari content type kiriman klien)
+// lalu membuat thumbnail sesuai jenis gambar
+func ProcessImage(data []byte, kind string) (*ProcessedImage, error) {
+	sizes, ok := ThumbnailSizes[kind]
+	if !ok {
+		return nil, ErrInvalidImageKind
+	}
+	if int64(len(data)) > MaxImageBytes {
+		return nil, ErrImageTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := imageExtensions[contentType]; !ok {
+		return nil, ErrUnsupportedImage
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width > maxImageSide || config.Height > maxImageSide {
+		return nil, fmt.Errorf("%w: maximum dimension is %dx%d pixels", ErrImageTooLarge, maxImageSide, maxImageSide)
+	}
+	if config.Width == 0 || config.Height == 0 {
+		return nil, ErrInvalidImage
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	processed := &ProcessedImage{
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		Original:    data,
+		Thumbnails:  make(map[string][]byte, len(sizes)),
+	}
+	for _, size := range sizes {
+		width, height := FitWidth(config.Width, config.Height, size.Width)
+		encoded, err := encodeImage(ResizeImage(img, width, height), thumbnailContentType(contentType))
+		if err != nil {
+			return nil, err
+		}
+		processed.Thumbnails[size.Name] = encoded
+	}
+	return processed, nil
+}
+
+func encodeImage(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	return buf.Bytes(), err
+}
+
+// imageFileKeys mengembalikan key storage file asli dan setiap thumbnail sebuah gambar
+func imageFileKeys(img models.AnimeImage) (string, map[string]string) {
+	original := img.Key + "/original" + imageExtensions[img.ContentType]
+	thumbExt := imageExtensions[thumbnailContentType(img.ContentType)]
+	thumbs := make(map[string]string)
+	for _, size := range ThumbnailSizes[img.Kind] {
+		thumbs[size.Name] = img.Key + "/" + size.Name + thumbExt
+	}
+	return original, thumbs
+}
+
+// ImageURLs membuat URL publik gambar dari storage
+func ImageURLs(img models.AnimeImage) *models.ImageURLs {
+	original, thumbs := imageFileKeys(img)
+	urls := &models.ImageURLs{
+		Original:   Images.URL(original),
+		Width:      img.Width,
+		Height:     img.Height,
+		Thumbnails: make(map[string]string, len(thumbs)),
+	}
+	for name, key := range thumbs {
+		urls.Thumbnails[name] = Images.URL(key)
+	}
+	return urls
+}
+
+// removeImageFiles menghapus file gambar dari storage. Kegagalan hanya dicatat karena
+// datanya sudah tidak dirujuk dari database.
+func removeImageFiles(img models.AnimeImage) {
+	original, thumbs := imageFileKeys(img)
+	keys := []string{original}
+	for _, key := range thumbs {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if err := Images.Delete(key); err != nil {
+			log.Printf("Error deleting image file %s: %v", key, err)
+		}
+	}
+}
+
+// SaveAnimeImage mengunggah file ke storage lalu mengganti gambar anime untuk jenis tersebut.
+// Setiap unggahan memakai awalan key baru sehingga URL lama di cache tidak menampilkan gambar baru.
+func SaveAnimeImage(animeID uint, kind string, processed *ProcessedImage) (models.AnimeImage, error) {
+	if err := ensureAnime(DB, animeID); err != nil {
+		return models.AnimeImage{}, err
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return models.AnimeImage{}, err
+	}
+	img := models.AnimeImage{
+		AnimeID:     animeID,
+		Kind:        kind,
+		Key:         fmt.Sprintf("anime/%d/%s/%s", animeID, kind, hex.EncodeToString(suffix)),
+		ContentType: processed.ContentType,
+		Width:       processed.Width,
+		Height:      processed.Height,
+		Size:        int64(len(processed.Original)),
+	}
+
+	original, thumbs := imageFileKeys(img)
+	thumbType := thumbnailContentType(img.ContentType)
+	err := Images.Put(original, processed.Original, img.ContentType)
+	for name, key := range thumbs {
+		if err != nil {
+			break
+		}
+		err = Images.Put(key, processed.Thumbnails[name], thumbType)
+	}
+	if err != nil {
+		removeImageFiles(img)
+		return models.AnimeImage{}, err
+	}
+
+	var previous models.AnimeImage
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("anime_id = ? AND kind = ?", animeID, kind).First(&previous).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+		case err != nil:
+			return err
+		default:
+			if err := tx.Delete(&previous).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&img).Error
+	})
+	if err != nil {
+		removeImageFiles(img)
+		return models.AnimeImage{}, err
+	}
+	if previous.ID != 0 {
+		removeImageFiles(previous)
+	}
+	return img, nil
+}
+
+// DeleteAnimeImage menghapus gambar anime untuk satu jenis beserta file-nya
+func DeleteAnimeImage(animeID uint, kind string) error {
+	var img models.AnimeImage
+	err := DB.Where("anime_id = ? AND kind = ?", animeID, kind).First(&img).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrImageNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := DB.Delete(&img).Error; err != nil {
+		return err
+	}
+	removeImageFiles(img)
+	return nil
+}
+
+// DeleteAnimeImages menghapus data gambar sebuah anime, dipakai saat anime dihapus.
+// File di storage dihapus oleh pemanggil dengan RemoveImageFiles setelah transaksi berhasil.
+func DeleteAnimeImages(tx *gorm.DB, animeID uint) ([]models.AnimeImage, error) {
+	var images []models.AnimeImage
+	if err := tx.Where("anime_id = ?", animeID).Find(&images).Error; err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return images, nil
+	}
+	return images, tx.Where("anime_id = ?", animeID).Delete(&models.AnimeImage{}).Error
+}
+
+// RemoveImageFiles menghapus file dari storage untuk gambar yang datanya sudah dihapus
+func RemoveImageFiles(images []models.AnimeImage) {
+	for _, img := range images {
+		removeImageFiles(img)
+	}
+}
+
+// AttachAnimeImages mengisi field Images untuk beberapa anime dengan satu query
+func AttachAnimeImages(db *gorm.DB, animes []*models.Anime) error {
+	if len(animes) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(animes))
+	for i, anime := range animes {
+		ids[i] = anime.ID
+	}
+	var images []models.AnimeImage
+	if err := db.Where("anime_id IN ?", ids).Find(&images).Error; err != nil {
+		return err
+	}
+
+	byAnime := make(map[uint]*models.AnimeImages)
+	for _, img := range images {
+		set := byAnime[img.AnimeID]
+		if set == nil {
+			set = &models.AnimeImages{}
+			byAnime[img.AnimeID] = set
+		}
+		switch img.Kind {
+		case models.ImageKindCover:
+			set.Cover = ImageURLs(img)
+		case models.ImageKindBanner:
+			set.Banner = ImageURLs(img)
+		}
+	}
+	for _, anime := range animes {
+		anime.Images = byAnime[anime.ID]
+		if anime.Images == nil {
+			anime.Images = &models.AnimeImages{}
+		}
+	}
+	return nil
+}
+
+// ParseImageKind memvalidasi jenis gambar dari URL
+func ParseImageKind(kind string) (string, error) {
+	kind = strings.ToLower(kind)
+	if _, ok := ThumbnailSizes[kind]; !ok {
+		return "", ErrInvalidImageKind
+	}
+	return kind, nil
+}
@@ -0,0 +1,353 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritma penandatanganan JWT yang didukung
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// SigningKey adalah satu kunci JWT yang diidentifikasi dengan kid
+type SigningKey struct {
+	KID       string
+	Algorithm string
+	RetiredAt *time.Time // Kunci pensiun tidak dipakai untuk tanda tangan, hanya verifikasi selama masa tenggang
+
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewSigningKey membuat SigningKey dari secret HS256 ([]byte), *rsa.PrivateKey atau ed25519.PrivateKey
+func NewSigningKey(kid, alg string, key interface{}, retiredAt *time.Time) (*SigningKey, error) {
+	k := &SigningKey{KID: kid, Algorithm: alg, RetiredAt: retiredAt}
+
+	switch alg {
+	case AlgHS256:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return nil, fmt.Errorf("key %s: HS256 requires a non-empty secret", kid)
+		}
+		k.method, k.signKey, k.verifyKey = jwt.SigningMethodHS256, secret, secret
+	case AlgRS256:
+		private, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key %s: RS256 requires an RSA private key", kid)
+		}
+		k.method, k.signKey, k.verifyKey = jwt.SigningMethodRS256, private, &private.PublicKey
+	case AlgEdDSA:
+		private, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key %s: EdDSA requires an Ed25519 private key", kid)
+		}
+		k.method, k.signKey, k.verifyKey = jwt.SigningMethodEdDSA, private, private.Public()
+	default:
+		return nil, fmt.Errorf("key %s: unsupported algorithm %q", kid, alg)
+	}
+
+	return k, nil
+}
+
+// KeyManager menyimpan semua kunci JWT yang aktif maupun yang sedang dalam masa tenggang
+type KeyManager struct {
+	mu         sync.RWMutex
+	keys       map[string]*SigningKey
+	order      []string
+	signingKID string
+	grace      time.Duration
+}
+
+// NewKeyManager membuat KeyManager. signingKID kosong berarti kunci aktif pertama yang dipakai untuk tanda tangan.
+func NewKeyManager(keys []*SigningKey, signingKID string, grace time.Duration) (*KeyManager, error) {
+	km := &KeyManager{keys: make(map[string]*SigningKey), grace: grace}
+	for _, k := range keys {
+		if _, exists := km.keys[k.KID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", k.KID)
+		}
+		km.keys[k.KID] = k
+		km.order = append(km.order, k.KID)
+	}
+
+	if signingKID == "" {
+		for _, kid := range km.order {
+			if km.keys[kid].RetiredAt == nil {
+				signingKID = kid
+				break
+			}
+		}
+	}
+	signing, ok := km.keys[signingKID]
+	if !ok {
+		return nil, errors.New("no active signing key configured")
+	}
+	if signing.RetiredAt != nil {
+		return nil, fmt.Errorf("signing key %q is retired", signingKID)
+	}
+	km.signingKID = signingKID
+
+	return km, nil
+}
+
+// usable mengecek apakah kunci masih boleh dipakai untuk verifikasi
+func (km *KeyManager) usable(k *SigningKey, now time.Time) bool {
+	return k.RetiredAt == nil || now.Before(k.RetiredAt.Add(km.grace))
+}
+
+// Sign menandatangani klaim dengan kunci aktif dan menyertakan kid di header
+func (km *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	km.mu.RLock()
+	k := km.keys[km.signingKID]
+	km.mu.RUnlock()
+
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.KID
+	return token.SignedString(k.signKey)
+}
+
+// Keyfunc dipakai jwt.Parse untuk memilih kunci verifikasi berdasarkan kid
+func (km *KeyManager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	now := time.Now()
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		// Token lama tanpa kid ditandatangani dengan HS256
+		if token.Method.Alg() != AlgHS256 {
+			return nil, ErrUnknownKey
+		}
+		set := jwt.VerificationKeySet{}
+		for _, id := range km.order {
+			k := km.keys[id]
+			if k.Algorithm == AlgHS256 && km.usable(k, now) {
+				set.Keys = append(set.Keys, k.verifyKey)
+			}
+		}
+		if len(set.Keys) == 0 {
+			return nil, ErrUnknownKey
+		}
+		return set, nil
+	}
+
+	k, ok := km.keys[kid]
+	if !ok || !km.usable(k, now) {
+		return nil, ErrUnknownKey
+	}
+	// Cegah serangan pergantian algoritma (mis. RS256 -> HS256)
+	if token.Method.Alg() != k.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %s for key %s", token.Method.Alg(), kid)
+	}
+	return k.verifyKey, nil
+}
+
+// JWK adalah representasi publik satu kunci sesuai RFC 7517
+type JWK struct {
+	KTY string `json:"kty"`
+	KID string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet adalah isi dari /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS mengembalikan kunci publik yang masih berlaku. Kunci HS256 tidak pernah dipublikasikan.
+func (km *KeyManager) JWKS() JWKSet {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	now := time.Now()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, kid := range km.order {
+		k := km.keys[kid]
+		if !km.usable(k, now) {
+			continue
+		}
+		switch pub := k.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KTY: "RSA", KID: k.KID, Use: "sig", Alg: k.Algorithm,
+				N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KTY: "OKP", KID: k.KID, Use: "sig", Alg: k.Algorithm,
+				Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
+
+// keyFileConfig adalah format file JWT_KEYS_FILE
+type keyFileConfig struct {
+	ActiveKID string `json:"active_kid"`
+	Keys      []struct {
+		KID            string     `json:"kid"`
+		Alg            string     `json:"alg"`
+		Secret         string     `json:"secret"`
+		PrivateKeyFile string     `json:"private_key_file"`
+		RetiredAt      *time.Time `json:"retired_at"`
+	} `json:"keys"`
+}
+
+// LoadKeyManagerFromEnv membaca konfigurasi kunci:
+//   - JWT_KEYS_FILE: file JSON berisi daftar kunci (kid, alg, secret/private_key_file, retired_at)
+//   - JWT_ACTIVE_KID: kid yang dipakai untuk tanda tangan (override active_kid di file)
+//   - JWT_KEY_GRACE_PERIOD: berapa lama kunci pensiun masih diterima (default 24h)
+//   - SECRET_KEYS: fallback daftar secret HS256 dipisah koma, yang pertama dipakai untuk tanda tangan
+//   - JWT_ALLOW_EPHEMERAL_KEY: "true" mengizinkan kunci acak per proses jika tidak ada kunci (hanya untuk development)
+func LoadKeyManagerFromEnv() (*KeyManager, error) {
+	grace := 24 * time.Hour
+	if v := os.Getenv("JWT_KEY_GRACE_PERIOD"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_KEY_GRACE_PERIOD: %w", err)
+		}
+		grace = d
+	}
+
+	var keys []*SigningKey
+	activeKID := os.Getenv("JWT_ACTIVE_KID")
+
+	if path := os.Getenv("JWT_KEYS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading JWT_KEYS_FILE: %w", err)
+		}
+		var cfg keyFileConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parsing JWT_KEYS_FILE: %w", err)
+		}
+		if activeKID == "" {
+			activeKID = cfg.ActiveKID
+		}
+		for _, entry := range cfg.Keys {
+			var material interface{}
+			if entry.Alg == AlgHS256 {
+				material = []byte(entry.Secret)
+			} else {
+				material, err = loadPrivateKey(entry.PrivateKeyFile)
+				if err != nil {
+					return nil, fmt.Errorf("key %s: %w", entry.KID, err)
+				}
+			}
+			k, err := NewSigningKey(entry.KID, entry.Alg, material, entry.RetiredAt)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+		}
+	} else if secrets := os.Getenv("SECRET_KEYS"); secrets != "" {
+		for _, secret := range strings.Split(secrets, ",") {
+			secret = strings.TrimSpace(secret)
+			if secret == "" {
+				continue
+			}
+			// kid diturunkan dari secret agar stabil antar restart tanpa membocorkan secret
+			k, err := NewSigningKey("hs-"+HashOpaqueToken(secret)[:12], AlgHS256, []byte(secret), nil)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) == 0 {
+		// Kunci acak per proses membuat semua token tidak valid setelah restart atau di replika lain,
+		// jadi hanya boleh dipakai jika diminta secara eksplisit untuk development
+		if os.Getenv("JWT_ALLOW_EPHEMERAL_KEY") != "true" {
+			return nil, errors.New("no JWT keys configured: set JWT_KEYS_FILE or SECRET_KEYS (or JWT_ALLOW_EPHEMERAL_KEY=true for development)")
+		}
+		log.Println("WARNING: no JWT keys configured (JWT_KEYS_FILE or SECRET_KEYS), using an ephemeral key")
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		k, _ := NewSigningKey("ephemeral", AlgHS256, secret, nil)
+		keys = append(keys, k)
+	}
+
+	return NewKeyManager(keys, activeKID, grace)
+}
+
+// loadPrivateKey membaca private key RSA/Ed25519 dari file PEM (PKCS#8 atau PKCS#1)
+func loadPrivateKey(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+var (
+	keyManager   *KeyManager
+	keyManagerMu sync.Mutex
+)
+
+// InitKeys memuat kunci JWT dari konfigurasi saat server start
+func InitKeys() {
+	km, err := LoadKeyManagerFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	SetKeyManager(km)
+}
+
+// SetKeyManager mengganti KeyManager global (dipakai saat start dan di test)
+func SetKeyManager(km *KeyManager) {
+	keyManagerMu.Lock()
+	defer keyManagerMu.Unlock()
+	keyManager = km
+}
+
+// CurrentKeys mengembalikan KeyManager global, memuatnya dari env jika belum ada
+func CurrentKeys() *KeyManager {
+	keyManagerMu.Lock()
+	defer keyManagerMu.Unlock()
+	if keyManager == nil {
+		km, err := LoadKeyManagerFromEnv()
+		if err != nil {
+			log.Fatalf("Failed to load JWT keys: %v", err)
+		}
+		keyManager = km
+	}
+	return keyManager
+}
@@ -0,0 +1,340 @@
+package utils
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptTracker menyimpan catatan login gagal per kunci
+type LoginAttemptTracker interface {
+	Get(key string) (models.LoginAttempt, error)
+	// AddFailure menambah hitungan gagal secara atomik, sehingga kegagalan yang tercatat
+	// bersamaan (juga dari replika lain) tidak saling menimpa, lalu memperpanjang blokir sesuai policy
+	AddFailure(key string, policy LoginThrottlePolicy, now time.Time) (models.LoginAttempt, error)
+	Delete(key string) error
+	// PurgeStale menghapus catatan yang sudah tidak memblokir dan kegagalan terakhirnya sebelum cutoff
+	PurgeStale(now, cutoff time.Time) error
+}
+
+// LoginAttempts adalah tracker yang dipakai Login. Default-nya in-memory;
+// InitDB menggantinya dengan versi database agar berlaku di semua replika.
+var LoginAttempts LoginAttemptTracker = NewMemoryLoginAttemptTracker()
+
+// MemoryLoginAttemptTracker menyimpan percobaan login di memori proses
+type MemoryLoginAttemptTracker struct {
+	mu sync.RWMutex
+	m  map[string]models.LoginAttempt
+}
+
+// NewMemoryLoginAttemptTracker membuat tracker in-memory kosong
+func NewMemoryLoginAttemptTracker() *MemoryLoginAttemptTracker {
+	return &MemoryLoginAttemptTracker{m: make(map[string]models.LoginAttempt)}
+}
+
+func (t *MemoryLoginAttemptTracker) Get(key string) (models.LoginAttempt, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	attempt, ok := t.m[key]
+	if !ok {
+		return models.LoginAttempt{Key: key}, nil
+	}
+	return attempt, nil
+}
+
+func (t *MemoryLoginAttemptTracker) AddFailure(key string, policy LoginThrottlePolicy, now time.Time) (models.LoginAttempt, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	attempt := t.m[key]
+	attempt.Key = key
+	if now.Sub(attempt.LastFailureAt) > policy.FailureWindow {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	if until, locked := policy.blockFor(attempt.Failures, now); until.After(attempt.BlockedUntil) {
+		attempt.BlockedUntil, attempt.Locked = until, locked
+	}
+	t.m[key] = attempt
+	return attempt, nil
+}
+
+func (t *MemoryLoginAttemptTracker) Delete(key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.m, key)
+	return nil
+}
+
+func (t *MemoryLoginAttemptTracker) PurgeStale(now, cutoff time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, attempt := range t.m {
+		if !now.Before(attempt.BlockedUntil) && attempt.LastFailureAt.Before(cutoff) {
+			delete(t.m, key)
+		}
+	}
+	return nil
+}
+
+// DBLoginAttemptTracker menyimpan percobaan login di tabel login_attempts
+type DBLoginAttemptTracker struct {
+	db *gorm.DB
+}
+
+// noLoginTime dipakai sebagai pengganti time.Time kosong, yang ditolak MySQL dengan NO_ZERO_DATE
+var noLoginTime = time.Unix(0, 0).UTC()
+
+// NewDBLoginAttemptTracker membuat tracker berbasis database
+func NewDBLoginAttemptTracker(db *gorm.DB) *DBLoginAttemptTracker {
+	return &DBLoginAttemptTracker{db: db}
+}
+
+func (t *DBLoginAttemptTracker) Get(key string) (models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := t.db.Where("`key` = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.LoginAttempt{Key: key}, nil
+	}
+	return attempt, err
+}
+
+// AddFailure menaikkan hitungan dengan INSERT ... ON DUPLICATE KEY UPDATE failures = failures + 1,
+// lalu hanya memperpanjang blocked_until (tidak pernah memperpendek), sehingga urutan replika
+// yang menulis tidak mengubah hasil akhirnya
+func (t *DBLoginAttemptTracker) AddFailure(key string, policy LoginThrottlePolicy, now time.Time) (models.LoginAttempt, error) {
+	// failures di-assign sebelum last_failure_at, jadi IF() masih membaca waktu gagal sebelumnya
+	err := t.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("IF(last_failure_at < ?, 1, failures + 1)", now.Add(-policy.FailureWindow))},
+			{Column: clause.Column{Name: "last_failure_at"}, Value: now},
+			{Column: clause.Column{Name: "updated_at"}, Value: now},
+		},
+	}).Create(&models.LoginAttempt{
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: now,
+		BlockedUntil:  noLoginTime,
+	}).Error
+	if err != nil {
+		return models.LoginAttempt{}, err
+	}
+
+	attempt, err := t.Get(key)
+	if err != nil {
+		return attempt, err
+	}
+	until, locked := policy.blockFor(attempt.Failures, now)
+	if !until.After(attempt.BlockedUntil) {
+		return attempt, nil
+	}
+	res := t.db.Model(&models.LoginAttempt{}).
+		Where("`key` = ? AND blocked_until < ?", key, until).
+		Updates(map[string]interface{}{"blocked_until": until, "locked": locked})
+	if res.Error != nil {
+		return attempt, res.Error
+	}
+	if res.RowsAffected == 0 {
+		// Replika lain sudah memasang blokir yang lebih lama
+		return t.Get(key)
+	}
+	attempt.BlockedUntil, attempt.Locked = until, locked
+	return attempt, nil
+}
+
+func (t *DBLoginAttemptTracker) Delete(key string) error {
+	return t.db.Where("`key` = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+func (t *DBLoginAttemptTracker) PurgeStale(now, cutoff time.Time) error {
+	return t.db.Where("blocked_until <= ? AND last_failure_at < ?", now, cutoff).
+		Delete(&models.LoginAttempt{}).Error
+}
+
+// StartLoginAttemptPurger menghapus catatan login gagal yang sudah lewat FailureWindow
+// dan tidak sedang memblokir secara berkala
+func StartLoginAttemptPurger(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := PurgeLoginAttempts(time.Now()); err != nil {
+				log.Println("Error purging login attempts:", err)
+			}
+		}
+	}()
+}
+
+// PurgeLoginAttempts menghapus catatan yang tidak lagi berpengaruh pada CheckLogin maupun
+// hitungan kegagalan berikutnya
+func PurgeLoginAttempts(now time.Time) error {
+	account, _ := loginPolicies()
+	return LoginAttempts.PurgeStale(now, now.Add(-account.FailureWindow))
+}
+
+// LoginThrottlePolicy mengatur kapan backoff dan lockout dimulai
+type LoginThrottlePolicy struct {
+	BackoffAfter    int           // Jumlah gagal sebelum backoff eksponensial dimulai
+	BackoffBase     time.Duration // Jeda pertama, lalu dikali dua setiap kegagalan berikutnya
+	BackoffMax      time.Duration
+	LockoutAfter    int // Jumlah gagal sebelum akun/IP dikunci sementara
+	LockoutDuration time.Duration
+	FailureWindow   time.Duration // Kegagalan yang lebih lama dari ini dilupakan
+}
+
+// LoginBlock menjelaskan kenapa login ditolak; nilai kosong berarti boleh mencoba
+type LoginBlock struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+// Blocked mengecek apakah login saat ini harus ditolak
+func (b LoginBlock) Blocked() bool {
+	return b.RetryAfter > 0
+}
+
+var (
+	loginPoliciesOnce sync.Once
+	accountPolicy     LoginThrottlePolicy
+	ipPolicy          LoginThrottlePolicy
+)
+
+// loginPolicies membaca kebijakan dari env (LOGIN_BACKOFF_AFTER, LOGIN_BACKOFF_BASE, LOGIN_BACKOFF_MAX,
+// LOGIN_LOCKOUT_AFTER, LOGIN_LOCKOUT_DURATION, LOGIN_FAILURE_WINDOW, LOGIN_IP_LOCKOUT_AFTER)
+func loginPolicies() (LoginThrottlePolicy, LoginThrottlePolicy) {
+	loginPoliciesOnce.Do(func() {
+		accountPolicy = LoginThrottlePolicy{
+			BackoffAfter:    envInt("LOGIN_BACKOFF_AFTER", 3),
+			BackoffBase:     envDuration("LOGIN_BACKOFF_BASE", time.Second),
+			BackoffMax:      envDuration("LOGIN_BACKOFF_MAX", 5*time.Minute),
+			LockoutAfter:    envInt("LOGIN_LOCKOUT_AFTER", 10),
+			LockoutDuration: envDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			FailureWindow:   envDuration("LOGIN_FAILURE_WINDOW", time.Hour),
+		}
+		// Satu IP wajar dipakai banyak akun (NAT, kampus), jadi ambang lockout-nya lebih longgar
+		ipPolicy = accountPolicy
+		ipPolicy.BackoffAfter = accountPolicy.BackoffAfter * 5
+		ipPolicy.LockoutAfter = envInt("LOGIN_IP_LOCKOUT_AFTER", accountPolicy.LockoutAfter*5)
+	})
+	return accountPolicy, ipPolicy
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func checkKey(key string, now time.Time) (LoginBlock, error) {
+	attempt, err := LoginAttempts.Get(key)
+	if err != nil {
+		return LoginBlock{}, err
+	}
+	if now.Before(attempt.BlockedUntil) {
+		return LoginBlock{RetryAfter: attempt.BlockedUntil.Sub(now), Locked: attempt.Locked}, nil
+	}
+	return LoginBlock{}, nil
+}
+
+// blockFor menghitung sampai kapan kunci diblokir setelah sejumlah kegagalan;
+// waktu kosong berarti belum diblokir
+func (p LoginThrottlePolicy) blockFor(failures int, now time.Time) (time.Time, bool) {
+	switch {
+	case failures >= p.LockoutAfter:
+		return now.Add(p.LockoutDuration), true
+	case failures >= p.BackoffAfter:
+		delay := p.BackoffBase << uint(failures-p.BackoffAfter)
+		if delay <= 0 || delay > p.BackoffMax {
+			delay = p.BackoffMax
+		}
+		return now.Add(delay), false
+	}
+	return time.Time{}, false
+}
+
+func recordFailure(key string, policy LoginThrottlePolicy, now time.Time) (LoginBlock, error) {
+	attempt, err := LoginAttempts.AddFailure(key, policy, now)
+	if err != nil {
+		return LoginBlock{}, err
+	}
+	if now.Before(attempt.BlockedUntil) {
+		return LoginBlock{RetryAfter: attempt.BlockedUntil.Sub(now), Locked: attempt.Locked}, nil
+	}
+	return LoginBlock{}, nil
+}
+
+// CheckLogin mengecek apakah akun atau IP sedang diblokir. Blokir IP didahulukan.
+func CheckLogin(email, ip string) (LoginBlock, error) {
+	now := time.Now()
+	if block, err := checkKey(ipKey(ip), now); err != nil || block.Blocked() {
+		block.Locked = false // Blokir IP selalu dilaporkan sebagai 429, bukan akun terkunci
+		return block, err
+	}
+	return checkKey(accountKey(email), now)
+}
+
+// RecordLoginFailure mencatat login gagal untuk akun dan IP
+func RecordLoginFailure(email, ip string) (LoginBlock, error) {
+	account, perIP := loginPolicies()
+	now := time.Now()
+
+	ipBlock, err := recordFailure(ipKey(ip), perIP, now)
+	if err != nil {
+		return LoginBlock{}, err
+	}
+	accountBlock, err := recordFailure(accountKey(email), account, now)
+	if err != nil {
+		return LoginBlock{}, err
+	}
+
+	if accountBlock.Blocked() {
+		return accountBlock, nil
+	}
+	ipBlock.Locked = false
+	return ipBlock, nil
+}
+
+// RecordLoginSuccess menghapus riwayat gagal untuk akun (riwayat IP tetap dipertahankan)
+func RecordLoginSuccess(email string) error {
+	return LoginAttempts.Delete(accountKey(email))
+}
+
+// ClearLoginLockout menghapus blokir untuk akun dan/atau IP (dipakai admin)
+func ClearLoginLockout(email, ip string) error {
+	if email != "" {
+		if err := LoginAttempts.Delete(accountKey(email)); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		if err := LoginAttempts.Delete(ipKey(ip)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func envInt(name string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
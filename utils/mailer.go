@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mail adalah satu email keluar
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email transaksional (reset password, verifikasi, dll.)
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+// LogMailer hanya menulis email ke log, cocok untuk development
+type LogMailer struct{}
+
+func (LogMailer) Send(mail Mail) error {
+	log.Printf("[mail] to=%s subject=%q\n%s", mail.To, mail.Subject, mail.Body)
+	return nil
+}
+
+// FileMailer menyimpan setiap email sebagai file .eml di Dir
+type FileMailer struct {
+	Dir string
+}
+
+func (m FileMailer) Send(mail Mail) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	suffix, err := randomHex(4)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405"), suffix)
+	return os.WriteFile(filepath.Join(m.Dir, name), buildMessage(os.Getenv("MAIL_FROM"), mail), 0o644)
+}
+
+// SMTPMailer mengirim email melalui server SMTP
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m SMTPMailer) Send(mail Mail) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{mail.To}, buildMessage(m.From, mail))
+}
+
+func buildMessage(from string, mail Mail) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(mail.Body)
+	return []byte(b.String())
+}
+
+var (
+	mailer   Mailer
+	mailerMu sync.Mutex
+)
+
+// NewMailerFromEnv memilih implementasi mailer lewat MAIL_DRIVER (smtp, file, log; default log)
+func NewMailerFromEnv() Mailer {
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		return SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASS"),
+			From:     os.Getenv("MAIL_FROM"),
+		}
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return FileMailer{Dir: dir}
+	default:
+		return LogMailer{}
+	}
+}
+
+// SetMailer mengganti mailer global (dipakai di test)
+func SetMailer(m Mailer) {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+	mailer = m
+}
+
+// GetMailer mengembalikan mailer global, dibuat dari env saat pertama kali dipakai
+func GetMailer() Mailer {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+	if mailer == nil {
+		mailer = NewMailerFromEnv()
+	}
+	return mailer
+}
+
+// FrontendURL adalah alamat frontend yang dipakai untuk link di email
+func FrontendURL() string {
+	if v := os.Getenv("FRONTEND_URL"); v != "" {
+		return strings.TrimRight(v, "/")
+	}
+	return "http://127.0.0.1:5500"
+}
+
+// PublicURL adalah alamat publik API ini, dipakai untuk link yang langsung mengarah ke backend
+func PublicURL() string {
+	if v := os.Getenv("PUBLIC_URL"); v != "" {
+		return strings.TrimRight(v, "/")
+	}
+	return "http://localhost:8080"
+}
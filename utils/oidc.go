@@ -0,0 +1,430 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval membatasi seberapa sering JWKS provider diambil ulang saat kid tidak dikenal
+const jwksRefreshInterval = time.Minute
+
+var ErrIDTokenInvalid = errors.New("invalid id_token")
+
+// ExternalIdentity adalah identitas pengguna yang sudah diverifikasi oleh identity provider
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// IdentityProvider adalah provider login eksternal dengan alur authorization code + PKCE
+type IdentityProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*ExternalIdentity, error)
+}
+
+// OIDCConfig adalah konfigurasi satu provider OpenID Connect
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// oidcDiscovery adalah bagian dari /.well-known/openid-configuration yang kita pakai
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider mengimplementasikan IdentityProvider untuk issuer OpenID Connect mana pun
+type OIDCProvider struct {
+	name   string
+	config OIDCConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	discovery   *oidcDiscovery
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+// NewOIDCProvider membuat provider OIDC. client nil berarti http.Client dengan timeout 10 detik.
+func NewOIDCProvider(name string, config OIDCConfig, client *http.Client) *OIDCProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDCProvider{name: name, config: config, client: client}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+// discover mengambil dan meng-cache dokumen discovery issuer
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var doc oidcDiscovery
+	wellKnown := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &doc); err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s: %w", p.name, err)
+	}
+	// Issuer di dokumen harus sama persis dengan yang dikonfigurasi (OIDC Discovery 4.3)
+	if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(p.config.Issuer, "/") {
+		return nil, fmt.Errorf("oidc discovery for %s: issuer mismatch %q", p.name, doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery for %s: incomplete configuration", p.name)
+	}
+	p.discovery = &doc
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// AuthCodeURL membuat URL authorization dengan PKCE (S256)
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return doc.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange menukar authorization code dengan token lalu memverifikasi id_token
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*ExternalIdentity, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token exchange with %s failed: status %d", p.name, resp.StatusCode)
+	}
+
+	var tokenResponse struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return nil, fmt.Errorf("token exchange with %s: %w", p.name, err)
+	}
+	if tokenResponse.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response from %s has no id_token", ErrIDTokenInvalid, p.name)
+	}
+
+	return p.verifyIDToken(ctx, doc, tokenResponse.IDToken, nonce)
+}
+
+// idTokenClaims adalah klaim id_token yang kita pakai
+type idTokenClaims struct {
+	Nonce           string      `json:"nonce"`
+	Email           string      `json:"email"`
+	EmailVerified   interface{} `json:"email_verified"` // Beberapa provider mengirim string "true"
+	Name            string      `json:"name"`
+	AuthorizedParty string      `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, doc *oidcDiscovery, raw, nonce string) (*ExternalIdentity, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims,
+		func(token *jwt.Token) (interface{}, error) { return p.verificationKey(ctx, doc, token) },
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "EdDSA"}),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIDTokenInvalid, err)
+	}
+
+	if claims.Nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrIDTokenInvalid)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrIDTokenInvalid)
+	}
+	// Jika token ditujukan ke beberapa audience, azp harus client kita (OIDC Core 3.1.3.7)
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("%w: unexpected authorized party", ErrIDTokenInvalid)
+	}
+
+	verified := false
+	switch v := claims.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+
+	return &ExternalIdentity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified: verified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// verificationKey memilih kunci dari JWKS provider berdasarkan kid, mengambil ulang JWKS jika kid belum dikenal
+func (p *OIDCProvider) verificationKey(ctx context.Context, doc *oidcDiscovery, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lookup := func() (interface{}, bool) {
+		if kid == "" && len(p.keys) == 1 {
+			for _, k := range p.keys {
+				return k, true
+			}
+		}
+		k, ok := p.keys[kid]
+		return k, ok
+	}
+
+	if key, ok := lookup(); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < jwksRefreshInterval {
+		return nil, ErrUnknownKey
+	}
+
+	var set JWKSet
+	if err := p.getJSON(ctx, doc.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	p.keys = make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			log.Printf("Skipping JWK %q from %s: %v", jwk.KID, p.name, err)
+			continue
+		}
+		p.keys[jwk.KID] = key
+	}
+	p.keysFetched = time.Now()
+
+	if key, ok := lookup(); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// PublicKey mengubah JWK menjadi kunci publik Go (RSA, EC P-256 atau Ed25519)
+func (k JWK) PublicKey() (interface{}, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.KTY {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("EC point is not on curve")
+		}
+		return pub, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KTY)
+}
+
+// NewPKCEVerifier membuat code_verifier acak beserta code_challenge S256-nya
+func NewPKCEVerifier() (verifier, challenge string, err error) {
+	verifier, _, err = NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	return verifier, PKCEChallenge(verifier), nil
+}
+
+// PKCEChallenge menghitung code_challenge S256 (RFC 7636)
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+var (
+	identityProviders   = map[string]IdentityProvider{}
+	identityProvidersMu sync.RWMutex
+)
+
+// RegisterIdentityProvider mendaftarkan provider (dipakai saat start dan di test)
+func RegisterIdentityProvider(p IdentityProvider) {
+	identityProvidersMu.Lock()
+	defer identityProvidersMu.Unlock()
+	identityProviders[p.Name()] = p
+}
+
+// GetIdentityProvider mencari provider berdasarkan nama
+func GetIdentityProvider(name string) (IdentityProvider, bool) {
+	identityProvidersMu.RLock()
+	defer identityProvidersMu.RUnlock()
+	p, ok := identityProviders[name]
+	return p, ok
+}
+
+// IdentityProviderNames mengembalikan nama semua provider yang terdaftar
+func IdentityProviderNames() []string {
+	identityProvidersMu.RLock()
+	defer identityProvidersMu.RUnlock()
+	names := make([]string, 0, len(identityProviders))
+	for name := range identityProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InitIdentityProviders membaca provider dari env:
+//   - OIDC_PROVIDERS: daftar nama provider dipisah koma, mis. "google,gitlab"
+//   - OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET
+//   - OIDC_<NAME>_REDIRECT_URL (default PUBLIC_URL/auth/<name>/callback)
+//   - OIDC_<NAME>_SCOPES (default "openid email profile")
+func InitIdentityProviders() {
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		config := OIDCConfig{
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if config.Issuer == "" || config.ClientID == "" {
+			log.Printf("WARNING: OIDC provider %q is missing %sISSUER or %sCLIENT_ID, skipping", name, prefix, prefix)
+			continue
+		}
+		if config.RedirectURL == "" {
+			config.RedirectURL = PublicURL() + "/auth/" + name + "/callback"
+		}
+
+		RegisterIdentityProvider(NewOIDCProvider(name, config, nil))
+		log.Printf("OIDC provider %q enabled (issuer %s)", name, config.Issuer)
+	}
+}
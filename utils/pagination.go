@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ParsePagination membaca parameter page dan limit dari query string.
+// page dimulai dari 1; limit dibatasi maxLimit.
+func ParsePagination(r *http.Request, defaultLimit, maxLimit int) (page, limit int) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err = strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	return page, limit
+}
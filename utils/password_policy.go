@@ -0,0 +1,318 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcrypt hanya memakai 72 byte pertama, jadi password yang lebih panjang ditolak
+const maxPasswordBytes = 72
+
+// Kode pelanggaran kebijakan password
+const (
+	PasswordTooShort         = "too_short"
+	PasswordTooLong          = "too_long"
+	PasswordLowEntropy       = "low_entropy"
+	PasswordContainsUsername = "contains_username"
+	PasswordContainsEmail    = "contains_email"
+	PasswordBreached         = "breached"
+)
+
+// PasswordViolation adalah satu alasan password ditolak
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError berisi semua pelanggaran kebijakan password
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return "password does not meet the policy: " + strings.Join(messages, "; ")
+}
+
+// PasswordPolicy adalah aturan password yang berlaku
+type PasswordPolicy struct {
+	MinLength      int
+	MinEntropyBits float64
+	BreachedList   string // Direktori file range k-anonymity, atau satu file hash SHA-1 yang terurut
+}
+
+// PasswordPolicyFromEnv membaca kebijakan dari env:
+//   - PASSWORD_MIN_LENGTH (default 8)
+//   - PASSWORD_MIN_ENTROPY: perkiraan entropi minimum dalam bit (default 50)
+//   - PASSWORD_BREACHED_HASHES_FILE: daftar hash password yang bocor (opsional). Sebaiknya direktori
+//     range (file XXXXX.txt per 5 karakter awal hash). File tunggal harus terurut menurut hash,
+//     misalnya Pwned Passwords versi "ordered by hash", karena dicari dengan binary search.
+func PasswordPolicyFromEnv() PasswordPolicy {
+	minEntropy := 50.0
+	if v, err := strconv.ParseFloat(os.Getenv("PASSWORD_MIN_ENTROPY"), 64); err == nil && v >= 0 {
+		minEntropy = v
+	}
+	return PasswordPolicy{
+		MinLength:      envInt("PASSWORD_MIN_LENGTH", 8),
+		MinEntropyBits: minEntropy,
+		BreachedList:   os.Getenv("PASSWORD_BREACHED_HASHES_FILE"),
+	}
+}
+
+// ValidatePassword mengecek password terhadap kebijakan dari env
+func ValidatePassword(password, username, email string) error {
+	return PasswordPolicyFromEnv().Validate(password, username, email)
+}
+
+// Validate mengembalikan *PasswordPolicyError jika password melanggar kebijakan
+func (p PasswordPolicy) Validate(password, username, email string) error {
+	var violations []PasswordViolation
+	add := func(code, format string, args ...interface{}) {
+		violations = append(violations, PasswordViolation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		add(PasswordTooShort, "Password must be at least %d characters", p.MinLength)
+	}
+	if len(password) > maxPasswordBytes {
+		add(PasswordTooLong, "Password must be at most %d bytes", maxPasswordBytes)
+	}
+
+	lower := strings.ToLower(password)
+	if u := strings.ToLower(strings.TrimSpace(username)); len(u) >= 3 && strings.Contains(lower, u) {
+		add(PasswordContainsUsername, "Password must not contain your username")
+	}
+	if local := strings.ToLower(strings.SplitN(strings.TrimSpace(email), "@", 2)[0]); len(local) >= 3 && strings.Contains(lower, local) {
+		add(PasswordContainsEmail, "Password must not contain your email address")
+	}
+
+	if bits := EstimatePasswordEntropy(password); bits < p.MinEntropyBits {
+		add(PasswordLowEntropy, "Password is too predictable; use a longer password or mix letters, numbers and symbols")
+	}
+
+	if p.BreachedList != "" {
+		breached, err := IsPasswordBreached(p.BreachedList, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			add(PasswordBreached, "Password has appeared in a data breach; choose a different one")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// EstimatePasswordEntropy memperkirakan entropi password dalam bit: ukuran himpunan karakter
+// yang dipakai dikalikan panjang efektif. Karakter berulang dan urutan (abc, 123, cba)
+// hanya dihitung sebagian.
+func EstimatePasswordEntropy(password string) float64 {
+	var hasLower, hasUpper, hasDigit, hasSymbol, hasOther bool
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			hasSymbol = true
+		default:
+			hasOther = true
+		}
+	}
+
+	pool := 0
+	if hasLower {
+		pool += 26
+	}
+	if hasUpper {
+		pool += 26
+	}
+	if hasDigit {
+		pool += 10
+	}
+	if hasSymbol {
+		pool += 33
+	}
+	if hasOther {
+		pool += 100
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	effective := 0.0
+	var prev rune
+	prevDelta := 0
+	for i, r := range []rune(password) {
+		weight := 1.0
+		if i > 0 {
+			delta := int(unicode.ToLower(r)) - int(unicode.ToLower(prev))
+			switch {
+			case delta == 0:
+				weight = 0.25 // Karakter berulang (aaaa)
+			case (delta == 1 || delta == -1) && delta == prevDelta:
+				weight = 0.25 // Urutan (abcd, 4321)
+			}
+			prevDelta = delta
+		}
+		effective += weight
+		prev = r
+	}
+
+	return effective * math.Log2(float64(pool))
+}
+
+// IsPasswordBreached mengecek password di daftar hash SHA-1 password yang bocor.
+// path boleh berupa direktori file range k-anonymity (nama file = 5 karakter awal hash,
+// isi "SUFFIX:COUNT" per baris, seperti hasil unduhan Pwned Passwords), atau satu file
+// berisi hash lengkap "HASH" / "HASH:COUNT" per baris yang terurut menurut hash. File tunggal
+// dicari dengan binary search sehingga daftar berukuran puluhan GB tetap cepat; file yang
+// tidak terurut bisa memberi hasil negatif palsu.
+func IsPasswordBreached(path, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("breached password list: %w", err)
+	}
+
+	if info.IsDir() {
+		prefix, suffix := hash[:5], hash[5:]
+		for _, name := range []string{prefix, prefix + ".txt"} {
+			found, err := scanHashFile(filepath.Join(path, name), suffix)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return found, err
+		}
+		return false, nil
+	}
+
+	return searchSortedHashFile(path, hash)
+}
+
+// hashSearchWindow adalah ukuran sisa rentang file yang dibaca berurutan setelah binary search
+const hashSearchWindow = 8 << 10
+
+// searchSortedHashFile mencari hash di file yang barisnya terurut menurut hash. Rentang
+// [lo, hi) selalu berisi awal baris yang dicari (jika ada) dan lo selalu awal sebuah baris.
+func searchSortedHashFile(path, hash string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	size := info.Size()
+	lo, hi := int64(0), size
+	for hi-lo > hashSearchWindow {
+		mid := lo + (hi-lo)/2
+		start, err := nextLineStart(f, mid, size)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+		line, err := bufio.NewReader(io.NewSectionReader(f, start, size-start)).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		switch cmp := strings.Compare(hashLineKey(line), hash); {
+		case cmp == 0:
+			return true, nil
+		case cmp < 0:
+			lo = start + int64(len(line))
+		default:
+			hi = start
+		}
+	}
+
+	// Sisa rentang kecil dibaca berurutan
+	reader := bufio.NewReader(io.NewSectionReader(f, lo, size-lo))
+	for offset := lo; offset < hi; {
+		line, err := reader.ReadString('\n')
+		if hashLineKey(line) == hash {
+			return true, nil
+		}
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		offset += int64(len(line))
+	}
+	return false, nil
+}
+
+// nextLineStart mengembalikan offset awal baris pertama yang dimulai di offset >= off
+func nextLineStart(f *os.File, off, size int64) (int64, error) {
+	if off == 0 {
+		return 0, nil
+	}
+	skipped, err := bufio.NewReader(io.NewSectionReader(f, off-1, size-off+1)).ReadString('\n')
+	if err == io.EOF {
+		return size, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return off - 1 + int64(len(skipped)), nil
+}
+
+// hashLineKey mengambil hash (huruf besar) dari baris "HASH" atau "HASH:COUNT"
+func hashLineKey(line string) string {
+	line = strings.TrimSpace(line)
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		line = line[:i]
+	}
+	return strings.ToUpper(line)
+}
+
+// scanHashFile mencari baris yang diawali hash (case-insensitive) di sebuah file
+func scanHashFile(path, hash string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		if strings.EqualFold(line, hash) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetTTL adalah masa berlaku token reset password
+const PasswordResetTTL = time.Hour
+
+var (
+	ErrResetTokenInvalid     = errors.New("invalid or expired reset token")
+	ErrPasswordResetCooldown = errors.New("password reset email was sent recently")
+)
+
+// PasswordResetCooldown adalah jeda minimum antar email reset password untuk satu akun
+// (PASSWORD_RESET_COOLDOWN, default 60s)
+func PasswordResetCooldown() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("PASSWORD_RESET_COOLDOWN")); err == nil {
+		return d
+	}
+	return time.Minute
+}
+
+// PasswordResetCooldownRemaining mengembalikan sisa waktu sebelum email reset boleh dikirim lagi
+func PasswordResetCooldownRemaining(userID int) (time.Duration, error) {
+	var last models.PasswordResetToken
+	err := DB.Where("user_id = ?", userID).Order("created_at DESC").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	remaining := time.Until(last.CreatedAt.Add(PasswordResetCooldown()))
+	if remaining < 0 {
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+// QueuePasswordResetEmail mengirim email reset di background. Hasilnya hanya dicatat di log
+// agar respons ForgotPassword (status dan waktunya) sama untuk email terdaftar maupun tidak.
+func QueuePasswordResetEmail(user models.User) {
+	go func() {
+		err := SendPasswordResetEmail(user)
+		switch {
+		case errors.Is(err, ErrPasswordResetCooldown):
+			log.Printf("Password reset for user %d skipped: cooldown active", user.ID)
+		case err != nil:
+			log.Printf("Error sending password reset email to user %d: %v", user.ID, err)
+		}
+	}()
+}
+
+// SendPasswordResetEmail membuat token reset baru dan mengirimkannya ke email pengguna.
+// Token lama yang belum dipakai otomatis dibatalkan. Mengembalikan ErrPasswordResetCooldown
+// jika email reset terakhir dikirim kurang dari PasswordResetCooldown yang lalu.
+func SendPasswordResetEmail(user models.User) error {
+	remaining, err := PasswordResetCooldownRemaining(user.ID)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return ErrPasswordResetCooldown
+	}
+	return sendPasswordResetEmail(user)
+}
+
+// sendPasswordResetEmail mengirim email reset tanpa cek cooldown. Dipakai langsung oleh
+// reset paksa dari admin, yang tidak boleh gagal hanya karena pengguna baru saja meminta reset.
+func sendPasswordResetEmail(user models.User) error {
+	raw, hash, err := NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(PasswordResetTTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password.html?token=%s", FrontendURL(), raw)
+	return GetMailer().Send(Mail{
+		To:      user.Email,
+		Subject: "Reset your Nyanime password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone requested a password reset for your Nyanime account.\n"+
+			"Open the link below within %d minutes to choose a new password:\n\n%s\n\n"+
+			"If you did not request this, you can ignore this email.\n",
+			user.Username, int(PasswordResetTTL.Minutes()), link),
+	})
+}
+
+// LookupPasswordResetToken mencari token reset yang masih berlaku
+func LookupPasswordResetToken(raw string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := DB.Where("token_hash = ?", HashOpaqueToken(raw)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResetTokenInvalid
+		}
+		return nil, err
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, ErrResetTokenInvalid
+	}
+	return &token, nil
+}
+
+// ConsumePasswordResetToken menandai token sebagai terpakai dan menyimpan hash password baru
+// dalam satu transaksi, sehingga token tidak bisa dipakai dua kali
+func ConsumePasswordResetToken(token *models.PasswordResetToken, passwordHash string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrResetTokenInvalid
+		}
+		return tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(map[string]interface{}{
+			"password":                passwordHash,
+			"password_reset_required": false,
+			"password_unset":          false,
+		}).Error
+	})
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"log"
+	"net/http"
+)
+
+// HasPermission mengecek apakah token pada request membawa permission tersebut
+func HasPermission(r *http.Request, permission string) bool {
+	perms, _ := r.Context().Value(UserPermissionsKey).([]string)
+	for _, p := range perms {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission membuat middleware yang mewajibkan semua permission yang disebutkan.
+// Harus dipasang setelah AuthMiddleware.
+func RequirePermission(permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, permission := range permissions {
+				if !HasPermission(r, permission) {
+					log.Printf("User %v lacks permission %s", r.Context().Value(UserIDKey), permission)
+					http.Error(w, "Forbidden: Missing permission "+permission, http.StatusForbidden)
+					return
+				}
+			}
+
+			// Jika 2FA wajib untuk admin, token role dengan permission admin harus berasal dari login dengan 2FA
+			if mfa, _ := r.Context().Value(UserMFAKey).(bool); !mfa {
+				role, _ := r.Context().Value(UserRoleKey).(string)
+				required, err := RoleRequiresTwoFactor(role)
+				if err != nil {
+					http.Error(w, "Database error", http.StatusInternalServerError)
+					return
+				}
+				if required {
+					http.Error(w, "Forbidden: Two-factor authentication required for admin access", http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MissingRolePermission mengembalikan permission pertama dari role yang tidak dimiliki token
+// pada request, atau string kosong jika semuanya dimiliki
+func MissingRolePermission(r *http.Request, roleName string) (string, error) {
+	perms, err := ResolvePermissions(roleName)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range perms {
+		if !HasPermission(r, p) {
+			return p, nil
+		}
+	}
+	return "", nil
+}
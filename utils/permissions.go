@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// Daftar permission bawaan
+const (
+	PermAnimeWrite     = "anime:write"
+	PermAnimeDelete    = "anime:delete"
+	PermReviewModerate = "review:moderate"
+	PermUserBan        = "user:ban"
+	PermUserManage     = "user:manage"
+	PermRoleManage     = "role:manage"
+	PermAuditRead      = "audit:read"
+)
+
+// Nama role bawaan
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleUser      = "user"
+)
+
+var defaultPermissions = []models.Permission{
+	{Name: PermAnimeWrite, Description: "Create and edit anime"},
+	{Name: PermAnimeDelete, Description: "Delete anime"},
+	{Name: PermReviewModerate, Description: "Edit or remove any review"},
+	{Name: PermUserBan, Description: "Ban and suspend users"},
+	{Name: PermUserManage, Description: "View users, assign roles and clear lockouts"},
+	{Name: PermRoleManage, Description: "Create roles and change their permissions"},
+	{Name: PermAuditRead, Description: "Read the admin audit log"},
+}
+
+var defaultRoles = map[string][]string{
+	RoleModerator: {PermReviewModerate, PermUserBan},
+	RoleUser:      {},
+}
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleExists        = errors.New("role already exists")
+	ErrRoleProtected     = errors.New("built-in role cannot be changed")
+	ErrRoleInUse         = errors.New("role is still assigned to users")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+// isBuiltinRole mengecek apakah role termasuk role bawaan
+func isBuiltinRole(name string) bool {
+	_, ok := defaultRoles[name]
+	return ok || name == RoleAdmin
+}
+
+// SeedRolesAndPermissions membuat permission dan role bawaan jika belum ada.
+// Role admin selalu dilengkapi dengan semua permission; role lain tidak diubah
+// agar penyesuaian dari admin tidak tertimpa.
+func SeedRolesAndPermissions(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		all := make([]models.Permission, 0, len(defaultPermissions))
+		byName := make(map[string]models.Permission)
+		for _, p := range defaultPermissions {
+			perm := p
+			if err := tx.Where(models.Permission{Name: p.Name}).Attrs(models.Permission{Description: p.Description}).FirstOrCreate(&perm).Error; err != nil {
+				return err
+			}
+			all = append(all, perm)
+			byName[perm.Name] = perm
+		}
+
+		var admin models.Role
+		if err := tx.Where(models.Role{Name: RoleAdmin}).Attrs(models.Role{Description: "Full access"}).FirstOrCreate(&admin).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&admin).Association("Permissions").Append(all); err != nil {
+			return err
+		}
+
+		for name, perms := range defaultRoles {
+			var role models.Role
+			var count int64
+			if err := tx.Model(&models.Role{}).Where("name = ?", name).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			role.Name = name
+			for _, p := range perms {
+				role.Permissions = append(role.Permissions, byName[p])
+			}
+			if err := tx.Create(&role).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ResolvePermissions mengembalikan nama permission untuk sebuah role
+func ResolvePermissions(roleName string) ([]string, error) {
+	if DB == nil {
+		return nil, nil
+	}
+
+	var role models.Role
+	if err := DB.Preload("Permissions").Where("name = ?", roleName).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	perms := make([]string, 0, len(role.Permissions))
+	for _, p := range role.Permissions {
+		perms = append(perms, p.Name)
+	}
+	return perms, nil
+}
+
+// privilegedPermissions adalah permission yang mewajibkan 2FA saat REQUIRE_ADMIN_2FA aktif
+var privilegedPermissions = []string{PermUserManage, PermRoleManage}
+
+// RoleRequiresTwoFactor mengecek apakah pengguna dengan role ini wajib memakai 2FA. Yang
+// menentukan adalah permission role, jadi role kustom dengan user:manage atau role:manage
+// diperlakukan sama seperti admin.
+func RoleRequiresTwoFactor(roleName string) (bool, error) {
+	if !RequireAdmin2FA() {
+		return false, nil
+	}
+	if roleName == RoleAdmin {
+		return true, nil
+	}
+	perms, err := ResolvePermissions(roleName)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range perms {
+		for _, privileged := range privilegedPermissions {
+			if p == privileged {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// BumpTokenVersion membuat access token pengguna yang sedang beredar tidak berlaku.
+// Refresh token tetap berlaku sehingga klien cukup me-refresh untuk mendapat permission terbaru.
+func BumpTokenVersion(db *gorm.DB, userIDs ...int) error {
+	return db.Model(&models.User{}).
+		Where("id IN ?", userIDs).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+// findPermissions mengambil permission berdasarkan nama, error jika ada yang tidak dikenal
+func findPermissions(tx *gorm.DB, names []string) ([]models.Permission, error) {
+	perms := []models.Permission{}
+	if len(names) == 0 {
+		return perms, nil
+	}
+	if err := tx.Where("name IN ?", names).Find(&perms).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(perms))
+	for _, p := range perms {
+		found[p.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, name)
+		}
+	}
+	return perms, nil
+}
+
+// CreateRole membuat role baru dengan permission yang diberikan
+func CreateRole(name, description string, permissions []string) (models.Role, error) {
+	role := models.Role{Name: name, Description: description}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Role{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRoleExists
+		}
+		perms, err := findPermissions(tx, permissions)
+		if err != nil {
+			return err
+		}
+		role.Permissions = perms
+		return tx.Create(&role).Error
+	})
+	return role, err
+}
+
+// UpdateRole mengganti deskripsi dan permission sebuah role. Access token milik
+// pengguna dengan role tersebut dibuat tidak berlaku agar permission baru langsung dipakai.
+func UpdateRole(name, description string, permissions []string) (models.Role, error) {
+	var role models.Role
+	if name == RoleAdmin {
+		return role, ErrRoleProtected
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("name = ?", name).First(&role).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotFound
+			}
+			return err
+		}
+		perms, err := findPermissions(tx, permissions)
+		if err != nil {
+			return err
+		}
+		role.Description = description
+		if err := tx.Save(&role).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&role).Association("Permissions").Replace(perms); err != nil {
+			return err
+		}
+		role.Permissions = perms
+		return tx.Model(&models.User{}).
+			Where("role = ?", name).
+			UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+	})
+	return role, err
+}
+
+// DeleteRole menghapus role yang bukan bawaan dan tidak sedang dipakai
+func DeleteRole(name string) error {
+	if isBuiltinRole(name) {
+		return ErrRoleProtected
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var role models.Role
+		if err := tx.Where("name = ?", name).First(&role).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotFound
+			}
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.User{}).Where("role = ?", name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRoleInUse
+		}
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+}
+
+// AssignRole mengganti role pengguna dan membuat access token lamanya tidak berlaku
+func AssignRole(userID int, roleName string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Role{}).Where("name = ?", roleName).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrRoleNotFound
+		}
+		var user models.User
+		if err := tx.Select("id").First(&user, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("role", roleName).Error; err != nil {
+			return err
+		}
+		return BumpTokenVersion(tx, userID)
+	})
+}
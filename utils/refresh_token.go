@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// IssueRefreshToken membuat refresh token baru untuk sesi; ID sesi dipakai sebagai FamilyID
+func IssueRefreshToken(userID int, sessionID string) (string, error) {
+	return createRefreshToken(DB, userID, sessionID)
+}
+
+func createRefreshToken(tx *gorm.DB, userID int, familyID string) (string, error) {
+	raw, hash, err := NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	token := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}
+	if err := tx.Create(&token).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// RotateRefreshToken menukar refresh token lama dengan yang baru dalam family yang sama.
+// Jika token yang sudah dipakai atau dicabut dikirim lagi, seluruh family dicabut
+// karena kemungkinan besar token tersebut telah dicuri.
+func RotateRefreshToken(raw string) (string, *models.RefreshToken, error) {
+	var current models.RefreshToken
+	var newRaw string
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", HashOpaqueToken(raw)).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+
+		if current.UsedAt != nil || current.RevokedAt != nil {
+			return ErrRefreshTokenReused
+		}
+		if time.Now().After(current.ExpiresAt) {
+			return ErrRefreshTokenExpired
+		}
+
+		// Update bersyarat agar dua request paralel dengan token yang sama tidak sama-sama berhasil
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", current.ID).
+			Update("used_at", time.Now())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		var err error
+		newRaw, err = createRefreshToken(tx, current.UserID, current.FamilyID)
+		return err
+	})
+
+	if errors.Is(err, ErrRefreshTokenReused) {
+		log.Printf("Refresh token reuse detected for user %d, revoking family %s", current.UserID, current.FamilyID)
+		if revokeErr := RevokeRefreshTokenFamily(current.FamilyID); revokeErr != nil {
+			log.Println("Error revoking refresh token family:", revokeErr)
+		}
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return newRaw, &current, nil
+}
+
+// RevokeRefreshTokenFamily mencabut semua refresh token dalam satu family beserta sesinya
+func RevokeRefreshTokenFamily(familyID string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", time.Now()).Error
+	})
+}
+
+// RevokeUserRefreshTokens mencabut semua refresh token milik pengguna
+func RevokeUserRefreshTokens(userID int) error {
+	return DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// InvalidateUserTokens membatalkan semua token pengguna setelah perubahan kredensial:
+// TokenVersion dinaikkan (semua JWT lama ditolak AuthMiddleware), semua refresh token dicabut
+// dan semua sesi kecuali keepSessionID ditutup. Pemanggil wajib menerbitkan token baru untuk sesi yang dipertahankan.
+func InvalidateUserTokens(userID int, keepSessionID string) error {
+	if err := BumpTokenVersion(DB, userID); err != nil {
+		return err
+	}
+	if err := RevokeAllSessions(userID, keepSessionID); err != nil {
+		return err
+	}
+	return RevokeUserRefreshTokens(userID)
+}
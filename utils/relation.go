@@ -0,0 +1,376 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// Jenis relasi antar anime. Relasi {A, B, sequel} berarti B adalah sekuel A.
+const (
+	RelationSequel             = "sequel"
+	RelationPrequel            = "prequel"
+	RelationSideStory          = "side_story"
+	RelationSpinOff            = "spin_off"
+	RelationParentStory        = "parent_story"
+	RelationOriginalStory      = "original_story" // Kebalikan spin_off: anime asal dari spin-off
+	RelationAlternativeVersion = "alternative_version"
+	RelationAdaptation         = "adaptation"
+	RelationSource             = "source"
+)
+
+// relationInverse memetakan jenis relasi ke jenis sisi kebalikannya. Pemetaannya satu-satu,
+// jadi jenis asli selalu bisa dibaca dari sisi mana pun.
+var relationInverse = map[string]string{
+	RelationSequel:             RelationPrequel,
+	RelationPrequel:            RelationSequel,
+	RelationSideStory:          RelationParentStory,
+	RelationParentStory:        RelationSideStory,
+	RelationSpinOff:            RelationOriginalStory,
+	RelationOriginalStory:      RelationSpinOff,
+	RelationAlternativeVersion: RelationAlternativeVersion,
+	RelationAdaptation:         RelationSource,
+	RelationSource:             RelationAdaptation,
+}
+
+var (
+	ErrInvalidRelation   = errors.New("invalid relation type")
+	ErrSelfRelation      = errors.New("anime cannot be related to itself")
+	ErrRelationExists    = errors.New("anime are already related")
+	ErrRelationNotFound  = errors.New("relation not found")
+	ErrFranchiseNotFound = errors.New("franchise not found")
+	ErrInvalidFranchise  = errors.New("franchise name is required")
+)
+
+// InverseRelation mengembalikan jenis relasi kebalikan
+func InverseRelation(relationType string) (string, bool) {
+	inverse, ok := relationInverse[relationType]
+	return inverse, ok
+}
+
+// RelationTypes mengembalikan semua jenis relasi yang didukung, terurut
+func RelationTypes() []string {
+	types := make([]string, 0, len(relationInverse))
+	for t := range relationInverse {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// RelationWatchEdge mengubah relasi menjadi aturan urutan tonton. Sekuel ditonton setelah
+// anime asalnya, side story/spin-off setelah cerita utamanya. Relasi lain tidak mengatur urutan.
+func RelationWatchEdge(relation models.AnimeRelation) (WatchOrderEdge, bool) {
+	switch relation.Type {
+	case RelationSequel, RelationSideStory, RelationSpinOff:
+		return WatchOrderEdge{Before: relation.AnimeID, After: relation.RelatedID}, true
+	case RelationPrequel, RelationParentStory, RelationOriginalStory:
+		return WatchOrderEdge{Before: relation.RelatedID, After: relation.AnimeID}, true
+	}
+	return WatchOrderEdge{}, false
+}
+
+// MigrateSpinOffInverses memperbaiki sisi kebalikan spin-off yang dulu disimpan sebagai parent_story
+func MigrateSpinOffInverses(db *gorm.DB) error {
+	var ids []uint
+	if err := db.Table("anime_relations AS r").
+		Joins("JOIN anime_relations o ON o.anime_id = r.related_id AND o.related_id = r.anime_id").
+		Where("r.type = ? AND o.type = ?", RelationParentStory, RelationSpinOff).
+		Pluck("r.id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return db.Model(&models.AnimeRelation{}).Where("id IN ?", ids).Update("type", RelationOriginalStory).Error
+}
+
+// ListRelatedAnime mengambil anime yang berhubungan dengan sebuah anime
+func ListRelatedAnime(db *gorm.DB, animeID uint) ([]models.RelatedAnime, error) {
+	if err := ensureAnime(db, animeID); err != nil {
+		return nil, err
+	}
+
+	var relations []models.AnimeRelation
+	if err := db.Where("anime_id = ?", animeID).Find(&relations).Error; err != nil {
+		return nil, err
+	}
+	related := []models.RelatedAnime{}
+	if len(relations) == 0 {
+		return related, nil
+	}
+
+	ids := make([]uint, len(relations))
+	for i, rel := range relations {
+		ids[i] = rel.RelatedID
+	}
+	var animes []models.Anime
+	if err := db.Where("id IN ?", ids).Find(&animes).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Anime, len(animes))
+	for _, anime := range animes {
+		byID[anime.ID] = anime
+	}
+
+	for _, rel := range relations {
+		if anime, ok := byID[rel.RelatedID]; ok {
+			related = append(related, models.RelatedAnime{Type: rel.Type, Anime: anime})
+		}
+	}
+	sort.SliceStable(related, func(i, j int) bool {
+		if related[i].Type != related[j].Type {
+			return related[i].Type < related[j].Type
+		}
+		return related[i].Anime.ReleaseDate < related[j].Anime.ReleaseDate
+	})
+	return related, nil
+}
+
+// AddAnimeRelation menyimpan relasi beserta sisi kebalikannya, menolak relasi yang membuat
+// urutan tonton menjadi siklus, lalu menggabungkan kedua anime ke franchise yang sama.
+func AddAnimeRelation(animeID, relatedID uint, relationType string) (models.AnimeRelation, error) {
+	relationType = strings.ToLower(strings.TrimSpace(relationType))
+	inverse, ok := InverseRelation(relationType)
+	if !ok {
+		return models.AnimeRelation{}, fmt.Errorf("%w: must be one of %s", ErrInvalidRelation, strings.Join(RelationTypes(), ", "))
+	}
+	if animeID == relatedID {
+		return models.AnimeRelation{}, ErrSelfRelation
+	}
+
+	relation := models.AnimeRelation{AnimeID: animeID, RelatedID: relatedID, Type: relationType}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var animes []models.Anime
+		if err := tx.Where("id IN ?", []uint{animeID, relatedID}).Find(&animes).Error; err != nil {
+			return err
+		}
+		if len(animes) != 2 {
+			return ErrAnimeNotFound
+		}
+
+		var count int64
+		if err := tx.Model(&models.AnimeRelation{}).
+			Where("(anime_id = ? AND related_id = ?) OR (anime_id = ? AND related_id = ?)", animeID, relatedID, relatedID, animeID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRelationExists
+		}
+
+		if err := checkRelationCycle(tx, relation); err != nil {
+			return err
+		}
+
+		rows := []models.AnimeRelation{relation, {AnimeID: relatedID, RelatedID: animeID, Type: inverse}}
+		if err := tx.Create(&rows).Error; err != nil {
+			return err
+		}
+		relation = rows[0]
+
+		source, target := animes[0], animes[1]
+		if source.ID != animeID {
+			source, target = target, source
+		}
+		return mergeFranchises(tx, source, target)
+	})
+	return relation, err
+}
+
+// checkRelationCycle memastikan relasi baru tidak membuat siklus urutan tonton
+// di komponen graf yang terhubung dengan kedua anime
+func checkRelationCycle(tx *gorm.DB, relation models.AnimeRelation) error {
+	newEdge, ok := RelationWatchEdge(relation)
+	if !ok {
+		return nil
+	}
+
+	// Cukup telusuri anime yang bisa dicapai dari kedua ujung relasi
+	ids, relations, err := relationComponent(tx, []uint{relation.AnimeID, relation.RelatedID})
+	if err != nil {
+		return err
+	}
+	nodes := make([]WatchOrderNode, len(ids))
+	for i, id := range ids {
+		nodes[i] = WatchOrderNode{ID: id}
+	}
+	edges := []WatchOrderEdge{newEdge}
+	for _, rel := range relations {
+		if edge, ok := RelationWatchEdge(rel); ok {
+			edges = append(edges, edge)
+		}
+	}
+	_, err = WatchOrder(nodes, edges)
+	return err
+}
+
+// relationComponent mengambil semua anime yang terhubung (lewat relasi apa pun) dengan anime awal
+func relationComponent(tx *gorm.DB, start []uint) ([]uint, []models.AnimeRelation, error) {
+	visited := make(map[uint]bool)
+	frontier := []uint{}
+	for _, id := range start {
+		if !visited[id] {
+			visited[id] = true
+			frontier = append(frontier, id)
+		}
+	}
+
+	var all []models.AnimeRelation
+	for len(frontier) > 0 {
+		var relations []models.AnimeRelation
+		if err := tx.Where("anime_id IN ?", frontier).Find(&relations).Error; err != nil {
+			return nil, nil, err
+		}
+		all = append(all, relations...)
+		frontier = frontier[:0]
+		for _, rel := range relations {
+			if !visited[rel.RelatedID] {
+				visited[rel.RelatedID] = true
+				frontier = append(frontier, rel.RelatedID)
+			}
+		}
+	}
+
+	ids := make([]uint, 0, len(visited))
+	for id := range visited {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, all, nil
+}
+
+// mergeFranchises memasukkan kedua anime ke franchise yang sama. Franchise baru dinamai
+// sesuai judul anime sumber; jika keduanya sudah punya franchise berbeda, anggota franchise
+// target dipindahkan dan franchise target dihapus.
+func mergeFranchises(tx *gorm.DB, source, target models.Anime) error {
+	switch {
+	case source.FranchiseID == nil && target.FranchiseID == nil:
+		franchise := models.Franchise{Name: source.Title}
+		if err := tx.Create(&franchise).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Anime{}).Where("id IN ?", []uint{source.ID, target.ID}).Update("franchise_id", franchise.ID).Error
+	case source.FranchiseID == nil:
+		return tx.Model(&models.Anime{}).Where("id = ?", source.ID).Update("franchise_id", *target.FranchiseID).Error
+	case target.FranchiseID == nil:
+		return tx.Model(&models.Anime{}).Where("id = ?", target.ID).Update("franchise_id", *source.FranchiseID).Error
+	case *source.FranchiseID != *target.FranchiseID:
+		if err := tx.Model(&models.Anime{}).Where("franchise_id = ?", *target.FranchiseID).Update("franchise_id", *source.FranchiseID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Franchise{}, *target.FranchiseID).Error
+	}
+	return nil
+}
+
+// RemoveAnimeRelation menghapus relasi dua anime di kedua arah. Keanggotaan franchise
+// tidak diubah; anime yang memang satu franchise tetap bisa tidak saling berelasi langsung.
+func RemoveAnimeRelation(animeID, relatedID uint) error {
+	result := DB.Where("(anime_id = ? AND related_id = ?) OR (anime_id = ? AND related_id = ?)", animeID, relatedID, relatedID, animeID).
+		Delete(&models.AnimeRelation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRelationNotFound
+	}
+	return nil
+}
+
+// DeleteAnimeRelations menghapus semua relasi sebuah anime, dipakai saat anime dihapus
+func DeleteAnimeRelations(tx *gorm.DB, animeID uint) error {
+	return tx.Where("anime_id = ? OR related_id = ?", animeID, animeID).Delete(&models.AnimeRelation{}).Error
+}
+
+// ListFranchises mengambil semua franchise beserta jumlah anime-nya
+func ListFranchises(db *gorm.DB, page, limit int) ([]models.FranchiseSummary, int64, error) {
+	var total int64
+	if err := db.Model(&models.Franchise{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	franchises := []models.FranchiseSummary{}
+	err := db.Table("franchises").
+		Select("franchises.*, COUNT(animes.id) AS anime_count").
+		Joins("LEFT JOIN animes ON animes.franchise_id = franchises.id").
+		Group("franchises.id").
+		Order("franchises.name, franchises.id").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Scan(&franchises).Error
+	return franchises, total, err
+}
+
+// GetFranchise mengambil franchise beserta anggotanya, urut tanggal rilis
+func GetFranchise(db *gorm.DB, id uint) (models.Franchise, []models.Anime, error) {
+	var franchise models.Franchise
+	if err := findEntity(db, &franchise, id, ErrFranchiseNotFound); err != nil {
+		return franchise, nil, err
+	}
+	animes := []models.Anime{}
+	err := db.Where("franchise_id = ?", id).Order(animeStartDateExpr + ", animes.id").Find(&animes).Error
+	return franchise, animes, err
+}
+
+// UpdateFranchise mengganti nama dan deskripsi franchise
+func UpdateFranchise(id uint, name, description string) (models.Franchise, error) {
+	var franchise models.Franchise
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return franchise, ErrInvalidFranchise
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := findEntity(tx, &franchise, id, ErrFranchiseNotFound); err != nil {
+			return err
+		}
+		franchise.Name, franchise.Description = name, description
+		return tx.Save(&franchise).Error
+	})
+	return franchise, err
+}
+
+// FranchiseWatchOrder menghitung urutan tonton anggota franchise dari graf relasi
+func FranchiseWatchOrder(db *gorm.DB, id uint) ([]models.Anime, error) {
+	_, animes, err := GetFranchise(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(animes) == 0 {
+		return animes, nil
+	}
+
+	ids := make([]uint, len(animes))
+	nodes := make([]WatchOrderNode, len(animes))
+	byID := make(map[uint]models.Anime, len(animes))
+	for i, anime := range animes {
+		ids[i] = anime.ID
+		nodes[i] = WatchOrderNode{ID: anime.ID, ReleaseDate: anime.ReleaseDate}
+		byID[anime.ID] = anime
+	}
+
+	var relations []models.AnimeRelation
+	if err := db.Where("anime_id IN ? AND related_id IN ?", ids, ids).Find(&relations).Error; err != nil {
+		return nil, err
+	}
+	var edges []WatchOrderEdge
+	for _, rel := range relations {
+		if edge, ok := RelationWatchEdge(rel); ok {
+			edges = append(edges, edge)
+		}
+	}
+
+	order, err := WatchOrder(nodes, edges)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.Anime, len(order))
+	for i, id := range order {
+		result[i] = byID[id]
+	}
+	return result, nil
+}
@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidReleaseInfo = errors.New("invalid release info")
+
+// AiringStatuses adalah semua status tayang yang valid
+var AiringStatuses = []string{
+	models.AiringStatusUpcoming,
+	models.AiringStatusAiring,
+	models.AiringStatusFinished,
+	models.AiringStatusCancelled,
+}
+
+// BroadcastSeasons adalah musim tayang berurutan dalam satu tahun
+var BroadcastSeasons = []string{models.SeasonWinter, models.SeasonSpring, models.SeasonSummer, models.SeasonFall}
+
+// legacyDateLayouts adalah format release_date lama yang dikenali saat migrasi
+var legacyDateLayouts = []struct {
+	layout    string
+	precision models.DatePrecision
+}{
+	{"2006-01-02", models.PrecisionDay},
+	{"2006-1-2", models.PrecisionDay},
+	{"2006/01/02", models.PrecisionDay},
+	{"2006/1/2", models.PrecisionDay},
+	{"2006.01.02", models.PrecisionDay},
+	{"January 2, 2006", models.PrecisionDay},
+	{"Jan 2, 2006", models.PrecisionDay},
+	{"2 January 2006", models.PrecisionDay},
+	{"2 Jan 2006", models.PrecisionDay},
+	{"2006-01", models.PrecisionMonth},
+	{"2006/01", models.PrecisionMonth},
+	{"January 2006", models.PrecisionMonth},
+	{"Jan 2006", models.PrecisionMonth},
+	{"2006", models.PrecisionYear},
+}
+
+// ParseLegacyReleaseDate membaca release_date bebas dari data lama, misalnya "2013-04-07",
+// "April 7, 2013", "Apr 2013", "2013" atau "Spring 2013". Tanggal dengan jam dipotong ke
+// tanggalnya. Format angka hari/bulan yang ambigu (07/04/2013) tidak dikenali.
+func ParseLegacyReleaseDate(s string) (models.PartialDate, bool) {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 10 && (s[10] == 'T' || s[10] == ' ') {
+		if _, err := time.Parse("2006-01-02", s[:10]); err == nil {
+			s = s[:10]
+		}
+	}
+
+	for _, l := range legacyDateLayouts {
+		if t, err := time.Parse(l.layout, s); err == nil {
+			date := models.NewDate(t.Year(), t.Month(), t.Day())
+			return models.PartialDate{Date: &date, Precision: l.precision}, true
+		}
+	}
+
+	// "Spring 2013" / "2013 Spring": dicatat sebagai bulan pertama musim tersebut
+	if fields := strings.Fields(strings.ToLower(s)); len(fields) == 2 {
+		for _, order := range [][2]string{{fields[0], fields[1]}, {fields[1], fields[0]}} {
+			month, okSeason := seasonStartMonth(order[0])
+			year, err := strconv.Atoi(order[1])
+			if okSeason && err == nil && year >= 1900 && year <= 9999 {
+				date := models.NewDate(year, month, 1)
+				return models.PartialDate{Date: &date, Precision: models.PrecisionMonth}, true
+			}
+		}
+	}
+	return models.PartialDate{}, false
+}
+
+// seasonStartMonth mengembalikan bulan pertama sebuah musim tayang ("autumn" sama dengan fall)
+func seasonStartMonth(season string) (time.Month, bool) {
+	if season == "autumn" {
+		season = models.SeasonFall
+	}
+	for i, s := range BroadcastSeasons {
+		if s == season {
+			return time.Month(i*3 + 1), true
+		}
+	}
+	return 0, false
+}
+
+// ParseBroadcastSeason memvalidasi nama musim dari URL (tidak peka huruf besar, "autumn" diterima)
+func ParseBroadcastSeason(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if _, ok := seasonStartMonth(s); !ok {
+		return "", false
+	}
+	if s == "autumn" {
+		return models.SeasonFall, true
+	}
+	return s, true
+}
+
+// BroadcastSeason menghitung musim tayang dari tanggal mulai. Tanggal yang hanya diketahui
+// tahunnya tidak punya musim.
+func BroadcastSeason(start models.PartialDate) (string, *int) {
+	if !start.Known() || start.Precision == models.PrecisionYear {
+		return "", nil
+	}
+	year := start.Date.Year()
+	return BroadcastSeasons[(int(start.Date.Month())-1)/3], &year
+}
+
+// hasStarted mengembalikan true jika tanggal mulai pasti sudah lewat. Untuk presisi bulan atau
+// tahun, periodenya harus sudah selesai karena hari tayang pertama tidak diketahui.
+func hasStarted(start models.PartialDate, today time.Time) bool {
+	if !start.Known() {
+		return false
+	}
+	if start.Precision == models.PrecisionDay {
+		return !start.Date.After(today)
+	}
+	return start.End().Before(today)
+}
+
+// DeriveAiringStatus menghitung status tayang dari tanggal mulai dan selesai
+func DeriveAiringStatus(start, end models.PartialDate, now time.Time) string {
+	today := models.NewDate(now.Year(), now.Month(), now.Day()).Time
+	switch {
+	case end.Known() && end.End().Before(today):
+		return models.AiringStatusFinished
+	case hasStarted(start, today):
+		return models.AiringStatusAiring
+	}
+	return models.AiringStatusUpcoming
+}
+
+// ValidAiringStatus mengecek apakah status tayang dikenal
+func ValidAiringStatus(status string) bool {
+	for _, s := range AiringStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyReleaseInfo melengkapi data tayang anime sebelum disimpan: tanggal mulai diambil dari
+// release_date lama jika belum diisi, status tayang dihitung dari tanggal jika kosong, musim
+// tayang dihitung ulang dan release_date disamakan dengan tanggal mulai.
+func ApplyReleaseInfo(anime *models.Anime, now time.Time) error {
+	if !anime.StartDate.Known() && strings.TrimSpace(anime.ReleaseDate) != "" {
+		start, ok := ParseLegacyReleaseDate(anime.ReleaseDate)
+		if !ok {
+			return fmt.Errorf("%w: unrecognized releaseDate %q, expected YYYY, YYYY-MM or YYYY-MM-DD", ErrInvalidReleaseInfo, anime.ReleaseDate)
+		}
+		anime.StartDate = start
+	}
+	if anime.StartDate.Known() && anime.EndDate.Known() && anime.EndDate.End().Before(anime.StartDate.Date.Time) {
+		return fmt.Errorf("%w: end_date must not be before start_date", ErrInvalidReleaseInfo)
+	}
+
+	if anime.AiringStatus == "" {
+		anime.AiringStatus = DeriveAiringStatus(anime.StartDate, anime.EndDate, now)
+	} else if !ValidAiringStatus(anime.AiringStatus) {
+		return fmt.Errorf("%w: airing_status must be one of %s", ErrInvalidReleaseInfo, strings.Join(AiringStatuses, ", "))
+	}
+
+	anime.ReleaseDate = anime.StartDate.String()
+	anime.BroadcastSeason, anime.SeasonYear = BroadcastSeason(anime.StartDate)
+	return nil
+}
+
+// releaseColumns adalah kolom yang ditulis ulang oleh ApplyReleaseInfo
+var releaseColumns = []string{"release_date", "start_date", "start_precision", "end_date", "end_precision", "airing_status", "season", "season_year"}
+
+// SaveReleaseInfo menyimpan kolom tanggal, status dan musim tayang anime
+func SaveReleaseInfo(tx *gorm.DB, anime *models.Anime) error {
+	return tx.Model(anime).Select(releaseColumns).Updates(anime).Error
+}
+
+// MigrateReleaseDates mengisi start_date, status dan musim tayang dari release_date lama.
+// Anime yang release_date-nya tidak bisa dibaca dibiarkan dan dicatat di log.
+func MigrateReleaseDates(db *gorm.DB) error {
+	var animes []models.Anime
+	if err := db.Where("start_date IS NULL AND release_date <> ''").Find(&animes).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i := range animes {
+			anime := &animes[i]
+			if _, ok := ParseLegacyReleaseDate(anime.ReleaseDate); !ok {
+				log.Printf("Skipping release date migration for anime %d: unrecognized date %q", anime.ID, anime.ReleaseDate)
+				continue
+			}
+			// Status kolom baru masih default, jadi dihitung ulang dari tanggal
+			anime.AiringStatus = ""
+			if err := ApplyReleaseInfo(anime, now); err != nil {
+				return err
+			}
+			if err := SaveReleaseInfo(tx, anime); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// airingStatusRank mengurutkan status yang berjalan maju seiring waktu
+var airingStatusRank = map[string]int{
+	models.AiringStatusUpcoming: 0,
+	models.AiringStatusAiring:   1,
+	models.AiringStatusFinished: 2,
+}
+
+// UpdateAiringStatuses memajukan status tayang (upcoming -> airing -> finished) sesuai tanggal.
+// Status tidak pernah dimundurkan dan anime yang dibatalkan tidak disentuh.
+func UpdateAiringStatuses(db *gorm.DB, now time.Time) (int, error) {
+	var animes []models.Anime
+	err := db.Select("id", "start_date", "start_precision", "end_date", "end_precision", "airing_status").
+		Where("airing_status IN ?", []string{models.AiringStatusUpcoming, models.AiringStatusAiring}).
+		Find(&animes).Error
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, anime := range animes {
+		status := DeriveAiringStatus(anime.StartDate, anime.EndDate, now)
+		if airingStatusRank[status] <= airingStatusRank[anime.AiringStatus] {
+			continue
+		}
+		if err := db.Model(&models.Anime{}).Where("id = ?", anime.ID).Update("airing_status", status).Error; err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// StartAiringStatusWorker menjalankan UpdateAiringStatuses secara berkala di background
+func StartAiringStatusWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := UpdateAiringStatuses(DB, time.Now()); err != nil {
+				log.Println("Error updating airing statuses:", err)
+			}
+		}
+	}()
+}
+
+// ListSeasonAnime mengambil anime yang mulai tayang pada musim tertentu, urut tanggal mulai
+func ListSeasonAnime(db *gorm.DB, year int, season string, page, limit int) ([]models.Anime, int64, error) {
+	query := db.Model(&models.Anime{}).Where("season_year = ? AND season = ?", year, season)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	animes := []models.Anime{}
+	err := query.Preload("Genres").
+		Order("start_date, title, id").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&animes).Error
+	return animes, total, err
+}
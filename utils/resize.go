@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// ResizeImage mengubah ukuran gambar menjadi width x height dengan filter segitiga (bilinear)
+// yang dilebarkan saat mengecilkan, sehingga setiap piksel tujuan merata-ratakan semua piksel
+// sumber di bawahnya dan hasilnya tidak bergerigi. Dihitung dalam dua tahap (horizontal lalu
+// vertikal) pada warna premultiplied alpha.
+func ResizeImage(src image.Image, width, height int) *image.RGBA {
+	rgba := toRGBA(src)
+	srcW, srcH := rgba.Rect.Dx(), rgba.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if srcW == 0 || srcH == 0 || width <= 0 || height <= 0 {
+		return dst
+	}
+
+	// Tahap horizontal: srcW x srcH -> width x srcH
+	xWeights := resampleWeights(srcW, width)
+	tmp := make([]float64, width*srcH*4)
+	for y := 0; y < srcH; y++ {
+		row := rgba.Pix[y*rgba.Stride:]
+		for x, cw := range xWeights {
+			var r, g, b, a float64
+			for i, w := range cw.weights {
+				p := row[(cw.start+i)*4:]
+				r += float64(p[0]) * w
+				g += float64(p[1]) * w
+				b += float64(p[2]) * w
+				a += float64(p[3]) * w
+			}
+			t := tmp[(y*width+x)*4:]
+			t[0], t[1], t[2], t[3] = r, g, b, a
+		}
+	}
+
+	// Tahap vertikal: width x srcH -> width x height
+	yWeights := resampleWeights(srcH, height)
+	for y, cw := range yWeights {
+		out := dst.Pix[y*dst.Stride:]
+		for x := 0; x < width; x++ {
+			var r, g, b, a float64
+			for i, w := range cw.weights {
+				t := tmp[((cw.start+i)*width+x)*4:]
+				r += t[0] * w
+				g += t[1] * w
+				b += t[2] * w
+				a += t[3] * w
+			}
+			o := out[x*4:]
+			o[0], o[1], o[2], o[3] = clampChannel(r), clampChannel(g), clampChannel(b), clampChannel(a)
+		}
+	}
+	return dst
+}
+
+// FitWidth menghitung ukuran thumbnail selebar maxWidth dengan rasio asli dipertahankan.
+// Gambar yang lebih kecil dari maxWidth tidak diperbesar.
+func FitWidth(srcW, srcH, maxWidth int) (int, int) {
+	if srcW <= maxWidth {
+		return srcW, srcH
+	}
+	height := int(math.Round(float64(srcH) * float64(maxWidth) / float64(srcW)))
+	return maxWidth, max(height, 1)
+}
+
+// resampleWeight adalah bobot piksel sumber [start, start+len(weights)) untuk satu piksel tujuan
+type resampleWeight struct {
+	start   int
+	weights []float64
+}
+
+// resampleWeights menghitung bobot filter segitiga untuk setiap piksel tujuan pada satu sumbu
+func resampleWeights(srcSize, dstSize int) []resampleWeight {
+	scale := float64(srcSize) / float64(dstSize)
+	support := math.Max(scale, 1) // Radius filter dalam piksel sumber
+
+	weights := make([]resampleWeight, dstSize)
+	for i := range weights {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := max(int(math.Ceil(center-support)), 0)
+		end := min(int(math.Floor(center+support)), srcSize-1)
+
+		var sum float64
+		ws := make([]float64, 0, end-start+1)
+		for j := start; j <= end; j++ {
+			w := 1 - math.Abs(float64(j)-center)/support
+			if w < 0 {
+				w = 0
+			}
+			ws = append(ws, w)
+			sum += w
+		}
+		if sum == 0 {
+			// Hanya terjadi jika pusat tepat di antara dua piksel tanpa jangkauan; pakai piksel terdekat
+			nearest := min(max(int(math.Round(center)), 0), srcSize-1)
+			weights[i] = resampleWeight{start: nearest, weights: []float64{1}}
+			continue
+		}
+		for j := range ws {
+			ws[j] /= sum
+		}
+		weights[i] = resampleWeight{start: start, weights: ws}
+	}
+	return weights
+}
+
+// toRGBA menyalin gambar ke *image.RGBA yang dimulai dari titik (0, 0)
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, src, b.Min, draw.Src)
+	return rgba
+}
+
+func clampChannel(v float64) uint8 {
+	v = math.Round(v)
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationStore menyimpan JWT yang sudah dicabut berdasarkan jti sampai token tersebut kedaluwarsa
+type RevocationStore interface {
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+	PurgeExpired() error
+}
+
+// Revocations adalah store yang dipakai AddToBlacklist/IsBlacklisted.
+// Default-nya in-memory (untuk test); InitDB menggantinya dengan versi database.
+var Revocations RevocationStore = NewMemoryRevocationStore()
+
+// MemoryRevocationStore menyimpan pencabutan di memori proses
+type MemoryRevocationStore struct {
+	mu sync.RWMutex
+	m  map[string]time.Time
+}
+
+// NewMemoryRevocationStore membuat store in-memory kosong
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{m: make(map[string]time.Time)}
+}
+
+func (s *MemoryRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	expiresAt, exists := s.m[jti]
+	return exists && time.Now().Before(expiresAt), nil
+}
+
+func (s *MemoryRevocationStore) PurgeExpired() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for jti, expiresAt := range s.m {
+		if !now.Before(expiresAt) {
+			delete(s.m, jti)
+		}
+	}
+	return nil
+}
+
+// DBRevocationStore menyimpan pencabutan di tabel revoked_tokens sehingga berlaku
+// di semua replika dan tetap ada setelah restart
+type DBRevocationStore struct {
+	db *gorm.DB
+}
+
+// NewDBRevocationStore membuat store berbasis database
+func NewDBRevocationStore(db *gorm.DB) *DBRevocationStore {
+	return &DBRevocationStore{db: db}
+}
+
+func (s *DBRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	entry := models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error
+}
+
+func (s *DBRevocationStore) IsRevoked(jti string) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.RevokedToken{}).
+		Where("jti = ? AND expires_at > ?", jti, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *DBRevocationStore) PurgeExpired() error {
+	return s.db.Where("expires_at <= ?", time.Now()).Delete(&models.RevokedToken{}).Error
+}
+
+// StartRevocationPurger menghapus entri yang sudah kedaluwarsa secara berkala
+func StartRevocationPurger(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := Revocations.PurgeExpired(); err != nil {
+				log.Println("Error purging revoked tokens:", err)
+			}
+		}
+	}()
+}
+
+// revocationKey mengambil jti dan waktu kedaluwarsa dari token. Token lama tanpa jti
+// memakai hash token sebagai kuncinya.
+func revocationKey(tokenString string) (string, time.Time) {
+	claims := &CustomClaims{}
+	expiresAt := time.Now().Add(AccessTokenTTL)
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err == nil {
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.Time
+		}
+		if strings.TrimSpace(claims.ID) != "" {
+			return claims.ID, expiresAt
+		}
+	}
+	return "sha256:" + HashOpaqueToken(tokenString), expiresAt
+}
+
+// AddToBlacklist menambahkan token ke blacklist
+func AddToBlacklist(token string) {
+	jti, expiresAt := revocationKey(token)
+	if err := Revocations.Revoke(jti, expiresAt); err != nil {
+		log.Println("Error revoking token:", err)
+	}
+}
+
+// IsBlacklisted memeriksa apakah token ada di blacklist
+func IsBlacklisted(token string) bool {
+	jti, _ := revocationKey(token)
+	revoked, err := Revocations.IsRevoked(jti)
+	if err != nil {
+		// Gagal tertutup: jika store tidak bisa dicek, token dianggap dicabut
+		log.Println("Error checking token revocation:", err)
+		return true
+	}
+	return revoked
+}
@@ -0,0 +1,438 @@
+package utils
+
+import (
+	"errors"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// SearchDocument adalah data satu anime yang diindeks
+type SearchDocument struct {
+	ID          uint
+	Title       string
+	AltTitles   []string
+	Description string
+}
+
+// SearchHit adalah satu hasil pencarian beserta skor relevansinya
+type SearchHit struct {
+	ID    uint    `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// SearchIndex adalah backend pencarian anime
+type SearchIndex interface {
+	Index(doc SearchDocument) error
+	Remove(id uint) error
+	Search(query string, limit int) ([]SearchHit, error)
+}
+
+// AnimeSearch adalah index yang dipakai aplikasi. Default in-memory; InitSearch memilih backend dari SEARCH_BACKEND.
+var AnimeSearch SearchIndex = NewMemorySearchIndex()
+
+// Bobot field saat menghitung skor
+const (
+	titleWeight       = 3.0
+	altTitleWeight    = 2.5
+	descriptionWeight = 1.0
+)
+
+// Bobot jenis kecocokan term
+const (
+	exactMatchWeight  = 1.0
+	prefixMatchWeight = 0.7
+	fuzzyMatchWeight  = 0.45
+)
+
+// Tokenize memecah teks menjadi term huruf kecil. Teks CJK (tanpa spasi) dipecah menjadi bigram.
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		if isCJK(word[0]) && len(word) > 1 {
+			for i := 0; i+1 < len(word); i++ {
+				tokens = append(tokens, string(word[i:i+2]))
+			}
+		} else {
+			tokens = append(tokens, string(word))
+		}
+		word = word[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			if len(word) > 0 && !isCJK(word[0]) {
+				flush()
+			}
+			word = append(word, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(word) > 0 && isCJK(word[0]) {
+				flush()
+			}
+			word = append(word, r)
+		case r == '\'' || r == '’':
+			// Apostrof diabaikan agar "Frieren's" cocok dengan "frierens"
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	// 'ー' (tanda vokal panjang) termasuk skrip Common, bukan Katakana
+	return r == 'ー' || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// MemorySearchIndex adalah inverted index di memori proses. Cocok untuk satu replika;
+// gunakan backend mysql jika API dijalankan di beberapa replika.
+type MemorySearchIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[uint]float64 // term -> id anime -> bobot field
+	docTerms map[uint][]string
+	terms    []string // Daftar term terurut untuk pencarian prefix
+	dirty    bool
+}
+
+// NewMemorySearchIndex membuat index kosong
+func NewMemorySearchIndex() *MemorySearchIndex {
+	return &MemorySearchIndex{
+		postings: make(map[string]map[uint]float64),
+		docTerms: make(map[uint][]string),
+	}
+}
+
+// Index menambahkan atau mengganti dokumen
+func (idx *MemorySearchIndex) Index(doc SearchDocument) error {
+	weights := make(map[string]float64)
+	add := func(text string, weight float64) {
+		for _, term := range Tokenize(text) {
+			if weight > weights[term] {
+				weights[term] = weight
+			}
+		}
+	}
+	add(doc.Description, descriptionWeight)
+	for _, alt := range doc.AltTitles {
+		add(alt, altTitleWeight)
+	}
+	add(doc.Title, titleWeight)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(doc.ID)
+
+	terms := make([]string, 0, len(weights))
+	for term, weight := range weights {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[uint]float64)
+			idx.dirty = true
+		}
+		idx.postings[term][doc.ID] = weight
+		terms = append(terms, term)
+	}
+	idx.docTerms[doc.ID] = terms
+	return nil
+}
+
+// Remove menghapus dokumen dari index
+func (idx *MemorySearchIndex) Remove(id uint) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(id)
+	return nil
+}
+
+func (idx *MemorySearchIndex) removeLocked(id uint) {
+	for _, term := range idx.docTerms[id] {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+			idx.dirty = true
+		}
+	}
+	delete(idx.docTerms, id)
+}
+
+// sortedTerms mengembalikan daftar term terurut, dibangun ulang jika index berubah
+func (idx *MemorySearchIndex) sortedTerms() []string {
+	idx.mu.RLock()
+	if !idx.dirty {
+		terms := idx.terms
+		idx.mu.RUnlock()
+		return terms
+	}
+	idx.mu.RUnlock()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.dirty {
+		idx.terms = make([]string, 0, len(idx.postings))
+		for term := range idx.postings {
+			idx.terms = append(idx.terms, term)
+		}
+		sort.Strings(idx.terms)
+		idx.dirty = false
+	}
+	return idx.terms
+}
+
+// Search mencari dokumen yang cocok dengan query. Setiap term query dicocokkan persis,
+// sebagai prefix (untuk autocomplete) atau dengan toleransi typo; dokumen yang cocok
+// dengan lebih banyak term dan pada field judul mendapat skor lebih tinggi.
+func (idx *MemorySearchIndex) Search(query string, limit int) ([]SearchHit, error) {
+	queryTerms := Tokenize(query)
+	if len(queryTerms) == 0 {
+		return []SearchHit{}, nil
+	}
+	terms := idx.sortedTerms()
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	total := float64(len(idx.docTerms))
+	scores := make(map[uint]float64)
+	matched := make(map[uint]int)
+
+	for _, qt := range queryTerms {
+		best := make(map[uint]float64)
+		consider := func(term string, matchWeight float64) {
+			docs := idx.postings[term]
+			idf := math.Log(1 + total/float64(len(docs)))
+			for id, fieldWeight := range docs {
+				if s := matchWeight * fieldWeight * idf; s > best[id] {
+					best[id] = s
+				}
+			}
+		}
+
+		if _, ok := idx.postings[qt]; ok {
+			consider(qt, exactMatchWeight)
+		}
+
+		// Prefix: term yang diawali qt (mis. "frier" -> "frieren")
+		qtLen := len([]rune(qt))
+		if qtLen >= 2 {
+			for i := sort.SearchStrings(terms, qt); i < len(terms) && strings.HasPrefix(terms[i], qt); i++ {
+				if terms[i] != qt {
+					// Prefix yang lebih pendek dari term lengkap mendapat bobot lebih kecil
+					ratio := float64(qtLen) / float64(len([]rune(terms[i])))
+					consider(terms[i], prefixMatchWeight*(0.5+0.5*ratio))
+				}
+			}
+		}
+
+		// Typo: jarak edit 1 untuk term 4-7 karakter, 2 untuk term yang lebih panjang
+		if maxDist := typoTolerance(qtLen); maxDist > 0 {
+			for _, term := range terms {
+				termLen := len([]rune(term))
+				if term == qt || termLen < qtLen-maxDist || termLen > qtLen+maxDist {
+					continue
+				}
+				if d := editDistance(qt, term, maxDist); d <= maxDist {
+					consider(term, fuzzyMatchWeight/float64(d))
+				}
+			}
+		}
+
+		for id, s := range best {
+			scores[id] += s
+			matched[id]++
+		}
+	}
+
+	hits := make([]SearchHit, 0, len(scores))
+	for id, score := range scores {
+		// Dokumen yang cocok dengan semua term query diutamakan
+		coverage := float64(matched[id]) / float64(len(queryTerms))
+		hits = append(hits, SearchHit{ID: id, Score: math.Round(score*coverage*coverage*1000) / 1000})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func typoTolerance(length int) int {
+	switch {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	}
+	return 0
+}
+
+// editDistance menghitung jarak Damerau-Levenshtein (optimal string alignment),
+// berhenti lebih awal jika jarak pasti melebihi max
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// MySQLSearchIndex memakai index FULLTEXT MySQL pada tabel animes dan anime_titles. Data dibaca langsung
+// dari tabel sehingga Index dan Remove tidak perlu melakukan apa-apa. Tidak ada toleransi typo.
+type MySQLSearchIndex struct {
+	db *gorm.DB
+}
+
+// NewMySQLSearchIndex membuat backend FULLTEXT dan memastikan index-nya ada
+func NewMySQLSearchIndex(db *gorm.DB) (*MySQLSearchIndex, error) {
+	// Judul alternatif memakai parser ngram agar judul Jepang/Cina tanpa spasi tetap bisa dicari
+	indexes := []struct{ table, name, ddl string }{
+		{"animes", "idx_animes_fulltext", "CREATE FULLTEXT INDEX idx_animes_fulltext ON animes (title, description)"},
+		{"anime_titles", "idx_anime_titles_fulltext", "CREATE FULLTEXT INDEX idx_anime_titles_fulltext ON anime_titles (title) WITH PARSER ngram"},
+	}
+	for _, index := range indexes {
+		var count int64
+		err := db.Raw("SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?", index.table, index.name).
+			Scan(&count).Error
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			if err := db.Exec(index.ddl).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &MySQLSearchIndex{db: db}, nil
+}
+
+func (idx *MySQLSearchIndex) Index(doc SearchDocument) error { return nil }
+
+func (idx *MySQLSearchIndex) Remove(id uint) error { return nil }
+
+// Search memakai BOOLEAN MODE: setiap term wajib ada, term terakhir dicocokkan sebagai prefix
+func (idx *MySQLSearchIndex) Search(query string, limit int) ([]SearchHit, error) {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return []SearchHit{}, nil
+	}
+	for i, term := range terms {
+		terms[i] = "+" + term
+	}
+	terms[len(terms)-1] += "*"
+	boolean := strings.Join(terms, " ")
+
+	// Skor judul alternatif diambil yang tertinggi per anime
+	titleScores := idx.db.Table("anime_titles").
+		Select("anime_id, MAX(MATCH(title) AGAINST (? IN BOOLEAN MODE)) AS score", boolean).
+		Where("MATCH(title) AGAINST (? IN BOOLEAN MODE)", boolean).
+		Group("anime_id")
+
+	hits := []SearchHit{}
+	err := idx.db.Table("animes").
+		Select("animes.id, GREATEST(MATCH(animes.title, animes.description) AGAINST (? IN BOOLEAN MODE), COALESCE(ts.score, 0)) AS score", boolean).
+		Joins("LEFT JOIN (?) ts ON ts.anime_id = animes.id", titleScores).
+		Where("MATCH(animes.title, animes.description) AGAINST (? IN BOOLEAN MODE) OR ts.anime_id IS NOT NULL", boolean).
+		Order("score DESC, animes.id").
+		Limit(limit).
+		Scan(&hits).Error
+	return hits, err
+}
+
+// AnimeSearchDocument membuat dokumen index dari data anime dan judul alternatifnya
+func AnimeSearchDocument(anime models.Anime, titles []models.AnimeTitle) SearchDocument {
+	doc := SearchDocument{ID: anime.ID, Title: anime.Title, Description: anime.Description}
+	for _, t := range titles {
+		doc.AltTitles = append(doc.AltTitles, t.Title)
+	}
+	return doc
+}
+
+// SyncAnimeIndex memperbarui index untuk satu anime; anime yang sudah dihapus dikeluarkan dari index
+func SyncAnimeIndex(id uint) {
+	var anime models.Anime
+	err := DB.First(&anime, id).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = AnimeSearch.Remove(id)
+	case err == nil:
+		var titles []models.AnimeTitle
+		if err = DB.Where("anime_id = ?", id).Find(&titles).Error; err == nil {
+			err = AnimeSearch.Index(AnimeSearchDocument(anime, titles))
+		}
+	}
+	if err != nil {
+		log.Printf("Error updating search index for anime %d: %v", id, err)
+	}
+}
+
+// InitSearch memilih backend pencarian dari SEARCH_BACKEND (memory atau mysql)
+// dan membangun index in-memory dari database
+func InitSearch() {
+	if os.Getenv("SEARCH_BACKEND") == "mysql" {
+		idx, err := NewMySQLSearchIndex(DB)
+		if err != nil {
+			log.Fatalf("Failed to initialize MySQL full-text search: %v", err)
+		}
+		AnimeSearch = idx
+		return
+	}
+
+	idx := NewMemorySearchIndex()
+	var animes []models.Anime
+	if err := DB.Find(&animes).Error; err != nil {
+		log.Fatalf("Failed to build search index: %v", err)
+	}
+	var titles []models.AnimeTitle
+	if err := DB.Order("id").Find(&titles).Error; err != nil {
+		log.Fatalf("Failed to build search index: %v", err)
+	}
+	titlesByAnime := make(map[uint][]models.AnimeTitle)
+	for _, t := range titles {
+		titlesByAnime[t.AnimeID] = append(titlesByAnime[t.AnimeID], t)
+	}
+	for _, anime := range animes {
+		idx.Index(AnimeSearchDocument(anime, titlesByAnime[anime.ID]))
+	}
+	AnimeSearch = idx
+	log.Printf("Search index built with %d anime", len(animes))
+}
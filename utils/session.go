@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval membatasi seberapa sering last_seen_at ditulis ke database
+const sessionTouchInterval = time.Minute
+
+var ErrSessionInactive = errors.New("session revoked or expired")
+
+// CreateSession mencatat login baru
+func CreateSession(userID int, userAgent, ip string, mfa bool) (*models.Session, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		MFA:        mfa,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL),
+	}
+	if err := DB.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ActiveSession mengambil sesi yang masih aktif
+func ActiveSession(sessionID string) (*models.Session, error) {
+	var session models.Session
+	if err := DB.Where("id = ?", sessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionInactive
+		}
+		return nil, err
+	}
+	if !session.Active() {
+		return nil, ErrSessionInactive
+	}
+	return &session, nil
+}
+
+// TouchSession memperbarui last_seen_at dan IP, paling sering sekali per sessionTouchInterval
+func TouchSession(session *models.Session, ip string) error {
+	if time.Since(session.LastSeenAt) < sessionTouchInterval && session.IP == ip {
+		return nil
+	}
+	return DB.Model(&models.Session{}).
+		Where("id = ?", session.ID).
+		Updates(map[string]interface{}{"last_seen_at": time.Now(), "ip": ip}).Error
+}
+
+// ExtendSession memperpanjang masa berlaku sesi setiap kali refresh token dirotasi
+func ExtendSession(sessionID string) error {
+	return DB.Model(&models.Session{}).
+		Where("id = ?", sessionID).
+		Update("expires_at", time.Now().Add(RefreshTokenTTL)).Error
+}
+
+// RevokeSession mencabut satu sesi milik pengguna beserta refresh token-nya
+func RevokeSession(userID int, sessionID string) error {
+	res := DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrSessionInactive
+	}
+	return RevokeRefreshTokenFamily(sessionID)
+}
+
+// RevokeAllSessions mencabut semua sesi pengguna kecuali exceptID ("log out everywhere")
+func RevokeAllSessions(userID int, exceptID string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		sessions := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		tokens := tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		if exceptID != "" {
+			sessions = sessions.Where("id <> ?", exceptID)
+			tokens = tokens.Where("family_id <> ?", exceptID)
+		}
+		if err := sessions.Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tokens.Update("revoked_at", time.Now()).Error
+	})
+}
+
+// ListActiveSessions mengembalikan sesi aktif pengguna, yang terakhir dipakai lebih dulu
+func ListActiveSessions(userID int) ([]models.Session, error) {
+	var sessions []models.Session
+	err := DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
@@ -0,0 +1,339 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ImageStorage menyimpan file gambar dan memberi URL publiknya
+type ImageStorage interface {
+	Put(key string, data []byte, contentType string) error
+	Delete(key string) error
+	URL(key string) string
+}
+
+// Images adalah storage gambar yang dipakai aplikasi, diatur oleh InitImageStorage
+var Images ImageStorage = NewLocalImageStorage("uploads", "/media")
+
+// LocalImageStorage menyimpan gambar di filesystem lokal. File disajikan oleh aplikasi
+// sendiri lewat MediaHandler pada BaseURL.
+type LocalImageStorage struct {
+	Dir     string
+	BaseURL string
+}
+
+// NewLocalImageStorage membuat storage filesystem di dir dengan URL publik baseURL
+func NewLocalImageStorage(dir, baseURL string) *LocalImageStorage {
+	return &LocalImageStorage{Dir: dir, BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// path mengubah key menjadi path file dan menolak key yang keluar dari Dir
+func (s *LocalImageStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(clean)), nil
+}
+
+func (s *LocalImageStorage) Put(key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Ditulis ke file sementara lalu di-rename agar pembaca tidak melihat file setengah jadi
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *LocalImageStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalImageStorage) URL(key string) string {
+	return s.BaseURL + "/" + key
+}
+
+// S3Config adalah konfigurasi storage S3 atau layanan yang kompatibel (MinIO, R2, dll.)
+type S3Config struct {
+	Endpoint  string // mis. "https://s3.ap-southeast-1.amazonaws.com" atau "http://localhost:9000"
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PublicURL string // URL publik bucket (CDN); kosong berarti Endpoint/Bucket
+}
+
+// S3ImageStorage menyimpan gambar di bucket S3 memakai path-style URL dan Signature V4
+type S3ImageStorage struct {
+	config S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3ImageStorage membuat storage S3. client nil berarti http.Client dengan timeout 30 detik.
+func NewS3ImageStorage(config S3Config, client *http.Client) *S3ImageStorage {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	config.PublicURL = strings.TrimRight(config.PublicURL, "/")
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	return &S3ImageStorage{config: config, client: client, now: time.Now}
+}
+
+// objectURL membuat URL objek path-style: endpoint/bucket/key
+func (s *S3ImageStorage) objectURL(key string) string {
+	return s.config.Endpoint + "/" + s.config.Bucket + s3EscapePath(key)
+}
+
+func (s *S3ImageStorage) Put(key string, data []byte, contentType string) error {
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	return s.do(req, data)
+}
+
+func (s *S3ImageStorage) Delete(key string) error {
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req, nil)
+}
+
+func (s *S3ImageStorage) URL(key string) string {
+	if s.config.PublicURL != "" {
+		return s.config.PublicURL + s3EscapePath(key)
+	}
+	return s.objectURL(key)
+}
+
+// do menandatangani dan mengirim request, lalu mengubah respons non-2xx menjadi error
+func (s *S3ImageStorage) do(req *http.Request, body []byte) error {
+	SignS3Request(req, body, s.config.AccessKey, s.config.SecretKey, s.config.Region, s.now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// SignS3Request menambahkan header AWS Signature Version 4 (service "s3") ke request.
+// Semua header yang sudah ada ikut ditandatangani, jadi header lain harus diset sebelumnya.
+func SignS3Request(req *http.Request, body []byte, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Header kanonik: nama huruf kecil, urut, ditambah host
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(strings.Fields(strings.Join(values, ",")), " ")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(strings.TrimPrefix(req.URL.EscapedPath(), "/")),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// s3EscapePath meng-escape setiap segmen path sesuai aturan URI encoding Signature V4
+func s3EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segment = unescaped
+		}
+		segments[i] = s3Escape(segment)
+	}
+	return "/" + strings.TrimPrefix(strings.Join(segments, "/"), "/")
+}
+
+// s3CanonicalQuery mengurutkan dan meng-escape parameter query
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape meng-escape semua karakter kecuali A-Z a-z 0-9 - _ . ~
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// MediaHandler menyajikan file dari storage lokal; untuk storage lain selalu 404
+// karena gambar diambil langsung dari URL bucket
+func MediaHandler(prefix string) http.Handler {
+	local, ok := Images.(*LocalImageStorage)
+	if !ok {
+		return http.NotFoundHandler()
+	}
+	return http.StripPrefix(prefix, http.FileServer(mediaFileSystem{http.Dir(local.Dir)}))
+}
+
+// mediaFileSystem hanya membuka file gambar biasa: direktori dan file .tmp yang sedang
+// ditulis Put dianggap tidak ada, jadi isi folder upload tidak bisa di-list
+type mediaFileSystem struct {
+	fs http.FileSystem
+}
+
+func (m mediaFileSystem) Open(name string) (http.File, error) {
+	if strings.HasSuffix(name, ".tmp") {
+		return nil, os.ErrNotExist
+	}
+	f, err := m.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, os.ErrNotExist
+	}
+	return f, nil
+}
+
+// InitImageStorage memilih storage gambar dari env:
+//   - STORAGE_BACKEND: local (default) atau s3
+//   - local: UPLOAD_DIR (default "uploads"), MEDIA_URL (default PUBLIC_URL/media)
+//   - s3: S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY, S3_PUBLIC_URL
+//   - IMAGE_MAX_BYTES: batas ukuran upload (default 5 MiB)
+func InitImageStorage() {
+	if v := os.Getenv("IMAGE_MAX_BYTES"); v != "" {
+		var limit int64
+		if _, err := fmt.Sscan(v, &limit); err != nil || limit <= 0 {
+			log.Fatalf("Invalid IMAGE_MAX_BYTES %q", v)
+		}
+		MaxImageBytes = limit
+	}
+
+	if os.Getenv("STORAGE_BACKEND") == "s3" {
+		config := S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		}
+		if config.Endpoint == "" || config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+			log.Fatalf("S3 storage requires S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY")
+		}
+		Images = NewS3ImageStorage(config, nil)
+		log.Printf("Image storage: s3 bucket %s at %s", config.Bucket, config.Endpoint)
+		return
+	}
+
+	dir := os.Getenv("UPLOAD_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	baseURL := os.Getenv("MEDIA_URL")
+	if baseURL == "" {
+		baseURL = PublicURL() + "/media"
+	}
+	Images = NewLocalImageStorage(dir, baseURL)
+	log.Printf("Image storage: local directory %s", dir)
+}
@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// maxAcceptLanguages membatasi jumlah bahasa yang dibaca dari header Accept-Language
+const maxAcceptLanguages = 10
+
+// TitleInput adalah satu judul alternatif dari request
+type TitleInput struct {
+	Title    string `json:"title"`
+	Language string `json:"language"`
+	Type     string `json:"type"`
+}
+
+// NormalizeLanguageTag merapikan tag bahasa ("ja-Latn" -> "ja-latn", "en_US" -> "en-us").
+// Mengembalikan false jika tag tidak valid.
+func NormalizeLanguageTag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if !languageTagPattern.MatchString(tag) {
+		return "", false
+	}
+	return tag, true
+}
+
+// ParseAcceptLanguage membaca header Accept-Language dan mengembalikan tag bahasa
+// terurut dari kualitas (q) tertinggi. Tag dengan q=0 dan "*" diabaikan.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag, ok := NormalizeLanguageTag(fields[0])
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				v, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil || v < 0 || v > 1 {
+					v = 0
+				}
+				q = v
+			}
+		}
+		if q > 0 {
+			entries = append(entries, weighted{tag, q})
+		}
+		if len(entries) == maxAcceptLanguages {
+			break
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+
+	tags := make([]string, len(entries))
+	for i, e := range entries {
+		tags[i] = e.tag
+	}
+	return tags
+}
+
+// RequestLanguages menentukan urutan bahasa judul untuk request: parameter ?lang=,
+// lalu preferensi user yang login, lalu header Accept-Language
+func RequestLanguages(r *http.Request) []string {
+	var langs []string
+	if tag, ok := NormalizeLanguageTag(r.URL.Query().Get("lang")); ok {
+		langs = append(langs, tag)
+	}
+	if userID, ok := r.Context().Value(UserIDKey).(int); ok && DB != nil {
+		var user models.User
+		if err := DB.Select("id", "preferred_language").First(&user, userID).Error; err == nil && user.PreferredLanguage != "" {
+			langs = append(langs, user.PreferredLanguage)
+		}
+	}
+	return append(langs, ParseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+}
+
+// PreferredTitle memilih judul yang paling cocok dengan bahasa pilihan. Untuk setiap bahasa
+// (berurutan) dicari tag yang sama persis, lalu tag dengan bahasa dasar yang sama
+// ("en-us" cocok dengan "en"; "ja" lebih memilih "ja" daripada "ja-latn"). Judul official
+// didahulukan daripada sinonim. Mengembalikan false jika tidak ada yang cocok.
+func PreferredTitle(titles []models.AnimeTitle, languages []string) (string, bool) {
+	rank := func(t models.AnimeTitle) int {
+		if t.Type == models.TitleTypeOfficial {
+			return 0
+		}
+		return 1
+	}
+	best := func(match func(models.AnimeTitle) bool) (string, bool) {
+		found, bestRank := -1, 2
+		for i, t := range titles {
+			if match(t) && rank(t) < bestRank {
+				found, bestRank = i, rank(t)
+			}
+		}
+		if found < 0 {
+			return "", false
+		}
+		return titles[found].Title, true
+	}
+
+	for _, lang := range languages {
+		base := strings.SplitN(lang, "-", 2)[0]
+		if title, ok := best(func(t models.AnimeTitle) bool { return t.Language == lang }); ok {
+			return title, true
+		}
+		if title, ok := best(func(t models.AnimeTitle) bool { return t.Language == base }); ok {
+			return title, true
+		}
+		if title, ok := best(func(t models.AnimeTitle) bool { return strings.HasPrefix(t.Language, base+"-") }); ok {
+			return title, true
+		}
+	}
+	return "", false
+}
+
+// loadAnimeTitles mengambil judul alternatif untuk beberapa anime, dikelompokkan per anime
+func loadAnimeTitles(db *gorm.DB, animeIDs []uint) (map[uint][]models.AnimeTitle, error) {
+	grouped := make(map[uint][]models.AnimeTitle)
+	if len(animeIDs) == 0 {
+		return grouped, nil
+	}
+	var titles []models.AnimeTitle
+	if err := db.Where("anime_id IN ?", animeIDs).Order("id").Find(&titles).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range titles {
+		grouped[t.AnimeID] = append(grouped[t.AnimeID], t)
+	}
+	return grouped, nil
+}
+
+// DisplayTitles menghitung judul tampilan untuk setiap anime. Anime tanpa judul alternatif
+// yang cocok tidak ada di map, sehingga pemanggil memakai judul utama.
+func DisplayTitles(db *gorm.DB, animeIDs []uint, languages []string) (map[uint]string, error) {
+	result := make(map[uint]string)
+	if len(languages) == 0 || len(animeIDs) == 0 {
+		return result, nil
+	}
+	grouped, err := loadAnimeTitles(db, animeIDs)
+	if err != nil {
+		return nil, err
+	}
+	for id, titles := range grouped {
+		if title, ok := PreferredTitle(titles, languages); ok {
+			result[id] = title
+		}
+	}
+	return result, nil
+}
+
+// RequestDisplayTitles menghitung judul tampilan untuk beberapa anime sesuai bahasa request
+func RequestDisplayTitles(r *http.Request, animeIDs []uint) (map[uint]string, error) {
+	return DisplayTitles(DB, animeIDs, RequestLanguages(r))
+}
+
+// ListAnimeTitles mengambil judul alternatif sebuah anime
+func ListAnimeTitles(animeID uint) ([]models.AnimeTitle, error) {
+	if err := ensureAnime(DB, animeID); err != nil {
+		return nil, err
+	}
+	titles := []models.AnimeTitle{}
+	err := DB.Where("anime_id = ?", animeID).Order("language, id").Find(&titles).Error
+	return titles, err
+}
+
+// NormalizeTitleInputs memvalidasi judul alternatif dan membuang duplikat
+func NormalizeTitleInputs(inputs []TitleInput) ([]TitleInput, error) {
+	type key struct{ title, language string }
+	seen := make(map[key]bool)
+	result := []TitleInput{}
+	for _, in := range inputs {
+		in.Title = strings.Join(strings.Fields(in.Title), " ")
+		if in.Title == "" {
+			return nil, errors.New("title is required")
+		}
+		if len([]rune(in.Title)) > 255 {
+			return nil, errors.New("title must be at most 255 characters")
+		}
+		lang, ok := NormalizeLanguageTag(in.Language)
+		if !ok {
+			return nil, fmt.Errorf("invalid language %q", in.Language)
+		}
+		in.Language = lang
+		in.Type = strings.ToLower(strings.TrimSpace(in.Type))
+		switch in.Type {
+		case "":
+			in.Type = models.TitleTypeOfficial
+		case models.TitleTypeOfficial, models.TitleTypeSynonym, models.TitleTypeShort:
+		default:
+			return nil, fmt.Errorf("title type must be %s, %s or %s", models.TitleTypeOfficial, models.TitleTypeSynonym, models.TitleTypeShort)
+		}
+		k := key{strings.ToLower(in.Title), in.Language}
+		if !seen[k] {
+			seen[k] = true
+			result = append(result, in)
+		}
+	}
+	return result, nil
+}
+
+// SetAnimeTitles mengganti semua judul alternatif sebuah anime
+func SetAnimeTitles(animeID uint, inputs []TitleInput) ([]models.AnimeTitle, error) {
+	titles := make([]models.AnimeTitle, len(inputs))
+	for i, in := range inputs {
+		titles[i] = models.AnimeTitle{AnimeID: animeID, Title: in.Title, Language: in.Language, Type: in.Type}
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureAnime(tx, animeID); err != nil {
+			return err
+		}
+		if err := tx.Where("anime_id = ?", animeID).Delete(&models.AnimeTitle{}).Error; err != nil {
+			return err
+		}
+		if len(titles) == 0 {
+			return nil
+		}
+		return tx.Create(&titles).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	SyncAnimeIndex(animeID)
+	return titles, nil
+}
+
+// DeleteAnimeTitles menghapus judul alternatif sebuah anime, dipakai saat anime dihapus
+func DeleteAnimeTitles(tx *gorm.DB, animeID uint) error {
+	return tx.Where("anime_id = ?", animeID).Delete(&models.AnimeTitle{}).Error
+}
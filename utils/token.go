@@ -1,22 +1,102 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"time"
 
+	"NYANIMEBACKEND/models"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// GenerateToken menghasilkan JWT untuk pengguna
-func GenerateToken(userID int, role string) (string, error) {
+// Masa berlaku access token (JWT) dan refresh token
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// MFAPendingTTL adalah masa berlaku token sementara antara langkah password dan langkah 2FA
+const MFAPendingTTL = 5 * time.Minute
+
+// GenerateToken menghasilkan JWT untuk pengguna yang terikat pada sesi login
+func GenerateToken(user models.User, session models.Session) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	// Permission diambil dari role saat token dibuat
+	perms, err := ResolvePermissions(user.Role)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &CustomClaims{
+		UserID:       user.ID,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+		SessionID:    session.ID,
+		MFA:          session.MFA,
+		Permissions:  perms,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti, // Dipakai sebagai kunci pencabutan token saat logout
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)), // Access token berumur pendek, diperpanjang lewat refresh token
+			Issuer:    "Nyanime",                                   // Ganti dengan nama aplikasi Anda
+		},
+	}
+
+	return CurrentKeys().Sign(claims)
+}
+
+// GenerateMFAPendingToken menghasilkan token terbatas setelah password benar tetapi sebelum kode 2FA diverifikasi.
+// Token ini ditolak AuthMiddleware dan hanya bisa ditukar lewat endpoint login 2FA.
+func GenerateMFAPendingToken(user models.User) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := &CustomClaims{
-		UserID: userID,
-		Role:   role,
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
+		MFAPending:   true,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // Token berlaku selama 24 jam
-			Issuer:    "Nyanime",                                          // Ganti dengan nama aplikasi Anda
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(MFAPendingTTL)),
+			Issuer:    "Nyanime",
 		},
 	}
+	return CurrentKeys().Sign(claims)
+}
+
+// NewOpaqueToken membuat token acak untuk klien beserta hash SHA-256 yang disimpan di database
+func NewOpaqueToken() (raw string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	raw = base64.RawURLEncoding.EncodeToString(b)
+	return raw, HashOpaqueToken(raw), nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte("G3n3r@t3dS3cr3tK3y!2024")) // Ganti dengan kunci rahasia Anda
+// HashOpaqueToken menghitung hash dari token opaque sehingga token asli tidak pernah disimpan
+func HashOpaqueToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex menghasilkan string hex acak sepanjang n byte
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parameter TOTP sesuai default RFC 6238 yang didukung semua aplikasi authenticator
+const (
+	TOTPPeriod = 30
+	TOTPDigits = 6
+	TOTPIssuer = "Nyanime"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// RequireAdmin2FA mengecek REQUIRE_ADMIN_2FA; jika true admin dan role dengan permission
+// admin wajib login dengan 2FA (lihat RoleRequiresTwoFactor)
+func RequireAdmin2FA() bool {
+	v, _ := strconv.ParseBool(os.Getenv("REQUIRE_ADMIN_2FA"))
+	return v
+}
+
+// GenerateTOTPSecret membuat secret acak 160-bit dalam format base32
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI membuat URI otpauth:// yang bisa dijadikan QR code oleh frontend
+func TOTPProvisioningURI(account, secret string) string {
+	label := url.PathEscape(TOTPIssuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", TOTPIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", strconv.Itoa(TOTPDigits))
+	params.Set("period", strconv.Itoa(TOTPPeriod))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// hotp menghitung kode HOTP (RFC 4226) untuk counter tertentu
+func hotp(key []byte, counter int64, digits int) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	return totpEncoding.DecodeString(strings.ToUpper(strings.ReplaceAll(secret, " ", "")))
+}
+
+// TOTPCode menghitung kode TOTP untuk waktu t
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, t.Unix()/TOTPPeriod, TOTPDigits), nil
+}
+
+// ValidateTOTP memvalidasi kode dengan toleransi satu periode sebelum/sesudah.
+// Counter yang cocok harus lebih besar dari lastCounter agar kode yang sama tidak bisa dipakai ulang.
+func ValidateTOTP(secret, code string, t time.Time, lastCounter int64) (int64, bool) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := t.Unix() / TOTPPeriod
+	for counter := current - 1; counter <= current+1; counter++ {
+		if counter <= lastCounter {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, counter, TOTPDigits)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes membuat n kode pemulihan dengan format xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode menyeragamkan input kode pemulihan sebelum di-hash
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"NYANIMEBACKEND/models"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeCount adalah jumlah kode pemulihan yang dibuat saat 2FA diaktifkan
+const RecoveryCodeCount = 10
+
+// ErrTwoFactorAlreadyEnabled dikembalikan jika 2FA sudah aktif saat akan diaktifkan
+var ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+// ReplaceRecoveryCodes menghapus kode pemulihan lama dan membuat yang baru.
+// Kode asli hanya dikembalikan sekali ke pengguna.
+func ReplaceRecoveryCodes(userID int) ([]string, error) {
+	codes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// EnableTwoFactor mengaktifkan 2FA, menyimpan kode pemulihan baru dan menandai sesi sebagai
+// sesi ber-2FA dalam satu transaksi. Update bersyarat totp_enabled = false memastikan dua
+// request bersamaan tidak sama-sama berhasil dan menimpa kode pemulihan satu sama lain.
+func EnableTwoFactor(userID int, counter int64, sessionID string) ([]string, error) {
+	codes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.User{}).
+			Where("id = ? AND totp_enabled = ?", userID, false).
+			Updates(map[string]interface{}{"totp_enabled": true, "totp_last_counter": counter})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrTwoFactorAlreadyEnabled
+		}
+		if err := replaceRecoveryCodes(tx, userID, codes); err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).Where("id = ?", sessionID).Update("mfa", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID int, codes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	for _, code := range codes {
+		entry := models.RecoveryCode{UserID: userID, CodeHash: HashOpaqueToken(NormalizeRecoveryCode(code))}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConsumeRecoveryCode memakai satu kode pemulihan; mengembalikan false jika kode salah atau sudah dipakai
+func ConsumeRecoveryCode(userID int, code string) (bool, error) {
+	res := DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, HashOpaqueToken(NormalizeRecoveryCode(code))).
+		Limit(1).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// VerifySecondFactor memeriksa kode TOTP atau kode pemulihan milik pengguna.
+// Counter TOTP yang dipakai disimpan agar kode yang sama tidak bisa dipakai dua kali.
+func VerifySecondFactor(user models.User, code, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		return ConsumeRecoveryCode(user.ID, recoveryCode)
+	}
+	if user.TOTPSecret == "" {
+		return false, nil
+	}
+
+	counter, ok := ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastCounter)
+	if !ok {
+		return false, nil
+	}
+	res := DB.Model(&models.User{}).
+		Where("id = ? AND totp_last_counter < ?", user.ID, counter).
+		Update("totp_last_counter", counter)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WatchOrderNode adalah satu anime dalam graf urutan tonton
+type WatchOrderNode struct {
+	ID          uint
+	ReleaseDate string // Dipakai untuk memilih urutan di antara anime yang tidak saling bergantung
+}
+
+// WatchOrderEdge berarti Before harus ditonton sebelum After
+type WatchOrderEdge struct {
+	Before uint
+	After  uint
+}
+
+// RelationCycleError dikembalikan jika relasi membentuk siklus, misalnya A sekuel B dan B sekuel A
+type RelationCycleError struct {
+	Cycle []uint // Anime dalam siklus; elemen pertama diulang di akhir
+}
+
+func (e *RelationCycleError) Error() string {
+	ids := make([]string, len(e.Cycle))
+	for i, id := range e.Cycle {
+		ids[i] = fmt.Sprint(id)
+	}
+	return "relation cycle detected: " + strings.Join(ids, " -> ")
+}
+
+// WatchOrder mengurutkan anime secara topologis (algoritma Kahn). Jika beberapa anime siap
+// ditonton bersamaan, yang rilis lebih dulu didahulukan, lalu ID terkecil.
+// Edge ke anime di luar nodes diabaikan.
+func WatchOrder(nodes []WatchOrderNode, edges []WatchOrderEdge) ([]uint, error) {
+	byID := make(map[uint]WatchOrderNode, len(nodes))
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+
+	next := make(map[uint][]uint)
+	indegree := make(map[uint]int, len(nodes))
+	seen := make(map[WatchOrderEdge]bool)
+	for _, e := range edges {
+		_, okBefore := byID[e.Before]
+		_, okAfter := byID[e.After]
+		if !okBefore || !okAfter || seen[e] {
+			continue
+		}
+		if e.Before == e.After {
+			return nil, &RelationCycleError{Cycle: []uint{e.Before, e.Before}}
+		}
+		seen[e] = true
+		next[e.Before] = append(next[e.Before], e.After)
+		indegree[e.After]++
+	}
+
+	ready := &watchQueue{}
+	for _, n := range nodes {
+		if indegree[n.ID] == 0 {
+			heap.Push(ready, n)
+		}
+	}
+
+	order := make([]uint, 0, len(nodes))
+	for ready.Len() > 0 {
+		n := heap.Pop(ready).(WatchOrderNode)
+		order = append(order, n.ID)
+		for _, id := range next[n.ID] {
+			indegree[id]--
+			if indegree[id] == 0 {
+				heap.Push(ready, byID[id])
+			}
+		}
+	}
+
+	if len(order) < len(byID) {
+		return nil, &RelationCycleError{Cycle: findCycle(next, indegree)}
+	}
+	return order, nil
+}
+
+// findCycle mencari satu siklus di antara node yang tersisa (indegree > 0) dengan DFS
+func findCycle(next map[uint][]uint, indegree map[uint]int) []uint {
+	var remaining []uint
+	for id, d := range indegree {
+		if d > 0 {
+			remaining = append(remaining, id)
+		}
+	}
+	sort.Slice(remaining, func(i, j int) bool { return remaining[i] < remaining[j] })
+
+	const (
+		unvisited = iota
+		inStack
+		done
+	)
+	state := make(map[uint]int)
+	var stack []uint
+	var cycle []uint
+
+	var visit func(id uint) bool
+	visit = func(id uint) bool {
+		state[id] = inStack
+		stack = append(stack, id)
+		for _, to := range next[id] {
+			if indegree[to] == 0 {
+				continue
+			}
+			switch state[to] {
+			case inStack:
+				for i, s := range stack {
+					if s == to {
+						cycle = append(append([]uint{}, stack[i:]...), to)
+						return true
+					}
+				}
+			case unvisited:
+				if visit(to) {
+					return true
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		return false
+	}
+
+	for _, id := range remaining {
+		if state[id] == unvisited && visit(id) {
+			return cycle
+		}
+	}
+	return remaining
+}
+
+// watchQueue adalah priority queue berdasarkan tanggal rilis lalu ID
+type watchQueue []WatchOrderNode
+
+func (q watchQueue) Len() int { return len(q) }
+func (q watchQueue) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	// Tanggal kosong (belum diketahui) ditaruh paling akhir
+	if a.ReleaseDate != b.ReleaseDate {
+		if a.ReleaseDate == "" || b.ReleaseDate == "" {
+			return b.ReleaseDate == ""
+		}
+		return a.ReleaseDate < b.ReleaseDate
+	}
+	return a.ID < b.ID
+}
+func (q watchQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *watchQueue) Push(x interface{}) { *q = append(*q, x.(WatchOrderNode)) }
+func (q *watchQueue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}